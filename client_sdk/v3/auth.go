@@ -42,6 +42,12 @@ type (
 	AuthRoleDeleteResponse           pb.AuthRoleDeleteResponse
 	AuthUserListResponse             pb.AuthUserListResponse
 	AuthRoleListResponse             pb.AuthRoleListResponse
+	AuthUserTokenCreateResponse      pb.AuthUserTokenCreateResponse
+	AuthUserTokenListResponse        pb.AuthUserTokenListResponse
+	AuthUserTokenRevokeResponse      pb.AuthUserTokenRevokeResponse
+	AuthCheckResponse                pb.AuthCheckResponse
+	AuthUserSetRateLimitResponse     pb.AuthUserSetRateLimitResponse
+	AuthRoleSetRateLimitResponse     pb.AuthRoleSetRateLimitResponse
 
 	PermissionType authpb.Permission_Type
 	Permission     authpb.Permission
@@ -55,6 +61,9 @@ const (
 
 type UserAddOptions authpb.UserAddOptions
 
+// RateLimit 用户或角色的速率限制, 0 表示不限制
+type RateLimit authpb.RateLimit
+
 type Auth interface {
 	Authenticate(ctx context.Context, name string, password string) (*AuthenticateResponse, error)
 	AuthEnable(ctx context.Context) (*AuthEnableResponse, error)
@@ -74,6 +83,18 @@ type Auth interface {
 	RoleList(ctx context.Context) (*AuthRoleListResponse, error)
 	RoleRevokePermission(ctx context.Context, role string, key, rangeEnd string) (*AuthRoleRevokePermissionResponse, error)
 	RoleDelete(ctx context.Context, role string) (*AuthRoleDeleteResponse, error)
+	// UserTokenCreate 创建一个ttl秒后过期的具名token, perms 必须是用户权限的子集
+	UserTokenCreate(ctx context.Context, user, name string, ttl int64, perms ...*Permission) (*AuthUserTokenCreateResponse, error)
+	UserTokenList(ctx context.Context, user string) (*AuthUserTokenListResponse, error)
+	UserTokenRevoke(ctx context.Context, user, name string) (*AuthUserTokenRevokeResponse, error)
+
+	// AuthCheck 检查用户对 [key, end) 是否拥有 permType 权限, 并返回原因
+	AuthCheck(ctx context.Context, user, key, end string, permType PermissionType) (*AuthCheckResponse, error)
+
+	// UserSetRateLimit 设置用户的速率限制, limit 为 nil 时取消限制
+	UserSetRateLimit(ctx context.Context, name string, limit *RateLimit) (*AuthUserSetRateLimitResponse, error)
+	// RoleSetRateLimit 设置角色的速率限制, 由拥有该角色的所有用户共享
+	RoleSetRateLimit(ctx context.Context, role string, limit *RateLimit) (*AuthRoleSetRateLimitResponse, error)
 }
 
 type authClient struct {
@@ -196,6 +217,43 @@ func (auth *authClient) RoleDelete(ctx context.Context, role string) (*AuthRoleD
 	return (*AuthRoleDeleteResponse)(resp), toErr(ctx, err)
 }
 
+func (auth *authClient) UserTokenCreate(ctx context.Context, user, name string, ttl int64, perms ...*Permission) (*AuthUserTokenCreateResponse, error) {
+	req := &pb.AuthUserTokenCreateRequest{User: user, Name: name, TTL: ttl}
+	for _, perm := range perms {
+		req.Perm = append(req.Perm, (*authpb.Permission)(perm))
+	}
+	resp, err := auth.remote.UserTokenCreate(ctx, req, auth.callOpts...)
+	return (*AuthUserTokenCreateResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) UserTokenList(ctx context.Context, user string) (*AuthUserTokenListResponse, error) {
+	resp, err := auth.remote.UserTokenList(ctx, &pb.AuthUserTokenListRequest{User: user}, auth.callOpts...)
+	return (*AuthUserTokenListResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) UserTokenRevoke(ctx context.Context, user, name string) (*AuthUserTokenRevokeResponse, error) {
+	resp, err := auth.remote.UserTokenRevoke(ctx, &pb.AuthUserTokenRevokeRequest{User: user, Name: name}, auth.callOpts...)
+	return (*AuthUserTokenRevokeResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) AuthCheck(ctx context.Context, user, key, end string, permType PermissionType) (*AuthCheckResponse, error) {
+	req := &pb.AuthCheckRequest{User: user, Key: key, RangeEnd: end, PermType: authpb.Permission_Type(permType)}
+	resp, err := auth.remote.AuthCheck(ctx, req, auth.callOpts...)
+	return (*AuthCheckResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) UserSetRateLimit(ctx context.Context, name string, limit *RateLimit) (*AuthUserSetRateLimitResponse, error) {
+	req := &pb.AuthUserSetRateLimitRequest{Name: name, RateLimit: (*authpb.RateLimit)(limit)}
+	resp, err := auth.remote.UserSetRateLimit(ctx, req, auth.callOpts...)
+	return (*AuthUserSetRateLimitResponse)(resp), toErr(ctx, err)
+}
+
+func (auth *authClient) RoleSetRateLimit(ctx context.Context, role string, limit *RateLimit) (*AuthRoleSetRateLimitResponse, error) {
+	req := &pb.AuthRoleSetRateLimitRequest{Name: role, RateLimit: (*authpb.RateLimit)(limit)}
+	resp, err := auth.remote.RoleSetRateLimit(ctx, req, auth.callOpts...)
+	return (*AuthRoleSetRateLimitResponse)(resp), toErr(ctx, err)
+}
+
 func StrToPermissionType(s string) (PermissionType, error) {
 	val, ok := authpb.PermissionTypeValue[strings.ToUpper(s)]
 	if ok {
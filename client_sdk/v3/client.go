@@ -415,6 +415,11 @@ func (c *Client) dial(creds grpccredentials.TransportCredentials, dopts ...grpc.
 	if c.Username != "" && c.Password != "" {
 		c.authTokenBundle = credentials.NewBundle(credentials.Config{})
 		opts = append(opts, grpc.WithPerRPCCredentials(c.authTokenBundle.PerRPCCredentials()))
+	} else if c.cfg.Token != "" {
+		// API token 不需要再认证, 直接使用
+		c.authTokenBundle = credentials.NewBundle(credentials.Config{})
+		c.authTokenBundle.UpdateAuthToken(c.cfg.Token)
+		opts = append(opts, grpc.WithPerRPCCredentials(c.authTokenBundle.PerRPCCredentials()))
 	}
 
 	opts = append(opts, c.cfg.DialOptions...)
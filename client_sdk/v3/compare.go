@@ -15,6 +15,8 @@
 package clientv3
 
 import (
+	"strconv"
+
 	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
 )
 
@@ -49,9 +51,16 @@ func Compare(cmp Cmp, result string, v interface{}) Cmp {
 	}
 
 	cmp.Result = r
+	if cmp.Aggregate == pb.Compare_COUNT {
+		cmp.Count = mustInt64(v)
+		return cmp
+	}
 	switch cmp.Target {
 	case pb.Compare_VALUE:
 		val, ok := v.(string)
+		if !ok && cmp.Numeric {
+			val, ok = strconv.FormatInt(mustInt64(v), 10), true
+		}
 		if !ok {
 			panic("bad compare value")
 		}
@@ -92,6 +101,34 @@ func LeaseValue(key string) Cmp {
 	return Cmp{Key: key, Target: pb.Compare_LEASE}
 }
 
+// Count compares the number of keys in the range, e.g.
+// Compare(Count("dir/").WithPrefix(), "<", 10). A range is empty if its count is 0.
+func Count(key string) Cmp {
+	return Cmp{Key: key, Aggregate: pb.Compare_COUNT}
+}
+
+// Max compares only the greatest target over the keys in the range, e.g.
+// Compare(ModRevision("dir/").WithPrefix().Max(), "<", rev+1).
+// An empty range compares as zero; comparing values of an empty range always fails.
+func (cmp Cmp) Max() Cmp {
+	cmp.Aggregate = pb.Compare_MAX
+	return cmp
+}
+
+// Min compares only the least target over the keys in the range.
+func (cmp Cmp) Min() Cmp {
+	cmp.Aggregate = pb.Compare_MIN
+	return cmp
+}
+
+// AsNumber compares values as decimal int64s, the encoding of counters, e.g.
+// Compare(Value("counter").AsNumber(), "<", 100). A value that is not a number fails
+// the comparison.
+func (cmp Cmp) AsNumber() Cmp {
+	cmp.Numeric = true
+	return cmp
+}
+
 // KeyBytes returns the byte slice holding with the comparison key.
 func (cmp *Cmp) KeyBytes() []byte { return []byte(cmp.Key) }
 
@@ -34,6 +34,7 @@ type Config struct {
 	TLS                  *tls.Config // 客户端sdk证书
 	Username             string      `json:"username"`
 	Password             string      `json:"password"`
+	Token                string      `json:"token"`              // 通过 UserTokenCreate 创建的 API token, 代替用户名密码
 	RejectOldCluster     bool        `json:"reject-old-cluster"` // 是否拒绝老版本服务器
 
 	// DialOptions is a list of dial options for the grpc client (e.g., for interceptors).
@@ -36,6 +36,20 @@ func WithRequireLeader(ctx context.Context) context.Context {
 	return metadata.NewOutgoingContext(ctx, copied)
 }
 
+// WithPriority 设置请求的优先级类别(rpctypes.MetadataPrioritySystem 等).
+// 服务端按请求类型确定优先级, 该值只能降低优先级, 例如批量导入数据时使用 rpctypes.MetadataPriorityBulk,
+// 避免挤占租约等请求的提议.
+func WithPriority(ctx context.Context, priority string) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok { // no outgoing metadata ctx key, create one
+		md = metadata.Pairs(rpctypes.MetadataPriorityKey, priority)
+		return metadata.NewOutgoingContext(ctx, md)
+	}
+	copied := md.Copy() // avoid racey updates
+	copied.Set(rpctypes.MetadataPriorityKey, priority)
+	return metadata.NewOutgoingContext(ctx, copied)
+}
+
 // embeds client version
 func withVersion(ctx context.Context) context.Context {
 	md, ok := metadata.FromOutgoingContext(ctx)
@@ -265,7 +265,7 @@ func (lc *leaseCache) clearOldRevokes(ctx context.Context) {
 
 func (lc *leaseCache) evalCmp(cmps []v3.Cmp) (cmpVal bool, ok bool) {
 	for _, cmp := range cmps {
-		if len(cmp.RangeEnd) > 0 {
+		if len(cmp.RangeEnd) > 0 || cmp.Aggregate != v3pb.Compare_NONE || cmp.Numeric {
 			return false, false
 		}
 		lk := lc.entries[string(cmp.Key)]
@@ -16,6 +16,7 @@ package leasing
 
 import (
 	"context"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -241,11 +242,15 @@ func (lkv *leasingKV) put(ctx context.Context, op v3.Op) (pr *v3.PutResponse, er
 			return nil, err
 		}
 		if resp.Succeeded {
-			lkv.leases.mu.Lock()
-			lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), resp.Header)
-			lkv.leases.mu.Unlock()
 			pr = (*v3.PutResponse)(resp.Responses[0].GetResponsePut())
 			pr.Header = resp.Header
+			val := op.ValueBytes()
+			if op.IsIncrement() {
+				val = []byte(strconv.FormatInt(pr.Counter, 10))
+			}
+			lkv.leases.mu.Lock()
+			lkv.leases.Update(op.KeyBytes(), val, resp.Header)
+			lkv.leases.mu.Unlock()
 		}
 		if wc != nil {
 			close(wc)
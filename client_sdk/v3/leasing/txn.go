@@ -158,6 +158,7 @@ func (txn *txnLeasing) guard(ops []v3.Op) ([]v3.Cmp, error) {
 
 func (txn *txnLeasing) commitToCache(txnResp *v3pb.TxnResponse, userTxn v3.Op) {
 	ops := gatherResponseOps(txnResp.Responses, []v3.Op{userTxn})
+	var evicts []string
 	txn.lkv.leases.mu.Lock()
 	for _, op := range ops {
 		key := string(op.KeyBytes())
@@ -171,11 +172,17 @@ func (txn *txnLeasing) commitToCache(txnResp *v3pb.TxnResponse, userTxn v3.Op) {
 		} else if op.IsDelete() {
 			txn.lkv.leases.delete(key, txnResp.Header)
 		}
-		if op.IsPut() {
+		if op.IsPut() && (op.HasValueRef() || op.IsIncrement()) {
+			// 值由服务端决定, 不能据此更新缓存
+			evicts = append(evicts, key)
+		} else if op.IsPut() {
 			txn.lkv.leases.Update(op.KeyBytes(), op.ValueBytes(), txnResp.Header)
 		}
 	}
 	txn.lkv.leases.mu.Unlock()
+	for _, key := range evicts {
+		txn.lkv.leases.Evict(key)
+	}
 }
 
 func (txn *txnLeasing) revokeFallback(fbResps []*v3pb.ResponseOp) error {
@@ -33,6 +33,8 @@ type (
 	MoveLeaderResponse pb.MoveLeaderResponse
 )
 
+type MaintenanceModeResponse pb.MaintenanceModeResponse
+
 type Maintenance interface {
 	AlarmList(ctx context.Context) (*AlarmResponse, error)                            // 获取目前所有的警报
 	AlarmDisarm(ctx context.Context, m *AlarmMember) (*AlarmResponse, error)          // 解除警报
@@ -41,6 +43,12 @@ type Maintenance interface {
 	HashKV(ctx context.Context, endpoint string, rev int64) (*HashKVResponse, error)  //
 	Snapshot(ctx context.Context) (io.ReadCloser, error)                              // 返回一个快照
 	MoveLeader(ctx context.Context, transfereeID uint64) (*MoveLeaderResponse, error) // leader 转移
+
+	// HashKVRanges 获取端点上各key范围在rev时的哈希, 每个范围最多返回 splits 个切分点, 用于定位不一致的key
+	HashKVRanges(ctx context.Context, endpoint string, rev int64, ranges []*pb.KeyRange, splits int64) (*HashKVResponse, error)
+
+	// MaintenanceMode 查询(GET)、开启(ENABLE)或关闭(DISABLE)集群的只读维护模式
+	MaintenanceMode(ctx context.Context, action pb.MaintenanceModeRequest_Action) (*MaintenanceModeResponse, error)
 }
 
 type maintenance struct {
@@ -179,6 +187,19 @@ func (m *maintenance) HashKV(ctx context.Context, endpoint string, rev int64) (*
 	return (*HashKVResponse)(resp), nil
 }
 
+func (m *maintenance) HashKVRanges(ctx context.Context, endpoint string, rev int64, ranges []*pb.KeyRange, splits int64) (*HashKVResponse, error) {
+	remote, cancel, err := m.dial(endpoint)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	defer cancel()
+	resp, err := remote.HashKV(ctx, &pb.HashKVRequest{Revision: rev, Ranges: ranges, Splits: splits}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*HashKVResponse)(resp), nil
+}
+
 func (m *maintenance) Snapshot(ctx context.Context) (io.ReadCloser, error) {
 	ss, err := m.remote.Snapshot(ctx, &pb.SnapshotRequest{}, append(m.callOpts, withMax(defaultStreamMaxRetries))...)
 	if err != nil {
@@ -223,3 +244,11 @@ func (m *maintenance) MoveLeader(ctx context.Context, transfereeID uint64) (*Mov
 	resp, err := m.remote.MoveLeader(ctx, &pb.MoveLeaderRequest{TargetID: transfereeID}, m.callOpts...)
 	return (*MoveLeaderResponse)(resp), toErr(ctx, err)
 }
+
+func (m *maintenance) MaintenanceMode(ctx context.Context, action pb.MaintenanceModeRequest_Action) (*MaintenanceModeResponse, error) {
+	resp, err := m.remote.MaintenanceMode(ctx, &pb.MaintenanceModeRequest{Action: action}, m.callOpts...)
+	if err != nil {
+		return nil, toErr(ctx, err)
+	}
+	return (*MaintenanceModeResponse)(resp), nil
+}
@@ -28,13 +28,16 @@ type watcherPrefix struct {
 	wg       sync.WaitGroup
 	stopc    chan struct{}
 	stopOnce sync.Once
+
+	mu   sync.Mutex
+	wchs map[clientv3.WatchChan]clientv3.WatchChan // 去掉前缀后的watch channel -> 底层的watch channel
 }
 
 // NewWatcher wraps a Watcher instance so that all Watch requests
 // are prefixed with a given string and all Watch responses have
 // the prefix removed.
 func NewWatcher(w clientv3.Watcher, prefix string) clientv3.Watcher {
-	return &watcherPrefix{Watcher: w, pfx: prefix, stopc: make(chan struct{})}
+	return &watcherPrefix{Watcher: w, pfx: prefix, stopc: make(chan struct{}), wchs: make(map[clientv3.WatchChan]clientv3.WatchChan)}
 }
 
 // Watch ok
@@ -51,9 +54,15 @@ func (w *watcherPrefix) Watch(ctx context.Context, key string, opts ...clientv3.
 
 	// 翻译watch事件从前缀到无前缀
 	pfxWch := make(chan clientv3.WatchResponse)
+	w.mu.Lock()
+	w.wchs[pfxWch] = wch
+	w.mu.Unlock()
 	w.wg.Add(1)
 	go func() {
 		defer func() {
+			w.mu.Lock()
+			delete(w.wchs, pfxWch)
+			w.mu.Unlock()
 			close(pfxWch)
 			w.wg.Done()
 		}()
@@ -76,6 +85,55 @@ func (w *watcherPrefix) Watch(ctx context.Context, key string, opts ...clientv3.
 	return pfxWch
 }
 
+func (w *watcherPrefix) RequestWatchProgress(ctx context.Context, wch clientv3.WatchChan) error {
+	w.mu.Lock()
+	inner, ok := w.wchs[wch]
+	w.mu.Unlock()
+	if ok {
+		wch = inner
+	}
+	return w.Watcher.RequestWatchProgress(ctx, wch)
+}
+
+func (w *watcherPrefix) ListWatch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.ListWatchChan {
+	op := clientv3.OpGet(key, opts...)
+	end := op.RangeBytes()
+	pfxBegin, pfxEnd := prefixInterval(w.pfx, []byte(key), end)
+	if pfxEnd != nil {
+		opts = append(opts, clientv3.WithRange(string(pfxEnd)))
+	}
+
+	lch := w.Watcher.ListWatch(ctx, string(pfxBegin), opts...)
+
+	pfxLch := make(chan clientv3.ListWatchResponse)
+	w.wg.Add(1)
+	go func() {
+		defer func() {
+			close(pfxLch)
+			w.wg.Done()
+		}()
+		for lr := range lch {
+			for i := range lr.Kvs {
+				lr.Kvs[i].Key = lr.Kvs[i].Key[len(w.pfx):]
+			}
+			for i := range lr.Events {
+				lr.Events[i].Kv.Key = lr.Events[i].Kv.Key[len(w.pfx):]
+				if lr.Events[i].PrevKv != nil {
+					lr.Events[i].PrevKv.Key = lr.Events[i].Kv.Key
+				}
+			}
+			select {
+			case pfxLch <- lr:
+			case <-ctx.Done():
+				return
+			case <-w.stopc:
+				return
+			}
+		}
+	}()
+	return pfxLch
+}
+
 func (w *watcherPrefix) Close() error {
 	err := w.Watcher.Close()
 	w.stopOnce.Do(func() { close(w.stopc) })
@@ -15,7 +15,10 @@
 package clientv3
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"time"
 
 	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
 )
@@ -47,6 +50,10 @@ type Op struct {
 	minCreateRev int64
 	maxCreateRev int64
 
+	// 有界陈旧读, 见 WithMaxStaleness
+	maxStaleness     time.Duration
+	maxStalenessRevs int64
+
 	// for range, watch
 	rev int64
 
@@ -62,11 +69,32 @@ type Op struct {
 	ignoreValue bool
 	ignoreLease bool
 
+	// 条件写入, 见 WithIfAbsent
+	ifAbsent    bool
+	ifModRev    int64
+	ifValueHash string
+
+	// 值取自同一事务分支中之前的range操作, 见 WithValueFrom
+	valueRef *pb.ValueRef
+
+	// 计数器增量, 见 OpIncrement
+	increment *pb.Increment
+
 	progressNotify bool // 处理更新
 	createdNotify  bool // 创建事件
 	filterPut      bool // 过滤掉put事件
 	filterDelete   bool // 过滤掉delete事件
 
+	// 服务端过滤, 见 WatchCreateRequest
+	filterValuePrefix string
+	filterValueRegex  string
+	filterLease       LeaseID
+	filterKeySuffixes []string
+
+	resumable bool // 断线重连时由服务端缓存的事件恢复
+
+	coalesceWindow time.Duration // 服务端按key合并事件的窗口
+
 	// for put
 	val     string
 	leaseID LeaseID
@@ -142,6 +170,12 @@ func (op Op) ValueBytes() []byte { return []byte(op.val) }
 // WithValueBytes sets the byte slice for the Op's value.
 func (op *Op) WithValueBytes(v []byte) { op.val = string(v) }
 
+// HasValueRef returns whether the Op's value is taken from an earlier op in its txn.
+func (op Op) HasValueRef() bool { return op.valueRef != nil }
+
+// IsIncrement returns whether the Op is a counter increment.
+func (op Op) IsIncrement() bool { return op.increment != nil }
+
 func (op Op) toRangeRequest() *pb.RangeRequest {
 	if op.t != tRange {
 		panic("op.t != tRange")
@@ -163,9 +197,33 @@ func (op Op) toRangeRequest() *pb.RangeRequest {
 		r.SortOrder = pb.RangeRequest_SortOrder(op.sort.Order)
 		r.SortTarget = pb.RangeRequest_SortTarget(op.sort.Target)
 	}
+	if op.maxStaleness > 0 {
+		// 不足1ms时向上取整, 避免变为不限制
+		r.MaxStalenessMs = int64((op.maxStaleness + time.Millisecond - 1) / time.Millisecond)
+	}
+	r.MaxStalenessRevisions = op.maxStalenessRevs
 	return r
 }
 
+func (op Op) toPutRequest() *pb.PutRequest {
+	if op.t != tPut {
+		panic("op.t != tPut")
+	}
+	return &pb.PutRequest{
+		Key:           op.key,
+		Value:         op.val,
+		Lease:         int64(op.leaseID),
+		PrevKv:        op.prevKV,
+		IgnoreValue:   op.ignoreValue,
+		IgnoreLease:   op.ignoreLease,
+		IfAbsent:      op.ifAbsent,
+		IfModRevision: op.ifModRev,
+		IfValueHash:   op.ifValueHash,
+		ValueRef:      op.valueRef,
+		Increment:     op.increment,
+	}
+}
+
 func (op Op) toTxnRequest() *pb.TxnRequest {
 	thenOps := make([]*pb.RequestOp, len(op.thenOps))
 	for i, tOp := range op.thenOps {
@@ -187,8 +245,7 @@ func (op Op) toRequestOp() *pb.RequestOp {
 	case tRange:
 		return &pb.RequestOp{RequestOp_RequestRange: &pb.RequestOp_RequestRange{RequestRange: op.toRangeRequest()}}
 	case tPut:
-		r := &pb.PutRequest{Key: op.key, Value: op.val, Lease: int64(op.leaseID), PrevKv: op.prevKV, IgnoreValue: op.ignoreValue, IgnoreLease: op.ignoreLease}
-		return &pb.RequestOp{RequestOp_RequestPut: &pb.RequestOp_RequestPut{RequestPut: r}}
+		return &pb.RequestOp{RequestOp_RequestPut: &pb.RequestOp_RequestPut{RequestPut: op.toPutRequest()}}
 	case tDeleteRange:
 		r := &pb.DeleteRangeRequest{Key: op.key, RangeEnd: op.end, PrevKv: op.prevKV}
 		fmt.Println("----->", r)
@@ -290,6 +347,23 @@ func OpPut(key, val string, opts ...OpOption) Op {
 	return ret
 }
 
+// OpIncrement returns a put that atomically adds delta to the counter at key and stores
+// the sum. Counters are stored as decimal int64 values; a missing key counts as 0.
+// The new value is returned in PutResponse.Counter. The put fails with ErrValueNotNumber
+// if the current value is not a number, and with ErrCounterOverflow if the sum overflows.
+// Options for puts other than a value apply.
+func OpIncrement(key string, delta int64, opts ...OpOption) Op {
+	ret := OpPut(key, "", opts...)
+	switch {
+	case ret.ignoreValue:
+		panic("unexpected ignoreValue in increment")
+	case ret.valueRef != nil:
+		panic("unexpected value reference in increment")
+	}
+	ret.increment = &pb.Increment{Delta: delta}
+	return ret
+}
+
 // OpTxn returns "txn" operation based on given transaction conditions.
 func OpTxn(cmps []Cmp, thenOps []Op, elseOps []Op) Op {
 	return Op{t: tTxn, cmps: cmps, thenOps: thenOps, elseOps: elseOps}
@@ -318,6 +392,29 @@ func opWatch(key string, opts ...OpOption) Op {
 	return ret
 }
 
+// 检查list-watch请求; limit 是列出阶段每页的key数
+func opListWatch(key string, opts ...OpOption) Op {
+	ret := Op{t: tRange, key: key}
+	ret.applyOpts(opts)
+	switch {
+	case ret.leaseID != 0:
+		panic("unexpected list-watch中不能有租约")
+	case ret.sort != nil:
+		panic("unexpected list-watch中不能有sort")
+	case ret.serializable:
+		panic("unexpected list-watch中不能有 serializable")
+	case ret.countOnly, ret.keysOnly:
+		panic("unexpected list-watch中不能有countOnly或keysOnly")
+	case ret.minModRev != 0, ret.maxModRev != 0, ret.minCreateRev != 0, ret.maxCreateRev != 0:
+		panic("unexpected list-watch中不能过滤修订版本")
+	case ret.filterPut, ret.filterDelete, ret.filterValuePrefix != "", ret.filterValueRegex != "", ret.filterLease != 0, len(ret.filterKeySuffixes) != 0:
+		panic("unexpected list-watch中不能过滤事件")
+	case ret.createdNotify, ret.fragment, ret.resumable, ret.coalesceWindow != 0:
+		panic("unexpected list-watch不支持该watch选项")
+	}
+	return ret
+}
+
 func (op *Op) applyOpts(opts []OpOption) {
 	for _, opt := range opts {
 		opt(op)
@@ -417,6 +514,20 @@ func WithSerializable() OpOption {
 	return func(op *Op) { op.serializable = true }
 }
 
+// WithMaxStaleness makes 'Get' request a bounded staleness read: the result lags the
+// cluster by at most d at the time the request started. Any member, including a follower,
+// serves it locally when it can prove the bound, otherwise it waits for the leader.
+// It has no effect together with WithSerializable.
+func WithMaxStaleness(d time.Duration) OpOption {
+	return func(op *Op) { op.maxStaleness = d }
+}
+
+// WithMaxStalenessRevisions is like WithMaxStaleness but bounds the lag in revisions.
+// If both bounds are set, both must hold.
+func WithMaxStalenessRevisions(n int64) OpOption {
+	return func(op *Op) { op.maxStalenessRevs = n }
+}
+
 // WithKeysOnly makes the 'Get' request return only the keys and the corresponding
 // values will be omitted.
 func WithKeysOnly() OpOption {
@@ -486,6 +597,43 @@ func WithFilterDelete() OpOption {
 	return func(op *Op) { op.filterDelete = true }
 }
 
+// WithFilterValuePrefix only receives PUT events whose value starts with prefix.
+// DELETE events are not affected.
+func WithFilterValuePrefix(prefix string) OpOption {
+	return func(op *Op) { op.filterValuePrefix = prefix }
+}
+
+// WithFilterValueRegex only receives PUT events whose value matches the RE2 expression.
+// DELETE events are not affected.
+func WithFilterValueRegex(expr string) OpOption {
+	return func(op *Op) { op.filterValueRegex = expr }
+}
+
+// WithFilterLease only receives PUT events of keys attached to the lease.
+// DELETE events are not affected.
+func WithFilterLease(id LeaseID) OpOption {
+	return func(op *Op) { op.filterLease = id }
+}
+
+// WithFilterKeySuffix only receives events whose key ends with one of the suffixes.
+func WithFilterKeySuffix(suffixes ...string) OpOption {
+	return func(op *Op) { op.filterKeySuffixes = append(op.filterKeySuffixes, suffixes...) }
+}
+
+// WithResumable asks the server to keep the watcher and buffer its events for a grace
+// period when the watch stream breaks, so a quick reconnect resumes from the buffer
+// instead of replaying from the backend. Servers without resumable watch support
+// ignore it.
+func WithResumable() OpOption {
+	return func(op *Op) { op.resumable = true }
+}
+
+// WithCoalesce asks the server to coalesce events per key over the window and send only
+// the final state of each key. Intermediate changes within the window are not delivered.
+func WithCoalesce(window time.Duration) OpOption {
+	return func(op *Op) { op.coalesceWindow = window }
+}
+
 // WithPrevKV gets the previous key-value pair before the event happens. If the previous KV is already compacted,
 // nothing will be returned.
 func WithPrevKV() OpOption {
@@ -523,6 +671,40 @@ func WithIgnoreLease() OpOption {
 	}
 }
 
+// WithIfAbsent puts the key only if it does not exist.
+// This option can not be combined with options that expect an existing key.
+// Returns ErrKeyExists if the key already exists.
+func WithIfAbsent() OpOption {
+	return func(op *Op) { op.ifAbsent = true }
+}
+
+// WithIfModRevision puts the key only if it exists and its mod revision equals rev.
+// Returns ErrModRevisionMismatch otherwise.
+func WithIfModRevision(rev int64) OpOption {
+	return func(op *Op) { op.ifModRev = rev }
+}
+
+// WithIfValueHash puts the key only if it exists and the hash of its current value
+// equals hash, as computed by ValueHash. Returns ErrValueHashMismatch otherwise.
+func WithIfValueHash(hash string) OpOption {
+	return func(op *Op) { op.ifValueHash = hash }
+}
+
+// ValueHash returns the hash of a value as compared by WithIfValueHash:
+// the lowercase hex SHA-256 digest.
+func ValueHash(val []byte) string {
+	sum := sha256.Sum256(val)
+	return hex.EncodeToString(sum[:])
+}
+
+// WithValueFrom makes a put inside a txn take its value from the first key returned by
+// the Get at index opIndex of the same Then or Else branch, which must precede the put.
+// The Get sees earlier writes of the branch. The value argument of OpPut must be empty.
+// If the Get returns no key, the put is skipped. Only valid for puts inside a txn.
+func WithValueFrom(opIndex int) OpOption {
+	return func(op *Op) { op.valueRef = &pb.ValueRef{OpIndex: int64(opIndex)} }
+}
+
 // LeaseOp represents an Operation that lease can execute.
 type LeaseOp struct {
 	id LeaseID
@@ -227,6 +227,10 @@ func (rmc *retryMaintenanceClient) Downgrade(ctx context.Context, in *pb.Downgra
 	return rmc.mc.Downgrade(ctx, in, opts...)
 }
 
+func (rmc *retryMaintenanceClient) MaintenanceMode(ctx context.Context, in *pb.MaintenanceModeRequest, opts ...grpc.CallOption) (resp *pb.MaintenanceModeResponse, err error) {
+	return rmc.mc.MaintenanceMode(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 type retryAuthClient struct {
 	ac pb.AuthClient
 }
@@ -254,6 +258,14 @@ func (rac *retryAuthClient) RoleList(ctx context.Context, in *pb.AuthRoleListReq
 	return rac.ac.RoleList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
 }
 
+func (rac *retryAuthClient) UserTokenList(ctx context.Context, in *pb.AuthUserTokenListRequest, opts ...grpc.CallOption) (resp *pb.AuthUserTokenListResponse, err error) {
+	return rac.ac.UserTokenList(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
+func (rac *retryAuthClient) AuthCheck(ctx context.Context, in *pb.AuthCheckRequest, opts ...grpc.CallOption) (resp *pb.AuthCheckResponse, err error) {
+	return rac.ac.AuthCheck(ctx, in, append(opts, withRetryPolicy(repeatable))...)
+}
+
 func (rac *retryAuthClient) AuthEnable(ctx context.Context, in *pb.AuthEnableRequest, opts ...grpc.CallOption) (resp *pb.AuthEnableResponse, err error) {
 	return rac.ac.AuthEnable(ctx, in, opts...)
 }
@@ -302,6 +314,22 @@ func (rac *retryAuthClient) RoleRevokePermission(ctx context.Context, in *pb.Aut
 	return rac.ac.RoleRevokePermission(ctx, in, opts...)
 }
 
+func (rac *retryAuthClient) UserTokenCreate(ctx context.Context, in *pb.AuthUserTokenCreateRequest, opts ...grpc.CallOption) (resp *pb.AuthUserTokenCreateResponse, err error) {
+	return rac.ac.UserTokenCreate(ctx, in, opts...)
+}
+
+func (rac *retryAuthClient) UserTokenRevoke(ctx context.Context, in *pb.AuthUserTokenRevokeRequest, opts ...grpc.CallOption) (resp *pb.AuthUserTokenRevokeResponse, err error) {
+	return rac.ac.UserTokenRevoke(ctx, in, opts...)
+}
+
+func (rac *retryAuthClient) UserSetRateLimit(ctx context.Context, in *pb.AuthUserSetRateLimitRequest, opts ...grpc.CallOption) (resp *pb.AuthUserSetRateLimitResponse, err error) {
+	return rac.ac.UserSetRateLimit(ctx, in, opts...)
+}
+
+func (rac *retryAuthClient) RoleSetRateLimit(ctx context.Context, in *pb.AuthRoleSetRateLimitRequest, opts ...grpc.CallOption) (resp *pb.AuthRoleSetRateLimitResponse, err error) {
+	return rac.ac.RoleSetRateLimit(ctx, in, opts...)
+}
+
 func (rac *retryAuthClient) Authenticate(ctx context.Context, in *pb.AuthenticateRequest, opts ...grpc.CallOption) (resp *pb.AuthenticateResponse, err error) {
 	return rac.ac.Authenticate(ctx, in, opts...)
 }
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
 	"sync"
 	"time"
 
@@ -46,11 +47,16 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 		if callOpts.max == 0 {
 			return invoker(ctx, method, req, reply, cc, grpcOpts...)
 		}
-		var lastErr error
+		var (
+			lastErr error
+			trailer metadata.MD
+		)
+		grpcOpts = append(grpcOpts[:len(grpcOpts):len(grpcOpts)], grpc.Trailer(&trailer))
 		for attempt := uint(0); attempt < callOpts.max; attempt++ {
 			if err := waitRetryBackoff(ctx, attempt, callOpts); err != nil {
 				return err
 			}
+			trailer = nil
 			c.GetLogger().Debug("重试调用", zap.String("target", cc.Target()), zap.Uint("attempt", attempt))
 			if !conf.Perf {
 				switch v := req.(type) {
@@ -94,6 +100,13 @@ func (c *Client) unaryClientInterceptor(optFuncs ...retryOption) grpc.UnaryClien
 				}
 				continue
 			}
+			// 服务端因负载过高拒绝时请求尚未提议, 按返回的等待时间重试是安全的
+			if d, ok := retryAfterHint(lastErr, trailer); ok {
+				if err := waitRetryAfter(ctx, d); err != nil {
+					return err
+				}
+				continue
+			}
 			if !isSafeRetry(c.lg, lastErr, callOpts) {
 				return lastErr
 			}
@@ -312,6 +325,33 @@ func waitRetryBackoff(ctx context.Context, attempt uint, callOpts *options) erro
 	return nil
 }
 
+// retryAfterHint returns the retry-after hint the server attached to an ErrTooManyRequests rejection.
+func retryAfterHint(err error, trailer metadata.MD) (time.Duration, bool) {
+	if rpctypes.Error(err) != rpctypes.ErrTooManyRequests {
+		return 0, false
+	}
+	vs := trailer.Get(rpctypes.MetadataRetryAfterKey)
+	if len(vs) == 0 {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(vs[0], 10, 64)
+	if err != nil || ms < 0 {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+func waitRetryAfter(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return contextErrToGrpcErr(ctx.Err())
+	case <-timer.C:
+	}
+	return nil
+}
+
 // isSafeRetry returns "true", if request is safe for retry with the given error.
 func isSafeRetry(lg *zap.Logger, err error, callOpts *options) bool {
 	if isContextError(err) {
@@ -47,6 +47,16 @@ type Watcher interface {
 	Watch(ctx context.Context, key string, opts ...OpOption) WatchChan
 	// RequestProgress requests a progress notify response be sent in all watch channels.
 	RequestProgress(ctx context.Context) error
+	// RequestWatchProgress requests a progress notify response be sent only in the given
+	// watch channel, which must have been created by Watch with the same ctx. The response
+	// is sent even if the watcher is not synced; its Header.Revision is the revision up to
+	// which all events of the watcher have been received.
+	RequestWatchProgress(ctx context.Context, wch WatchChan) error
+	// ListWatch lists the keys matching key and opts at a consistent revision page by page,
+	// sends a response with ListDone set, then sends the events after that revision.
+	// WithRev selects the revision to list at and WithLimit the page size. The channel is
+	// closed after a canceled response or when ctx is done; the stream is not resumed.
+	ListWatch(ctx context.Context, key string, opts ...OpOption) ListWatchChan
 	// Close closes the watcher and cancels all watch requests.
 	Close() error
 }
@@ -60,6 +70,10 @@ type WatchResponse struct {
 
 	CreatedRevision int64
 
+	// ResumeRevision is set when the server canceled the watcher because its stream
+	// fell too far behind. Watching again from this revision receives the dropped events.
+	ResumeRevision int64
+
 	// Canceled is used to indicate watch failure.
 	// If the watch failed and the stream was about to close, before the channel is closed,
 	// the channel sends a final response that has Canceled set to true with a non-nil Err().
@@ -91,6 +105,8 @@ func (wr *WatchResponse) Err() error {
 		return v3rpc.Error(wr.closeErr)
 	case wr.CompactRevision != 0:
 		return v3rpc.ErrCompacted
+	case wr.ResumeRevision != 0:
+		return v3rpc.ErrWatchSlowConsumer
 	case wr.Canceled:
 		if len(wr.cancelReason) != 0 {
 			return v3rpc.Error(status.Error(codes.FailedPrecondition, wr.cancelReason))
@@ -148,12 +164,22 @@ type watchRequest struct {
 	progressNotify bool // 进度更新
 	fragment       bool // 是否切分响应,当数据较大时
 	filters        []pb.WatchCreateRequest_FilterType
+	valuePrefix    string
+	valueRegex     string
+	lease          int64
+	keySuffixes    []string
+	resumable      bool
+	sessionID      string // 服务端返回的会话ID, 重连时用于恢复
+	coalesceWindow time.Duration
 	prevKV         bool
 	retc           chan chan WatchResponse
 }
 
 // progressRequest is issued by the subscriber to request watch progress
-type progressRequest struct{}
+type progressRequest struct {
+	wch     WatchChan // 只请求该watch的进度, 为nil时请求所有watch
+	watchID int64
+}
 
 // watcherStream 代表注册的观察者
 // watch()时,构造watchgrpcstream时构造的watcherStream,用于封装一个watch rpc请求,包含订阅监听key,通知key变更通道,一些重要标志.
@@ -239,6 +265,12 @@ func (w *watcher) Watch(ctx context.Context, key string, opts ...OpOption) Watch
 		progressNotify: ow.progressNotify,
 		fragment:       ow.fragment,
 		filters:        filters,
+		valuePrefix:    ow.filterValuePrefix,
+		valueRegex:     ow.filterValueRegex,
+		lease:          int64(ow.filterLease),
+		keySuffixes:    ow.filterKeySuffixes,
+		resumable:      ow.resumable,
+		coalesceWindow: ow.coalesceWindow,
 		prevKV:         ow.prevKV,
 		retc:           make(chan chan WatchResponse, 1),
 	}
@@ -364,6 +396,33 @@ func (w *watcher) RequestProgress(ctx context.Context) (err error) {
 	}
 }
 
+// RequestWatchProgress requests a progress notify response be sent only in the given watch channel.
+func (w *watcher) RequestWatchProgress(ctx context.Context, wch WatchChan) error {
+	ctxKey := streamKeyFromCtx(ctx)
+
+	w.mu.Lock()
+	var wgs *watchGrpcStream
+	if w.streams != nil {
+		wgs = w.streams[ctxKey]
+	}
+	w.mu.Unlock()
+	if wgs == nil {
+		return fmt.Errorf("no stream found for context")
+	}
+
+	select {
+	case wgs.reqc <- &progressRequest{wch: wch}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-wgs.donec:
+		if wgs.closeErr != nil {
+			return wgs.closeErr
+		}
+		return fmt.Errorf("watch stream closed")
+	}
+}
+
 func (w *watchGrpcStream) close() (err error) {
 	w.cancel()
 	<-w.donec
@@ -393,9 +452,22 @@ func (w *watchGrpcStream) addSubstream(resp *pb.WatchResponse, ws *watcherStream
 		return
 	}
 	ws.id = resp.WatchId
+	if resp.SessionId != "" {
+		ws.initReq.sessionID = resp.SessionId
+	}
 	w.substreams[ws.id] = ws
 }
 
+// substreamOf 返回输出到 wch 的已建立的watch
+func (w *watchGrpcStream) substreamOf(wch WatchChan) *watcherStream {
+	for _, ws := range w.substreams {
+		if WatchChan(ws.outc) == wch {
+			return ws
+		}
+	}
+	return nil
+}
+
 func (w *watchGrpcStream) sendCloseSubstream(ws *watcherStream, resp *WatchResponse) {
 	select {
 	case ws.outc <- *resp:
@@ -497,6 +569,15 @@ func (w *watchGrpcStream) run() {
 					}
 				}
 			case *progressRequest:
+				if wreq.wch != nil {
+					ws := w.substreamOf(wreq.wch)
+					if ws == nil {
+						// watch尚未建立或已关闭, 它的创建响应会带上当前修订版本
+						w.lg.Debug("no established watch for progress request")
+						break
+					}
+					wreq.watchID = ws.id
+				}
 				if err := wc.Send(wreq.toPB()); err != nil {
 					w.lg.Debug("error when sending request", zap.Error(err))
 				}
@@ -532,7 +613,7 @@ func (w *watchGrpcStream) run() {
 				// reset for next iteration
 				cur = nil
 
-			case pbresp.Canceled && pbresp.CompactRevision == 0:
+			case pbresp.Canceled && pbresp.CompactRevision == 0 && pbresp.ResumeRevision == 0:
 				delete(cancelSet, pbresp.WatchId)
 				if ws, ok := w.substreams[pbresp.WatchId]; ok {
 					// signal to stream goroutine to update closingc
@@ -647,6 +728,7 @@ func (w *watchGrpcStream) dispatchEvent(pbresp *pb.WatchResponse) bool {
 		Header:          *pbresp.Header,
 		Events:          events,
 		CompactRevision: pbresp.CompactRevision,
+		ResumeRevision:  pbresp.ResumeRevision,
 		Created:         pbresp.Created,
 		Canceled:        pbresp.Canceled,
 		cancelReason:    pbresp.CancelReason,
@@ -943,7 +1025,15 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 		Filters:        wr.filters,
 		PrevKv:         wr.prevKV,
 		Fragment:       wr.fragment,
+		ValuePrefix:    wr.valuePrefix,
+		ValueRegex:     wr.valueRegex,
+		Lease:          wr.lease,
+		KeySuffixes:    wr.keySuffixes,
+		Resumable:      wr.resumable,
 	}
+	// 重连时恢复服务端保留的watcher
+	req.ResumeSessionId = wr.sessionID
+	req.CoalesceWindowMs = wr.coalesceWindow.Milliseconds()
 	cr := &pb.WatchRequest_CreateRequest{CreateRequest: req}
 	return &pb.WatchRequest{WatchRequest_CreateRequest: cr}
 }
@@ -951,6 +1041,10 @@ func (wr *watchRequest) toPB() *pb.WatchRequest {
 // toPB converts an internal progress request structure to its protobuf WatchRequest structure.
 func (pr *progressRequest) toPB() *pb.WatchRequest {
 	req := &pb.WatchProgressRequest{}
+	if pr.wch != nil {
+		req.Targeted = true
+		req.WatchId = pr.watchID
+	}
 	cr := &pb.WatchRequest_ProgressRequest{ProgressRequest: req}
 	return &pb.WatchRequest{WatchRequest_ProgressRequest: cr}
 }
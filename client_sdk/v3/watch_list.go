@@ -0,0 +1,133 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"io"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+	v3rpc "github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type ListWatchChan <-chan ListWatchResponse
+
+type ListWatchResponse struct {
+	Header pb.ResponseHeader
+
+	// Kvs is one page of the initial state, all pages are at the same revision.
+	Kvs []*mvccpb.KeyValue
+
+	// ListDone marks the end of the initial state. Later responses only carry
+	// events with a revision greater than Header.Revision of this response.
+	ListDone bool
+
+	Events []*Event
+
+	// CompactRevision is set if a needed revision was compacted.
+	CompactRevision int64
+
+	// Canceled is set on the last response of a failed list-watch, Err() tells why.
+	Canceled bool
+
+	closeErr     error
+	cancelReason string
+}
+
+// Err is the error value if this ListWatchResponse holds an error.
+func (lr *ListWatchResponse) Err() error {
+	switch {
+	case lr.closeErr != nil:
+		return v3rpc.Error(lr.closeErr)
+	case lr.CompactRevision != 0:
+		return v3rpc.ErrCompacted
+	case lr.Canceled:
+		if len(lr.cancelReason) != 0 {
+			return v3rpc.Error(status.Error(codes.FailedPrecondition, lr.cancelReason))
+		}
+		return v3rpc.ErrFutureRev
+	}
+	return nil
+}
+
+// IsProgressNotify returns true if the ListWatchResponse is a progress notification.
+func (lr *ListWatchResponse) IsProgressNotify() bool {
+	return len(lr.Kvs) == 0 && len(lr.Events) == 0 && !lr.ListDone && !lr.Canceled && lr.Header.Revision != 0
+}
+
+// ListWatch 每次调用使用单独的服务端流, 不与Watch共享
+func (w *watcher) ListWatch(ctx context.Context, key string, opts ...OpOption) ListWatchChan {
+	op := opListWatch(key, opts...)
+	req := &pb.ListWatchRequest{
+		Key:            op.key,
+		RangeEnd:       op.end,
+		PageSize:       op.limit,
+		Revision:       op.rev,
+		PrevKv:         op.prevKV,
+		ProgressNotify: op.progressNotify,
+	}
+
+	ch := make(chan ListWatchResponse)
+	go func() {
+		defer close(ch)
+		send := func(lr ListWatchResponse) bool {
+			select {
+			case ch <- lr:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		lc, err := w.remote.ListWatch(ctx, req, w.callOpts...)
+		if err != nil {
+			if ctx.Err() == nil {
+				send(ListWatchResponse{Canceled: true, closeErr: err})
+			}
+			return
+		}
+		for {
+			resp, err := lc.Recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					send(ListWatchResponse{Canceled: true, closeErr: err})
+				}
+				return
+			}
+			lr := ListWatchResponse{
+				Kvs:             resp.Kvs,
+				ListDone:        resp.ListDone,
+				Events:          make([]*Event, len(resp.Events)),
+				CompactRevision: resp.CompactRevision,
+				Canceled:        resp.Canceled,
+				cancelReason:    resp.CancelReason,
+			}
+			if resp.Header != nil {
+				lr.Header = *resp.Header
+			}
+			for i, ev := range resp.Events {
+				lr.Events[i] = (*Event)(ev)
+			}
+			if !send(lr) || lr.Canceled {
+				return
+			}
+		}
+	}()
+	return ch
+}
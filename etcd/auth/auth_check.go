@@ -0,0 +1,158 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"fmt"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/backend"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"github.com/ls-2018/etcd_cn/pkg/adt"
+)
+
+// AuthCheck 按 isOpPermitted 的规则评估用户对某个范围的权限, 并说明是哪些角色/权限允许或拒绝了请求; 不修改任何状态
+func (as *authStore) AuthCheck(authInfo *AuthInfo, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	resp := &pb.AuthCheckResponse{}
+	if !as.IsAuthEnabled() {
+		resp.Allowed = true
+		resp.Reason = "认证未开启, 所有请求都被允许"
+		return resp, nil
+	}
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	// 检查自己时与真实请求一样带上证书映射的角色和 scoped token; 检查其他用户时只看用户本身的权限
+	info := &AuthInfo{Username: r.User}
+	if authInfo != nil && authInfo.Username == r.User {
+		info = &AuthInfo{Username: r.User, Roles: authInfo.Roles, ScopedToken: authInfo.ScopedToken}
+	}
+
+	user := getUser(as.lg, tx, r.User)
+	if user == nil && len(info.Roles) == 0 {
+		return nil, ErrUserNotFound
+	}
+	var roles []string
+	roles = append(roles, info.Roles...)
+	if user != nil {
+		roles = append(roles, user.Roles...)
+	}
+	if len(roles) == 0 {
+		resp.Reason = fmt.Sprintf("用户 %q 没有任何角色", r.User)
+		return resp, nil
+	}
+
+	key, rangeEnd := []byte(r.Key), []byte(r.RangeEnd)
+	resp.Allowed = as.isCheckPermitted(tx, info, key, rangeEnd, r.PermType)
+	if !resp.Allowed && info.ScopedToken != "" && user != nil && as.isCheckPermitted(tx, &AuthInfo{Username: r.User, Roles: info.Roles}, key, rangeEnd, r.PermType) {
+		resp.Reason = "scoped token 的权限范围没有覆盖请求的范围"
+		return resp, nil
+	}
+	for _, roleName := range roles {
+		if roleName == rootRole && resp.Allowed {
+			resp.Roles = []string{rootRole}
+			resp.Reason = fmt.Sprintf("用户 %q 拥有 root 角色", r.User)
+			return resp, nil
+		}
+	}
+
+	req := &authpb.Permission{PermType: r.PermType, Key: r.Key, RangeEnd: r.RangeEnd}
+	var coveringRole string
+	var coveringPerm *authpb.Permission
+	for i, roleName := range roles {
+		if i > 0 && containsString(roles[:i], roleName) {
+			continue
+		}
+		role := getRole(as.lg, tx, roleName)
+		if role == nil {
+			continue
+		}
+		matched := false
+		for _, perm := range role.KeyPermission {
+			if !permOverlaps(perm, key, rangeEnd, r.PermType) {
+				continue
+			}
+			matched = true
+			resp.Perms = append(resp.Perms, perm)
+			if coveringPerm == nil && permContained(as.lg, mergePerms([]*authpb.Permission{perm}), req) {
+				coveringRole, coveringPerm = roleName, perm
+			}
+		}
+		if matched {
+			resp.Roles = append(resp.Roles, roleName)
+		}
+	}
+
+	switch {
+	case resp.Allowed && coveringPerm != nil:
+		resp.Reason = fmt.Sprintf("角色 %q 的权限 %s 覆盖了请求的范围", coveringRole, permString(coveringPerm))
+	case resp.Allowed:
+		resp.Reason = fmt.Sprintf("角色 %v 的权限合并后覆盖了请求的范围", resp.Roles)
+	case len(resp.Perms) == 0:
+		resp.Reason = fmt.Sprintf("用户 %q 的角色 %v 都没有与请求范围重叠的 %s 权限", r.User, roles, r.PermType)
+	default:
+		resp.Reason = fmt.Sprintf("角色 %v 的权限只覆盖了请求范围的一部分", resp.Roles)
+	}
+	return resp, nil
+}
+
+// isCheckPermitted 与 isOpPermitted 走同一条路径(证书角色、scoped token、root、用户角色), 额外支持 READWRITE
+func (as *authStore) isCheckPermitted(tx backend.BatchTx, authInfo *AuthInfo, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	if permtyp == authpb.READWRITE {
+		return as.unsafeIsOpPermitted(tx, authInfo, key, rangeEnd, authpb.READ) &&
+			as.unsafeIsOpPermitted(tx, authInfo, key, rangeEnd, authpb.WRITE)
+	}
+	return as.unsafeIsOpPermitted(tx, authInfo, key, rangeEnd, permtyp)
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// permOverlaps 判断 perm 是否与请求的范围有交集, 且权限类型相关
+func permOverlaps(perm *authpb.Permission, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	if perm.PermType != authpb.READWRITE && permtyp != authpb.READWRITE && perm.PermType != permtyp {
+		return false
+	}
+	var ivl adt.Interval
+	switch {
+	case len(rangeEnd) == 0:
+		ivl = adt.NewBytesAffinePoint(key)
+	case len(rangeEnd) == 1 && rangeEnd[0] == 0:
+		ivl = adt.NewBytesAffineInterval(key, nil)
+	default:
+		ivl = adt.NewBytesAffineInterval(key, rangeEnd)
+	}
+	perms := mergePerms([]*authpb.Permission{perm})
+	return perms.readPerms.Intersects(ivl) || perms.writePerms.Intersects(ivl)
+}
+
+func permString(perm *authpb.Permission) string {
+	switch {
+	case len(perm.RangeEnd) == 0:
+		return fmt.Sprintf("%s %q", perm.PermType, perm.Key)
+	case perm.RangeEnd == "\x00":
+		return fmt.Sprintf("%s [%q, <open ended>)", perm.PermType, perm.Key)
+	default:
+		return fmt.Sprintf("%s [%q, %q)", perm.PermType, perm.Key, perm.RangeEnd)
+	}
+}
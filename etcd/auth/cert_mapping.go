@@ -0,0 +1,183 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/backend"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+)
+
+const (
+	CertFieldCN  = "cn"
+	CertFieldURI = "uri"
+	CertFieldDNS = "dns"
+	CertFieldOU  = "ou"
+)
+
+// CertMappingRule 把客户端证书的某个字段映射为一个用户或直接映射为一个角色.
+// 文本格式: <field>:<pattern>=user:<name> 或 <field>:<pattern>=role:<name>
+// field 取值 cn/uri/dns/ou, pattern 以 * 结尾时表示前缀匹配, 例如
+//   uri:spiffe://example.org/ns/ci/*=role:ci-reader
+type CertMappingRule struct {
+	Field   string
+	Pattern string
+	User    string // 与 Role 二选一
+	Role    string
+}
+
+// ParseCertMappingRules 解析 --client-cert-auth-rules 的配置
+func ParseCertMappingRules(rules []string) ([]CertMappingRule, error) {
+	var ret []CertMappingRule
+	for _, s := range rules {
+		r, err := parseCertMappingRule(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, r)
+	}
+	return ret, nil
+}
+
+func parseCertMappingRule(s string) (CertMappingRule, error) {
+	var r CertMappingRule
+	// pattern 里可能有 ':' 和 '=', 所以 target 从最后一个 '=' 开始
+	eq := strings.LastIndex(s, "=")
+	colon := strings.Index(s, ":")
+	if eq < 0 || colon < 0 || colon > eq {
+		return r, fmt.Errorf("无效的证书映射规则 %q", s)
+	}
+	r.Field = strings.ToLower(s[:colon])
+	r.Pattern = s[colon+1 : eq]
+	switch r.Field {
+	case CertFieldCN, CertFieldURI, CertFieldDNS, CertFieldOU:
+	default:
+		return r, fmt.Errorf("证书映射规则 %q 的字段必须是 cn/uri/dns/ou 之一", s)
+	}
+	if len(r.Pattern) == 0 {
+		return r, fmt.Errorf("证书映射规则 %q 的匹配模式不能为空", s)
+	}
+
+	target := s[eq+1:]
+	switch {
+	case strings.HasPrefix(target, "user:"):
+		r.User = strings.TrimPrefix(target, "user:")
+	case strings.HasPrefix(target, "role:"):
+		r.Role = strings.TrimPrefix(target, "role:")
+	}
+	if r.User == "" && r.Role == "" {
+		return r, fmt.Errorf("证书映射规则 %q 的目标必须是 user:<name> 或 role:<name>", s)
+	}
+	return r, nil
+}
+
+func (r CertMappingRule) match(v string) bool {
+	if strings.HasSuffix(r.Pattern, "*") {
+		return strings.HasPrefix(v, strings.TrimSuffix(r.Pattern, "*"))
+	}
+	return v == r.Pattern
+}
+
+// matchCert 返回证书中第一个匹配的字段值, 没有匹配时返回空字符串
+func (r CertMappingRule) matchCert(cert *x509.Certificate) string {
+	var values []string
+	switch r.Field {
+	case CertFieldCN:
+		values = []string{cert.Subject.CommonName}
+	case CertFieldURI:
+		for _, u := range cert.URIs {
+			values = append(values, u.String())
+		}
+	case CertFieldDNS:
+		values = cert.DNSNames
+	case CertFieldOU:
+		values = cert.Subject.OrganizationalUnit
+	}
+	for _, v := range values {
+		if v != "" && r.match(v) {
+			return v
+		}
+	}
+	return ""
+}
+
+// SetCertMappingRules 设置客户端证书到用户/角色的映射规则; 没有规则时沿用 CommonName 作为用户名
+func (as *authStore) SetCertMappingRules(rules []CertMappingRule) {
+	as.certMappingRules = rules
+}
+
+// mapCert 按规则把证书映射为用户名和角色; 第一个匹配的 user 规则决定用户名, 所有匹配的 role 规则的角色都会授予.
+// 只匹配到角色时, 用户名取匹配到的证书字段值, 仅用于日志和审计.
+func (as *authStore) mapCert(cert *x509.Certificate) (username string, roles []string) {
+	var identity string
+	for _, r := range as.certMappingRules {
+		v := r.matchCert(cert)
+		if v == "" {
+			continue
+		}
+		if r.User != "" {
+			if username == "" {
+				username = r.User
+			}
+			continue
+		}
+		if identity == "" {
+			identity = v
+		}
+		roles = append(roles, r.Role)
+	}
+	if username == "" && len(roles) == 0 {
+		return cert.Subject.CommonName, nil
+	}
+	if username == "" {
+		username = identity
+	}
+	sort.Strings(roles)
+	return username, roles
+}
+
+// isRolesOpPermitted 检查证书直接映射的角色的权限
+func (as *authStore) isRolesOpPermitted(tx backend.BatchTx, roles []string, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	// assumption: tx is Lock()ed
+	for _, role := range roles {
+		if role == rootRole {
+			return true
+		}
+	}
+
+	cacheKey := strings.Join(roles, ",")
+	perms, ok := as.rolesPermCache[cacheKey]
+	if !ok {
+		var ps []*authpb.Permission
+		for _, roleName := range roles {
+			role := getRole(as.lg, tx, roleName)
+			if role == nil {
+				continue
+			}
+			ps = append(ps, role.KeyPermission...)
+		}
+		perms = mergePerms(ps)
+		as.rolesPermCache[cacheKey] = perms
+	}
+
+	if len(rangeEnd) == 0 {
+		return checkKeyPoint(as.lg, perms, key, permtyp)
+	}
+	return checkKeyInterval(as.lg, perms, key, rangeEnd, permtyp)
+}
@@ -28,40 +28,45 @@ func getMergedPerms(lg *zap.Logger, tx backend.BatchTx, userName string) *unifie
 		return nil
 	}
 
-	readPerms := adt.NewIntervalTree()
-	writePerms := adt.NewIntervalTree()
-
+	var perms []*authpb.Permission
 	for _, roleName := range user.Roles {
 		role := getRole(lg, tx, roleName)
 		if role == nil {
 			continue
 		}
+		perms = append(perms, role.KeyPermission...)
+	}
+	return mergePerms(perms)
+}
 
-		for _, perm := range role.KeyPermission {
-			var ivl adt.Interval
-			var rangeEnd []byte
+func mergePerms(perms []*authpb.Permission) *unifiedRangePermissions {
+	readPerms := adt.NewIntervalTree()
+	writePerms := adt.NewIntervalTree()
 
-			if len(perm.RangeEnd) != 1 || perm.RangeEnd[0] != 0 {
-				rangeEnd = []byte(perm.RangeEnd)
-			}
+	for _, perm := range perms {
+		var ivl adt.Interval
+		var rangeEnd []byte
 
-			if len(perm.RangeEnd) != 0 {
-				ivl = adt.NewBytesAffineInterval([]byte(perm.Key), rangeEnd)
-			} else {
-				ivl = adt.NewBytesAffinePoint([]byte(perm.Key))
-			}
+		if len(perm.RangeEnd) != 1 || perm.RangeEnd[0] != 0 {
+			rangeEnd = []byte(perm.RangeEnd)
+		}
+
+		if len(perm.RangeEnd) != 0 {
+			ivl = adt.NewBytesAffineInterval([]byte(perm.Key), rangeEnd)
+		} else {
+			ivl = adt.NewBytesAffinePoint([]byte(perm.Key))
+		}
 
-			switch perm.PermType {
-			case authpb.READWRITE:
-				readPerms.Insert(ivl, struct{}{})
-				writePerms.Insert(ivl, struct{}{})
+		switch perm.PermType {
+		case authpb.READWRITE:
+			readPerms.Insert(ivl, struct{}{})
+			writePerms.Insert(ivl, struct{}{})
 
-			case authpb.READ:
-				readPerms.Insert(ivl, struct{}{})
+		case authpb.READ:
+			readPerms.Insert(ivl, struct{}{})
 
-			case authpb.WRITE:
-				writePerms.Insert(ivl, struct{}{})
-			}
+		case authpb.WRITE:
+			writePerms.Insert(ivl, struct{}{})
 		}
 	}
 
@@ -130,6 +135,8 @@ func (as *authStore) isRangeOpPermitted(tx backend.BatchTx, userName string, key
 
 func (as *authStore) clearCachedPerm() {
 	as.rangePermCache = make(map[string]*unifiedRangePermissions)
+	as.tokenPermCache = make(map[string]*unifiedRangePermissions)
+	as.rolesPermCache = make(map[string]*unifiedRangePermissions)
 }
 
 // 清除缓存中的全新信息, 之后重新生成
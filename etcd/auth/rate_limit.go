@@ -0,0 +1,300 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+var ErrRateLimited = errors.New("auth: 超过了用户或角色的请求速率限制")
+
+// 限速配置随用户/角色一起存储并经过raft同步, 令牌桶本身只存在于当前节点内存中,
+// 即每个节点各自按配置限速.
+// 用户的限制只作用于该用户; 角色的限制由拥有该角色的所有用户共享.
+
+func (as *authStore) UserSetRateLimit(r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := getUser(as.lg, tx, r.Name)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	limit := normalizeRateLimit(r.RateLimit)
+	user.RateLimit = limit
+	putUser(as.lg, tx, user)
+
+	as.commitRevision(tx)
+
+	as.lg.Info(
+		"设置了用户的速率限制",
+		zap.String("user-name", r.Name),
+		zap.Any("rate-limit", limit),
+	)
+	return &pb.AuthUserSetRateLimitResponse{}, nil
+}
+
+func (as *authStore) RoleSetRateLimit(r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	role := getRole(as.lg, tx, r.Name)
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	limit := normalizeRateLimit(r.RateLimit)
+	role.RateLimit = limit
+	putRole(as.lg, tx, role)
+
+	as.commitRevision(tx)
+
+	as.lg.Info(
+		"设置了角色的速率限制",
+		zap.String("role-name", r.Name),
+		zap.Any("rate-limit", limit),
+	)
+	return &pb.AuthRoleSetRateLimitResponse{}, nil
+}
+
+// normalizeRateLimit 全为0的限制等同于不限制
+func normalizeRateLimit(l *authpb.RateLimit) *authpb.RateLimit {
+	if l == nil || (l.RequestsPerSec == 0 && l.BytesPerSec == 0) {
+		return nil
+	}
+	return &authpb.RateLimit{RequestsPerSec: l.RequestsPerSec, BytesPerSec: l.BytesPerSec}
+}
+
+// AllowRequest 检查请求是否超过了用户及其角色的速率限制; msg 实现了 Size() 时按其大小计算字节数
+func (as *authStore) AllowRequest(authInfo *AuthInfo, msg interface{}) error {
+	now := time.Now()
+	reserved, ok := as.reserveRequest(now, authInfo, msg)
+	if ok && reservationDelay(now, reserved) == 0 {
+		return nil
+	}
+	// 任意一个桶拒绝时, 归还已经从其他桶中取走的令牌
+	cancelReservations(now, reserved)
+	return ErrRateLimited
+}
+
+// WaitRequest 与 AllowRequest 相同, 但超过限制时等待令牌而不是拒绝; 用于流上的消息,
+// 拒绝单个消息会结束整个流(租约过期, 流上所有watch被取消). 只在ctx结束时返回错误.
+func (as *authStore) WaitRequest(ctx context.Context, authInfo *AuthInfo, msg interface{}) error {
+	now := time.Now()
+	reserved, ok := as.reserveRequest(now, authInfo, msg)
+	if !ok {
+		cancelReservations(now, reserved)
+		return ErrRateLimited
+	}
+	delay := reservationDelay(now, reserved)
+	if delay == 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		cancelReservations(time.Now(), reserved)
+		return ctx.Err()
+	}
+}
+
+// reserveRequest 从请求需要经过的所有令牌桶中预留令牌; 某个桶无法预留时返回false
+func (as *authStore) reserveRequest(now time.Time, authInfo *AuthInfo, msg interface{}) ([]*rate.Reservation, bool) {
+	if authInfo == nil || !as.IsAuthEnabled() {
+		return nil, true
+	}
+	// 令牌桶的限制可能被并发调整, 读取和扣减令牌都在锁内完成; 计算请求大小的开销较大, 放在锁外且只在需要时进行
+	rl := as.rateLimiters
+	rl.mu.Lock()
+	limiters := as.unsafeRateLimitersOf(authInfo)
+	needBytes := false
+	for _, l := range limiters {
+		needBytes = needBytes || l.bytes != nil
+	}
+	rl.mu.Unlock()
+	if len(limiters) == 0 {
+		return nil, true
+	}
+
+	size := 0
+	if m, ok := msg.(interface{ Size() int }); ok && needBytes {
+		size = m.Size()
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	var reserved []*rate.Reservation
+	for _, l := range limiters {
+		rs, ok := l.reserve(now, size)
+		reserved = append(reserved, rs...)
+		if !ok {
+			return reserved, false
+		}
+	}
+	return reserved, true
+}
+
+// reservationDelay 所有预留中最长的等待时间
+func reservationDelay(now time.Time, reserved []*rate.Reservation) time.Duration {
+	var delay time.Duration
+	for _, r := range reserved {
+		if d := r.DelayFrom(now); d > delay {
+			delay = d
+		}
+	}
+	return delay
+}
+
+func cancelReservations(now time.Time, reserved []*rate.Reservation) {
+	for _, r := range reserved {
+		r.CancelAt(now)
+	}
+}
+
+// unsafeRateLimitersOf 返回请求需要经过的令牌桶; 结果按用户和证书角色缓存, 鉴权版本变化后重新计算
+func (as *authStore) unsafeRateLimitersOf(authInfo *AuthInfo) []*rateLimiter {
+	// assumption: as.rateLimiters.mu is Lock()ed
+	rl := as.rateLimiters
+
+	if rev := as.Revision(); rev != rl.revision {
+		rl.revision = rev
+		rl.byUser = make(map[string][]*rateLimiter)
+		// 旧的令牌桶在重新计算时按名称复用, 不再被引用的随之丢弃
+		rl.stale, rl.limiters = rl.limiters, make(map[string]*rateLimiter)
+	}
+
+	key := authInfo.Username + "/" + strings.Join(authInfo.Roles, ",")
+	if ls, ok := rl.byUser[key]; ok {
+		return ls
+	}
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	var ls []*rateLimiter
+	roles := append([]string(nil), authInfo.Roles...)
+	if user := getUser(as.lg, tx, authInfo.Username); user != nil {
+		if user.RateLimit != nil {
+			ls = append(ls, rl.get("user/"+user.Name, user.RateLimit))
+		}
+		roles = append(roles, user.Roles...)
+	}
+	sort.Strings(roles)
+	for i, roleName := range roles {
+		if i > 0 && roles[i-1] == roleName {
+			continue
+		}
+		role := getRole(as.lg, tx, roleName)
+		if role != nil && role.RateLimit != nil {
+			ls = append(ls, rl.get("role/"+role.Name, role.RateLimit))
+		}
+	}
+	tx.Unlock()
+
+	rl.byUser[key] = ls
+	return ls
+}
+
+type rateLimiterSet struct {
+	mu       sync.Mutex
+	revision uint64
+	byUser   map[string][]*rateLimiter // 用户名/证书角色 -> 需要经过的令牌桶
+	limiters map[string]*rateLimiter   // "user/<name>" 或 "role/<name>" -> 令牌桶
+	stale    map[string]*rateLimiter
+}
+
+func newRateLimiterSet() *rateLimiterSet {
+	return &rateLimiterSet{
+		byUser:   make(map[string][]*rateLimiter),
+		limiters: make(map[string]*rateLimiter),
+	}
+}
+
+// get 返回名称对应的令牌桶, 限制变化时原地调整, 保留桶中剩余的令牌
+func (rl *rateLimiterSet) get(name string, limit *authpb.RateLimit) *rateLimiter {
+	l, ok := rl.limiters[name]
+	if !ok {
+		l, ok = rl.stale[name]
+	}
+	if !ok {
+		l = &rateLimiter{}
+	}
+	l.setLimit(limit)
+	rl.limiters[name] = l
+	return l
+}
+
+type rateLimiter struct {
+	requests *rate.Limiter // 为nil表示不限制
+	bytes    *rate.Limiter
+}
+
+// setLimit 突发量为一秒的配额
+func (l *rateLimiter) setLimit(limit *authpb.RateLimit) {
+	l.requests = adjustLimiter(l.requests, limit.RequestsPerSec)
+	l.bytes = adjustLimiter(l.bytes, limit.BytesPerSec)
+}
+
+func adjustLimiter(l *rate.Limiter, perSec uint64) *rate.Limiter {
+	if perSec == 0 {
+		return nil
+	}
+	if l == nil {
+		return rate.NewLimiter(rate.Limit(perSec), int(perSec))
+	}
+	if l.Limit() != rate.Limit(perSec) {
+		l.SetLimit(rate.Limit(perSec))
+		l.SetBurst(int(perSec))
+	}
+	return l
+}
+
+// reserve 预留令牌, 需要等待的时间由调用方根据预留结果决定; 无法预留时返回false
+func (l *rateLimiter) reserve(now time.Time, size int) ([]*rate.Reservation, bool) {
+	var rs []*rate.Reservation
+	if l.requests != nil {
+		r := l.requests.ReserveN(now, 1)
+		if !r.OK() {
+			return rs, false
+		}
+		rs = append(rs, r)
+	}
+	if l.bytes != nil && size > 0 {
+		// 单个请求超过突发量时, 等桶满后按突发量计费, 否则该请求永远无法通过
+		if burst := l.bytes.Burst(); size > burst {
+			size = burst
+		}
+		r := l.bytes.ReserveN(now, size)
+		if !r.OK() {
+			return rs, false
+		}
+		rs = append(rs, r)
+	}
+	return rs, true
+}
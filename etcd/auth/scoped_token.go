@@ -0,0 +1,331 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/backend"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/buckets"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+	"go.uber.org/zap"
+)
+
+// scoped token: 具名、可撤销、有过期时间的 API token, 权限是所属用户权限的子集,
+// 用于 CI 等自动化场景, 避免共享用户密码.
+const (
+	scopedTokenPrefix = "apitoken."
+	scopedTokenLength = 32
+)
+
+var (
+	ErrTokenNameEmpty         = errors.New("auth: token名称不能为空")
+	ErrTokenAlreadyExist      = errors.New("auth: token已存在")
+	ErrTokenNotFound          = errors.New("auth: token不存在")
+	ErrTokenExpired           = errors.New("auth: token已过期")
+	ErrInvalidTokenTTL        = errors.New("auth: token的TTL必须大于0")
+	ErrInvalidTokenPermission = errors.New("auth: token的权限必须是用户权限的子集")
+)
+
+// GenScopedToken 生成一个新的 scoped token 及其哈希; 只有哈希会经过raft并持久化
+func GenScopedToken() (token string, hashed string, err error) {
+	ret := make([]byte, scopedTokenLength)
+	for i := 0; i < scopedTokenLength; i++ {
+		bInt, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		if err != nil {
+			return "", "", err
+		}
+		ret[i] = letters[bInt.Int64()]
+	}
+	token = scopedTokenPrefix + string(ret)
+	return token, hashScopedToken(token), nil
+}
+
+func isScopedToken(token string) bool {
+	return strings.HasPrefix(token, scopedTokenPrefix)
+}
+
+func hashScopedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// authInfoFromScopedToken 过期检查只在这里做, apply 阶段不依赖本地时钟
+func (as *authStore) authInfoFromScopedToken(token string) (*AuthInfo, error) {
+	hashed := hashScopedToken(token)
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	st := getScopedToken(as.lg, tx, hashed)
+	tx.Unlock()
+
+	if st == nil {
+		return nil, ErrInvalidAuthToken
+	}
+	if time.Now().Unix() >= st.ExpireTime {
+		as.lg.Warn("scoped token已过期", zap.String("user-name", st.User), zap.String("token-name", st.Name))
+		return nil, ErrTokenExpired
+	}
+	return &AuthInfo{Username: st.User, Revision: as.Revision(), ScopedToken: hashed}, nil
+}
+
+func (as *authStore) UserTokenCreate(r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	if len(r.Name) == 0 {
+		return nil, ErrTokenNameEmpty
+	}
+	if r.ExpireTime <= 0 || len(r.HashedToken) == 0 {
+		return nil, ErrInvalidTokenTTL
+	}
+
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	user := getUser(as.lg, tx, r.User)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	for _, st := range getAllScopedTokens(as.lg, tx) {
+		if st.User == r.User && st.Name == r.Name {
+			return nil, ErrTokenAlreadyExist
+		}
+	}
+
+	// root 拥有所有权限, 不需要检查
+	if !hasRootRole(user) {
+		userPerms := getMergedPerms(as.lg, tx, r.User)
+		for _, perm := range r.Perm {
+			if !permContained(as.lg, userPerms, perm) {
+				return nil, ErrInvalidTokenPermission
+			}
+		}
+	}
+
+	st := &authpb.ScopedToken{
+		Name:          r.Name,
+		User:          r.User,
+		ExpireTime:    r.ExpireTime,
+		KeyPermission: r.Perm,
+	}
+	sort.Sort(permSlice(st.KeyPermission))
+	putScopedToken(as.lg, tx, r.HashedToken, st)
+
+	as.commitRevision(tx)
+
+	as.lg.Info(
+		"创建了一个scoped token",
+		zap.String("user-name", r.User),
+		zap.String("token-name", r.Name),
+		zap.Int64("expire-time", r.ExpireTime),
+	)
+	return &pb.AuthUserTokenCreateResponse{ExpireTime: r.ExpireTime}, nil
+}
+
+func (as *authStore) UserTokenList(r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	if getUser(as.lg, tx, r.User) == nil {
+		return nil, ErrUserNotFound
+	}
+
+	resp := &pb.AuthUserTokenListResponse{}
+	for _, st := range getAllScopedTokens(as.lg, tx) {
+		if st.User == r.User {
+			resp.Tokens = append(resp.Tokens, st)
+		}
+	}
+	sort.Slice(resp.Tokens, func(i, j int) bool { return resp.Tokens[i].Name < resp.Tokens[j].Name })
+	return resp, nil
+}
+
+func (as *authStore) UserTokenRevoke(r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	hashed := findScopedToken(as.lg, tx, r.User, r.Name)
+	if hashed == "" {
+		return nil, ErrTokenNotFound
+	}
+	delScopedToken(tx, hashed)
+
+	as.commitRevision(tx)
+
+	delete(as.tokenPermCache, hashed)
+
+	as.lg.Info("撤销了一个scoped token", zap.String("user-name", r.User), zap.String("token-name", r.Name))
+	return &pb.AuthUserTokenRevokeResponse{}, nil
+}
+
+// HasExpiredScopedTokens 是否存在过期时间不晚于now的token; leader 据此决定是否发起清理
+func (as *authStore) HasExpiredScopedTokens(now int64) bool {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	for _, st := range getAllScopedTokens(as.lg, tx) {
+		if st.ExpireTime <= now {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeExpiredScopedTokens 删除过期的token; 截止时间来自请求, 各节点的结果一致
+func (as *authStore) PurgeExpiredScopedTokens(r *pb.InternalAuthTokenPurgeRequest) (*pb.EmptyResponse, error) {
+	tx := as.be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+
+	purged := 0
+	keys, vs := tx.UnsafeRange(buckets.AuthTokens, []byte{0}, []byte{0xff}, -1)
+	for i := range vs {
+		st := unmarshalScopedToken(as.lg, vs[i])
+		if st.ExpireTime <= r.ExpireBefore {
+			delScopedToken(tx, string(keys[i]))
+			delete(as.tokenPermCache, string(keys[i]))
+			purged++
+		}
+	}
+	if purged == 0 {
+		return &pb.EmptyResponse{}, nil
+	}
+
+	as.commitRevision(tx)
+
+	as.lg.Info("清理了过期的scoped token", zap.Int("purged", purged), zap.Int64("expire-before", r.ExpireBefore))
+	return &pb.EmptyResponse{}, nil
+}
+
+// delUserScopedTokens 删除用户时一并删除其所有token
+func (as *authStore) delUserScopedTokens(tx backend.BatchTx, username string) {
+	keys, vs := tx.UnsafeRange(buckets.AuthTokens, []byte{0}, []byte{0xff}, -1)
+	for i := range vs {
+		st := unmarshalScopedToken(as.lg, vs[i])
+		if st.User == username {
+			delScopedToken(tx, string(keys[i]))
+			delete(as.tokenPermCache, string(keys[i]))
+		}
+	}
+}
+
+// isScopedTokenOpPermitted 检查token自身的权限范围; 用户权限由调用方另行检查
+func (as *authStore) isScopedTokenOpPermitted(tx backend.BatchTx, userName, hashed string, key, rangeEnd []byte, permtyp authpb.Permission_Type) bool {
+	// assumption: tx is Lock()ed
+	perms, ok := as.tokenPermCache[hashed]
+	if !ok {
+		st := getScopedToken(as.lg, tx, hashed)
+		if st == nil || st.User != userName {
+			return false
+		}
+		perms = mergePerms(st.KeyPermission)
+		as.tokenPermCache[hashed] = perms
+	}
+
+	if len(rangeEnd) == 0 {
+		return checkKeyPoint(as.lg, perms, key, permtyp)
+	}
+	return checkKeyInterval(as.lg, perms, key, rangeEnd, permtyp)
+}
+
+// permContained 判断perm是否被perms完全覆盖
+func permContained(lg *zap.Logger, perms *unifiedRangePermissions, perm *authpb.Permission) bool {
+	if perms == nil {
+		return false
+	}
+	var check func(typ authpb.Permission_Type) bool
+	if len(perm.RangeEnd) == 0 {
+		check = func(typ authpb.Permission_Type) bool {
+			return checkKeyPoint(lg, perms, []byte(perm.Key), typ)
+		}
+	} else {
+		check = func(typ authpb.Permission_Type) bool {
+			return checkKeyInterval(lg, perms, []byte(perm.Key), []byte(perm.RangeEnd), typ)
+		}
+	}
+
+	switch perm.PermType {
+	case authpb.READ:
+		return check(authpb.READ)
+	case authpb.WRITE:
+		return check(authpb.WRITE)
+	case authpb.READWRITE:
+		return check(authpb.READ) && check(authpb.WRITE)
+	}
+	return false
+}
+
+func findScopedToken(lg *zap.Logger, tx backend.BatchTx, username, name string) string {
+	keys, vs := tx.UnsafeRange(buckets.AuthTokens, []byte{0}, []byte{0xff}, -1)
+	for i := range vs {
+		st := unmarshalScopedToken(lg, vs[i])
+		if st.User == username && st.Name == name {
+			return string(keys[i])
+		}
+	}
+	return ""
+}
+
+func getScopedToken(lg *zap.Logger, tx backend.BatchTx, hashed string) *authpb.ScopedToken {
+	_, vs := tx.UnsafeRange(buckets.AuthTokens, []byte(hashed), nil, 0)
+	if len(vs) == 0 {
+		return nil
+	}
+	return unmarshalScopedToken(lg, vs[0])
+}
+
+func getAllScopedTokens(lg *zap.Logger, tx backend.BatchTx) []*authpb.ScopedToken {
+	_, vs := tx.UnsafeRange(buckets.AuthTokens, []byte{0}, []byte{0xff}, -1)
+	if len(vs) == 0 {
+		return nil
+	}
+
+	tokens := make([]*authpb.ScopedToken, len(vs))
+	for i := range vs {
+		tokens[i] = unmarshalScopedToken(lg, vs[i])
+	}
+	return tokens
+}
+
+func unmarshalScopedToken(lg *zap.Logger, v []byte) *authpb.ScopedToken {
+	st := &authpb.ScopedToken{}
+	if err := st.Unmarshal(v); err != nil {
+		lg.Panic("不能反序列化 'authpb.ScopedToken'", zap.Error(err))
+	}
+	return st
+}
+
+func putScopedToken(lg *zap.Logger, tx backend.BatchTx, hashed string, st *authpb.ScopedToken) {
+	b, err := st.Marshal()
+	if err != nil {
+		lg.Panic("序列化失败 'authpb.ScopedToken'", zap.Error(err))
+	}
+	tx.UnsafePut(buckets.AuthTokens, []byte(hashed), b)
+}
+
+func delScopedToken(tx backend.BatchTx, hashed string) {
+	tx.UnsafeDelete(buckets.AuthTokens, []byte(hashed))
+}
@@ -82,6 +82,10 @@ const (
 type AuthInfo struct {
 	Username string
 	Revision uint64
+	// ScopedToken 请求使用的scoped token的哈希, 权限被限制在该token的范围内
+	ScopedToken string
+	// Roles 由客户端证书映射规则直接授予的角色
+	Roles []string
 }
 
 // AuthenticateParamIndex is used for a key of context in the parameters of Authenticate()
@@ -103,6 +107,22 @@ type AuthStore interface {
 	UserGrantRole(r *pb.AuthUserGrantRoleRequest) (*pb.AuthUserGrantRoleResponse, error)
 	UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse, error)
 	UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUserRevokeRoleResponse, error)
+	UserTokenCreate(r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error)
+	UserTokenList(r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error)
+	UserTokenRevoke(r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error)
+	// HasExpiredScopedTokens 是否存在过期时间不晚于now(unix秒)的scoped token
+	HasExpiredScopedTokens(now int64) bool
+	// PurgeExpiredScopedTokens 删除过期的scoped token, 由raft应用
+	PurgeExpiredScopedTokens(r *pb.InternalAuthTokenPurgeRequest) (*pb.EmptyResponse, error)
+
+	// AuthCheck 评估用户对某个范围的权限并说明原因; authInfo 是请求者, 检查自己时带上其证书映射的角色和 scoped token 的范围
+	AuthCheck(authInfo *AuthInfo, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error)
+	UserSetRateLimit(r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error)
+	RoleSetRateLimit(r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error)
+	// AllowRequest 检查请求是否超过了用户及其角色的速率限制
+	AllowRequest(authInfo *AuthInfo, msg interface{}) error
+	// WaitRequest 超过速率限制时等待而不是拒绝, 用于流上的消息
+	WaitRequest(ctx context.Context, authInfo *AuthInfo, msg interface{}) error
 
 	RoleAdd(r *pb.AuthRoleAddRequest) (*pb.AuthRoleAddResponse, error)
 	RoleGrantPermission(r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error)
@@ -143,8 +163,13 @@ type authStore struct {
 	enabled        bool                                // 是否开启认证
 	enabledMu      sync.RWMutex                        //
 	rangePermCache map[string]*unifiedRangePermissions // username -> unifiedRangePermissions
+	tokenPermCache map[string]*unifiedRangePermissions // hashed scoped token -> unifiedRangePermissions
+	rolesPermCache map[string]*unifiedRangePermissions // 证书映射的角色列表 -> unifiedRangePermissions
 	tokenProvider  TokenProvider                       // TODO
 	bcryptCost     int                                 // the algorithm cost / strength for hashing auth passwords
+
+	certMappingRules []CertMappingRule // 客户端证书到用户/角色的映射规则
+	rateLimiters     *rateLimiterSet   // 用户/角色的令牌桶
 }
 
 func (as *authStore) AuthEnable() error {
@@ -247,6 +272,8 @@ func (as *authStore) Recover(be backend.Backend) {
 	as.be = be
 	tx := be.BatchTx()
 	tx.Lock()
+	// 旧版本的快照里没有这个bucket
+	tx.UnsafeCreateBucket(buckets.AuthTokens)
 	_, vs := tx.UnsafeRange(buckets.Auth, enableFlagKey, nil, 0)
 	if len(vs) == 1 {
 		if bytes.Equal(vs[0], authEnabled) {
@@ -255,6 +282,7 @@ func (as *authStore) Recover(be backend.Backend) {
 	}
 
 	as.setRevision(getRevision(tx))
+	as.clearCachedPerm()
 
 	tx.Unlock()
 
@@ -267,12 +295,13 @@ func (as *authStore) authInfoFromToken(ctx context.Context, token string) (*Auth
 	return as.tokenProvider.info(ctx, token, as.Revision())
 }
 
-func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
+func (as *authStore) isOpPermitted(authInfo *AuthInfo, key, rangeEnd []byte, permTyp authpb.Permission_Type) error {
 	// 这个函数的开销很大,所以我们需要一个缓存机制
 	if !as.IsAuthEnabled() {
 		return nil
 	}
 
+	revision := authInfo.Revision
 	// only gets rev == 0 when passed AuthInfo{}; no user given
 	if revision == 0 {
 		return ErrUserEmpty
@@ -291,34 +320,53 @@ func (as *authStore) isOpPermitted(userName string, revision uint64, key, rangeE
 	tx.Lock()
 	defer tx.Unlock()
 
+	if as.unsafeIsOpPermitted(tx, authInfo, key, rangeEnd, permTyp) {
+		return nil
+	}
+	return ErrPermissionDenied
+}
+
+// unsafeIsOpPermitted 依次检查证书映射的角色、scoped token 的范围和用户自身的权限
+func (as *authStore) unsafeIsOpPermitted(tx backend.BatchTx, authInfo *AuthInfo, key, rangeEnd []byte, permTyp authpb.Permission_Type) bool {
+	// assumption: tx is Lock()ed
+	userName := authInfo.Username
+
+	// 证书直接映射的角色, 不要求存在对应的用户
+	if len(authInfo.Roles) > 0 && as.isRolesOpPermitted(tx, authInfo.Roles, key, rangeEnd, permTyp) {
+		return true
+	}
+
 	user := getUser(as.lg, tx, userName)
 	if user == nil {
-		as.lg.Error("cannot find a user for permission check", zap.String("user-name", userName))
-		return ErrPermissionDenied
+		if len(authInfo.Roles) == 0 {
+			as.lg.Error("cannot find a user for permission check", zap.String("user-name", userName))
+		}
+		return false
 	}
 
-	// root role should have permission on all ranges
-	if hasRootRole(user) {
-		return nil
+	// scoped token 的权限不能超出token本身的范围, 即使用户是root
+	if authInfo.ScopedToken != "" && !as.isScopedTokenOpPermitted(tx, userName, authInfo.ScopedToken, key, rangeEnd, permTyp) {
+		return false
 	}
 
-	if as.isRangeOpPermitted(tx, userName, key, rangeEnd, permTyp) {
-		return nil
+	// root role should have permission on all ranges
+	if hasRootRole(user) {
+		return true
 	}
 
-	return ErrPermissionDenied
+	return as.isRangeOpPermitted(tx, userName, key, rangeEnd, permTyp)
 }
 
 func (as *authStore) IsPutPermitted(authInfo *AuthInfo, key []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, nil, authpb.WRITE)
+	return as.isOpPermitted(authInfo, key, nil, authpb.WRITE)
 }
 
 func (as *authStore) IsRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.READ) // '' ,0 ,health,nil
+	return as.isOpPermitted(authInfo, key, rangeEnd, authpb.READ) // '' ,0 ,health,nil
 }
 
 func (as *authStore) IsDeleteRangePermitted(authInfo *AuthInfo, key, rangeEnd []byte) error {
-	return as.isOpPermitted(authInfo.Username, authInfo.Revision, key, rangeEnd, authpb.WRITE)
+	return as.isOpPermitted(authInfo, key, rangeEnd, authpb.WRITE)
 }
 
 func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
@@ -328,6 +376,15 @@ func (as *authStore) IsAdminPermitted(authInfo *AuthInfo) error {
 	if authInfo == nil || authInfo.Username == "" {
 		return ErrUserEmpty
 	}
+	// scoped token 不能用于管理操作
+	if authInfo.ScopedToken != "" {
+		return ErrPermissionDenied
+	}
+	for _, role := range authInfo.Roles {
+		if role == rootRole {
+			return nil
+		}
+	}
 
 	tx := as.be.BatchTx()
 	tx.Lock()
@@ -375,6 +432,7 @@ func NewAuthStore(lg *zap.Logger, be backend.Backend, tp TokenProvider, bcryptCo
 	tx.UnsafeCreateBucket(buckets.Auth)
 	tx.UnsafeCreateBucket(buckets.AuthUsers)
 	tx.UnsafeCreateBucket(buckets.AuthRoles)
+	tx.UnsafeCreateBucket(buckets.AuthTokens)
 
 	enabled := false
 	_, vs := tx.UnsafeRange(buckets.Auth, enableFlagKey, nil, 0)
@@ -390,6 +448,9 @@ func NewAuthStore(lg *zap.Logger, be backend.Backend, tp TokenProvider, bcryptCo
 		be:             be,
 		enabled:        enabled,
 		rangePermCache: make(map[string]*unifiedRangePermissions),
+		tokenPermCache: make(map[string]*unifiedRangePermissions),
+		rolesPermCache: make(map[string]*unifiedRangePermissions),
+		rateLimiters:   newRateLimiterSet(),
 		tokenProvider:  tp,
 		bcryptCost:     bcryptCost,
 	}
@@ -453,9 +514,11 @@ func (as *authStore) AuthInfoFromTLS(ctx context.Context) (ai *AuthInfo) {
 		if len(chains) < 1 {
 			continue
 		}
+		username, roles := as.mapCert(chains[0])
 		ai = &AuthInfo{
-			Username: chains[0].Subject.CommonName,
+			Username: username,
 			Revision: as.Revision(),
+			Roles:    roles,
 		}
 		md, ok := metadata.FromIncomingContext(ctx)
 		if !ok {
@@ -476,8 +539,9 @@ func (as *authStore) AuthInfoFromTLS(ctx context.Context) (ai *AuthInfo) {
 		}
 		as.lg.Debug(
 			"found command name",
-			zap.String("common-name", ai.Username),
+			zap.String("common-name", chains[0].Subject.CommonName),
 			zap.String("user-name", ai.Username),
+			zap.Strings("roles", ai.Roles),
 			zap.Uint64("revision", ai.Revision),
 		)
 		break
@@ -501,6 +565,9 @@ func (as *authStore) AuthInfoFromCtx(ctx context.Context) (*AuthInfo, error) {
 	}
 
 	token := ts[0]
+	if isScopedToken(token) {
+		return as.authInfoFromScopedToken(token)
+	}
 	authInfo, uok := as.authInfoFromToken(ctx, token)
 	if !uok {
 		as.lg.Warn("invalid auth token", zap.String("token", token))
@@ -635,7 +702,8 @@ func (as *authStore) RoleRevokePermission(r *pb.AuthRoleRevokePermissionRequest)
 	}
 
 	updatedRole := &authpb.Role{
-		Name: role.Name,
+		Name:      role.Name,
+		RateLimit: role.RateLimit,
 	}
 
 	for _, perm := range role.KeyPermission {
@@ -741,9 +809,10 @@ func (as *authStore) RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDelete
 	users := getAllUsers(as.lg, tx) // 获取所有用户
 	for _, user := range users {
 		updatedUser := &authpb.User{
-			Name:     user.Name,
-			Password: user.Password,
-			Options:  user.Options,
+			Name:      user.Name,
+			Password:  user.Password,
+			Options:   user.Options,
+			RateLimit: user.RateLimit,
 		}
 		for _, role := range user.Roles {
 			if role != r.Role {
@@ -756,6 +825,7 @@ func (as *authStore) RoleDelete(r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDelete
 		putUser(as.lg, tx, updatedUser)
 		as.invalidateCachedPerm(user.Name)
 	}
+	as.rolesPermCache = make(map[string]*unifiedRangePermissions)
 
 	as.commitRevision(tx)
 
@@ -803,6 +873,7 @@ func (as *authStore) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetResponse,
 		return nil, ErrRoleNotFound
 	}
 	resp.Perm = append(resp.Perm, role.KeyPermission...)
+	resp.RateLimit = role.RateLimit
 	return &resp, nil
 }
 
@@ -997,6 +1068,7 @@ func (as *authStore) UserDelete(r *pb.AuthUserDeleteRequest) (*pb.AuthUserDelete
 	}
 
 	delUser(tx, r.Name)
+	as.delUserScopedTokens(tx, r.Name)
 
 	as.commitRevision(tx)
 
@@ -1032,10 +1104,11 @@ func (as *authStore) UserChangePassword(r *pb.AuthUserChangePasswordRequest) (*p
 	}
 
 	updatedUser := &authpb.User{
-		Name:     r.Name,
-		Roles:    user.Roles,
-		Password: string(password),
-		Options:  user.Options,
+		Name:      r.Name,
+		Roles:     user.Roles,
+		Password:  string(password),
+		Options:   user.Options,
+		RateLimit: user.RateLimit,
 	}
 
 	putUser(as.lg, tx, updatedUser)
@@ -1111,6 +1184,8 @@ func (as *authStore) UserGet(r *pb.AuthUserGetRequest) (*pb.AuthUserGetResponse,
 
 	var resp pb.AuthUserGetResponse
 	resp.Roles = append(resp.Roles, user.Roles...)
+	resp.RateLimit = user.RateLimit
+	resp.Options = user.Options
 	return &resp, nil
 }
 
@@ -1147,9 +1222,10 @@ func (as *authStore) UserRevokeRole(r *pb.AuthUserRevokeRoleRequest) (*pb.AuthUs
 	}
 
 	updatedUser := &authpb.User{
-		Name:     user.Name,
-		Password: user.Password,
-		Options:  user.Options,
+		Name:      user.Name,
+		Password:  user.Password,
+		Options:   user.Options,
+		RateLimit: user.RateLimit,
 	}
 
 	for _, role := range user.Roles {
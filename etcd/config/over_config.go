@@ -79,10 +79,25 @@ type ServerConfig struct {
 	AuthToken             string // 认证格式  simple、jwt
 	BcryptCost            uint   // 为散列身份验证密码指定bcrypt算法的成本/强度默认10
 	TokenTTL              uint
+	ClientCertAuthRules   []string // 客户端证书到用户/角色的映射规则
 
 	InitialCorruptCheck bool // 数据毁坏检测功能,运行之后,在开始服务之前
 	CorruptCheckTime    time.Duration
 
+	// CompactHashCheckEnabled 每次压缩完成后由leader检查数据一致性, 不一致时定位到具体的key范围
+	CompactHashCheckEnabled bool
+	// CorruptAutoRepair leader 自动用快照修复有 CORRUPT 警报的成员, 修复成功后解除警报
+	CorruptAutoRepair bool
+
+	// SlowDiskAlarmFsyncP99 最近 WAL fsync 耗时的 p99 超过该值时触发 SLOWDISK 警报, 0 表示不检查
+	SlowDiskAlarmFsyncP99 time.Duration
+	// ClockSkewAlarmThreshold 与任一成员的时钟差超过该值时触发 CLOCKSKEW 警报, 0 表示不检查
+	ClockSkewAlarmThreshold time.Duration
+	// ApplyLagAlarmEntries 已提交未应用的日志数超过该值时触发 APPLYLAG 警报, 0 表示不检查
+	ApplyLagAlarmEntries uint64
+	// AdmissionMemoryBytes 堆内存达到该值时开始按优先级拒绝新的提议, 0 表示不按内存限制
+	AdmissionMemoryBytes uint64
+
 	PreVote bool // PreVote 是否启用PreVote
 
 	// SocketOpts are socket options passed to listener config.
@@ -108,6 +123,26 @@ type ServerConfig struct {
 
 	WatchProgressNotifyInterval time.Duration
 
+	// WatchSessionGracePeriod 可恢复的watch在连接断开后保留的时间, 0表示不支持恢复
+	WatchSessionGracePeriod time.Duration
+	// WatchSessionBufferSize 每个可恢复的watch最多缓存的事件数
+	WatchSessionBufferSize int
+	// WatchSessionMaxParked 等待恢复的会话总数上限, 超过后断开的会话直接关闭, 0表示不限制
+	WatchSessionMaxParked int
+	// WatchSessionMaxParkedPerUser 每个用户等待恢复的会话数上限, 0表示不限制
+	WatchSessionMaxParkedPerUser int
+	// WatchCacheRevisions 服务端缓存最近多少个修订版本的watch事件, 供落后的watcher同步, 0表示不缓存
+	WatchCacheRevisions int64
+	// WatchStreamMaxPending 每个watch流最多积压的事件数, 0表示不限制
+	WatchStreamMaxPending int
+	// SlowWatcherPolicy watch流积压超限后的处理方式: block 或 cancel
+	SlowWatcherPolicy string
+
+	// LinearizableReadMode 线性一致读的模式: safe 或 lease
+	LinearizableReadMode LinearizableReadMode
+	// LeaseReadClockDrift lease模式下leader租约比选举超时短的时长
+	LeaseReadClockDrift time.Duration
+
 	// UnsafeNoFsync 禁用所有fsync的使用.设置这个是不安全的,会导致数据丢失.
 	UnsafeNoFsync bool `json:"unsafe-no-fsync"`
 
@@ -256,6 +291,12 @@ func (c *ServerConfig) ElectionTimeout() time.Duration {
 	return time.Duration(c.ElectionTicks*int(c.TickMs)) * time.Millisecond
 }
 
+// LeaseReadClockDriftTicks 租约预留的时钟漂移对应的tick数, 向上取整
+func (c *ServerConfig) LeaseReadClockDriftTicks() int {
+	tick := time.Duration(c.TickMs) * time.Millisecond
+	return int((c.LeaseReadClockDrift + tick - 1) / tick)
+}
+
 func (c *ServerConfig) PeerDialTimeout() time.Duration {
 	return time.Second + time.Duration(c.ElectionTicks*int(c.TickMs))*time.Millisecond
 }
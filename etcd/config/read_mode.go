@@ -0,0 +1,28 @@
+// Copyright 2021 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// LinearizableReadMode 线性一致读确认leader身份的方式; 集群所有成员应使用相同的模式
+type LinearizableReadMode string
+
+const (
+	// LinearizableReadSafe 每批读请求都由leader发送心跳, 得到多数节点确认后再读取
+	LinearizableReadSafe = LinearizableReadMode("safe")
+	// LinearizableReadLease leader在租约内直接返回已提交索引, 省去心跳往返;
+	// 依赖节点间的时钟漂移不超过 LeaseReadClockDrift, 租约无法确认时退化为 safe
+	LinearizableReadLease = LinearizableReadMode("lease")
+
+	LinearizableReadDefault = LinearizableReadSafe
+)
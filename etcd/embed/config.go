@@ -31,9 +31,11 @@ import (
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/tlsutil"
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/transport"
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
+	"github.com/ls-2018/etcd_cn/etcd/auth"
 	"github.com/ls-2018/etcd_cn/etcd/config"
 	"github.com/ls-2018/etcd_cn/etcd/etcdserver"
 	"github.com/ls-2018/etcd_cn/etcd/etcdserver/api/v3compactor"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
 	"github.com/ls-2018/etcd_cn/pkg/flags"
 	"github.com/ls-2018/etcd_cn/pkg/netutil"
 
@@ -61,6 +63,14 @@ const (
 	DefaultGRPCKeepAliveTimeout  = 20 * time.Second
 	DefaultDowngradeCheckTime    = 5 * time.Second
 
+	DefaultWatchSessionGracePeriod = 30 * time.Second
+	DefaultWatchSessionBufferSize  = 1000
+	// 每个会话在宽限期内占用一个watch stream、一个goroutine和事件缓存
+	DefaultWatchSessionMaxParked        = 10000
+	DefaultWatchSessionMaxParkedPerUser = 100
+
+	DefaultLeaseReadClockDrift = 200 * time.Millisecond
+
 	DefaultListenPeerURLs   = "http://localhost:2380"
 	DefaultListenClientURLs = "http://localhost:2379"
 
@@ -261,9 +271,23 @@ type Config struct {
 	BcryptCost uint   `json:"bcrypt-cost"` // 为散列身份验证密码指定bcrypt算法的成本/强度.有效值介于4和31之间.默认值:10
 
 	AuthTokenTTL uint `json:"auth-token-ttl"` // token 有效期
+	// ClientCertAuthRules 客户端证书到用户/角色的映射规则, 例如 uri:spiffe://example.org/ns/ci/*=role:ci-reader
+	ClientCertAuthRules []string `json:"client-cert-auth-rules"`
 
 	ExperimentalInitialCorruptCheck bool          `json:"experimental-initial-corrupt-check"` // 数据毁坏检测功能
 	ExperimentalCorruptCheckTime    time.Duration `json:"experimental-corrupt-check-time"`    // 数据毁坏检测功能
+	// ExperimentalCompactHashCheckEnabled 每次压缩完成后由leader检查数据一致性
+	ExperimentalCompactHashCheckEnabled bool `json:"experimental-compact-hash-check-enabled"`
+	// ExperimentalCorruptAutoRepair leader 自动用快照修复有 CORRUPT 警报的成员, 需要所有成员都启用
+	ExperimentalCorruptAutoRepair bool `json:"experimental-corrupt-auto-repair"`
+	// ExperimentalSlowDiskAlarmFsyncP99 最近 WAL fsync 耗时的 p99 超过该值时触发 SLOWDISK 警报, 0 表示不检查
+	ExperimentalSlowDiskAlarmFsyncP99 time.Duration `json:"experimental-slow-disk-alarm-fsync-p99"`
+	// ExperimentalClockSkewAlarmThreshold 与任一成员的时钟差超过该值时触发 CLOCKSKEW 警报, 0 表示不检查
+	ExperimentalClockSkewAlarmThreshold time.Duration `json:"experimental-clock-skew-alarm-threshold"`
+	// ExperimentalApplyLagAlarmEntries 已提交未应用的日志数超过该值时触发 APPLYLAG 警报, 0 表示不检查
+	ExperimentalApplyLagAlarmEntries uint64 `json:"experimental-apply-lag-alarm-entries"`
+	// ExperimentalAdmissionMemoryBytes 堆内存达到该值时开始按优先级拒绝新的提议, 0 表示不按内存限制
+	ExperimentalAdmissionMemoryBytes uint64 `json:"experimental-admission-memory-bytes"`
 	// ExperimentalEnableV2V3 configures URLs that expose deprecated V2 API working on V3 store.
 	// Deprecated in v3.5.
 	// TODO: Delete in v3.6 (https://github.com/etcd-io/etcd/issues/12913)
@@ -277,6 +301,24 @@ type Config struct {
 	ExperimentalEnableLeaseCheckpointPersist bool          `json:"experimental-enable-lease-checkpoint-persist"`
 	ExperimentalCompactionBatchLimit         int           `json:"experimental-compaction-batch-limit"`
 	ExperimentalWatchProgressNotifyInterval  time.Duration `json:"experimental-watch-progress-notify-interval"`
+	// ExperimentalWatchSessionGracePeriod 可恢复的watch在连接断开后保留的时间, 0表示不支持恢复
+	ExperimentalWatchSessionGracePeriod time.Duration `json:"experimental-watch-session-grace-period"`
+	// ExperimentalWatchSessionBufferSize 每个可恢复的watch最多缓存的事件数
+	ExperimentalWatchSessionBufferSize int `json:"experimental-watch-session-buffer-size"`
+	// ExperimentalWatchSessionMaxParked 等待恢复的会话总数上限, 0表示不限制
+	ExperimentalWatchSessionMaxParked int `json:"experimental-watch-session-max-parked"`
+	// ExperimentalWatchSessionMaxParkedPerUser 每个用户等待恢复的会话数上限, 0表示不限制
+	ExperimentalWatchSessionMaxParkedPerUser int `json:"experimental-watch-session-max-parked-per-user"`
+	// ExperimentalWatchCacheRevisions 服务端缓存最近多少个修订版本的watch事件, 供落后的watcher同步, 0表示不缓存
+	ExperimentalWatchCacheRevisions int64 `json:"experimental-watch-cache-revisions"`
+	// ExperimentalWatchStreamMaxPending 每个watch流最多积压的事件数, 0表示不限制
+	ExperimentalWatchStreamMaxPending int `json:"experimental-watch-stream-max-pending"`
+	// ExperimentalSlowWatcherPolicy watch流积压超限后的处理方式: block 或 cancel
+	ExperimentalSlowWatcherPolicy string `json:"experimental-slow-watcher-policy"`
+	// ExperimentalLinearizableReadMode 线性一致读的模式: safe 或 lease, 集群所有成员应相同
+	ExperimentalLinearizableReadMode string `json:"experimental-linearizable-read-mode"`
+	// ExperimentalLeaseReadClockDrift lease模式下leader租约比选举超时短的时长, 需覆盖节点间的时钟漂移和消息延迟
+	ExperimentalLeaseReadClockDrift time.Duration `json:"experimental-lease-read-clock-drift"`
 	// ExperimentalWarningApplyDuration 是时间长度.如果应用请求的时间超过这个值.就会产生一个警告.
 	ExperimentalWarningApplyDuration time.Duration `json:"experimental-warning-apply-duration"`
 	// ExperimentalBootstrapDefragThresholdMegabytes is the minimum number of megabytes needed to be freed for etcd etcd to
@@ -440,6 +482,13 @@ func NewConfig() *Config {
 		ExperimentalDowngradeCheckTime:           DefaultDowngradeCheckTime, // 两次降级状态检查之间的时间间隔.
 		ExperimentalMemoryMlock:                  false,                     // 内存页锁定
 		ExperimentalTxnModeWriteWithSharedBuffer: true,                      // 启用写事务在其只读检查操作中使用共享缓冲区.
+		ExperimentalWatchSessionGracePeriod:      DefaultWatchSessionGracePeriod,
+		ExperimentalWatchSessionBufferSize:       DefaultWatchSessionBufferSize,
+		ExperimentalWatchSessionMaxParked:        DefaultWatchSessionMaxParked,
+		ExperimentalWatchSessionMaxParkedPerUser: DefaultWatchSessionMaxParkedPerUser,
+		ExperimentalSlowWatcherPolicy:            string(mvcc.SlowWatcherBlock),
+		ExperimentalLinearizableReadMode:         string(config.LinearizableReadDefault),
+		ExperimentalLeaseReadClockDrift:          DefaultLeaseReadClockDrift,
 
 		V2Deprecation: config.V2_DEPR_DEFAULT, // not-yet
 	}
@@ -626,12 +675,31 @@ func (cfg *Config) Validate() error {
 		return ErrUnsetAdvertiseClientURLsFlag
 	}
 
+	if _, err := auth.ParseCertMappingRules(cfg.ClientCertAuthRules); err != nil {
+		return err
+	}
+
 	switch cfg.AutoCompactionMode {
 	case "":
 	case CompactorModeRevision, CompactorModePeriodic:
 	default:
 		return fmt.Errorf("未知的 auto-compaction-mode %q", cfg.AutoCompactionMode)
 	}
+
+	switch mvcc.SlowWatcherPolicy(cfg.ExperimentalSlowWatcherPolicy) {
+	case "", mvcc.SlowWatcherBlock, mvcc.SlowWatcherCancel:
+	default:
+		return fmt.Errorf("未知的 experimental-slow-watcher-policy %q", cfg.ExperimentalSlowWatcherPolicy)
+	}
+	switch config.LinearizableReadMode(cfg.ExperimentalLinearizableReadMode) {
+	case "", config.LinearizableReadSafe:
+	case config.LinearizableReadLease:
+		if cfg.ExperimentalLeaseReadClockDrift < 0 || cfg.ExperimentalLeaseReadClockDrift >= time.Duration(cfg.ElectionMs)*time.Millisecond {
+			return fmt.Errorf("experimental-lease-read-clock-drift %v 必须小于选举超时 %dms", cfg.ExperimentalLeaseReadClockDrift, cfg.ElectionMs)
+		}
+	default:
+		return fmt.Errorf("未知的 experimental-linearizable-read-mode %q", cfg.ExperimentalLinearizableReadMode)
+	}
 	// false,false 不会走
 	if !cfg.ExperimentalEnableLeaseCheckpointPersist && cfg.ExperimentalEnableLeaseCheckpoint {
 		cfg.logger.Warn("检测到启用了Checkpoint而没有持久性.考虑启用experimental-enable-le-checkpoint-persist")
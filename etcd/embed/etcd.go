@@ -195,10 +195,17 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		AuthToken:                                cfg.AuthToken,  // 认证格式  simple、jwt
 		BcryptCost:                               cfg.BcryptCost, // 为散列身份验证密码指定bcrypt算法的成本/强度
 		TokenTTL:                                 cfg.AuthTokenTTL,
+		ClientCertAuthRules:                      cfg.ClientCertAuthRules,
 		CORS:                                     cfg.CORS,
 		HostWhitelist:                            cfg.HostWhitelist,
 		InitialCorruptCheck:                      cfg.ExperimentalInitialCorruptCheck, // 数据毁坏检测功能
 		CorruptCheckTime:                         cfg.ExperimentalCorruptCheckTime,
+		CompactHashCheckEnabled:                  cfg.ExperimentalCompactHashCheckEnabled,
+		CorruptAutoRepair:                        cfg.ExperimentalCorruptAutoRepair,
+		SlowDiskAlarmFsyncP99:                    cfg.ExperimentalSlowDiskAlarmFsyncP99,
+		ClockSkewAlarmThreshold:                  cfg.ExperimentalClockSkewAlarmThreshold,
+		ApplyLagAlarmEntries:                     cfg.ExperimentalApplyLagAlarmEntries,
+		AdmissionMemoryBytes:                     cfg.ExperimentalAdmissionMemoryBytes,
 		PreVote:                                  cfg.PreVote, // PreVote 是否启用PreVote
 		Logger:                                   cfg.logger,
 		ForceNewCluster:                          cfg.ForceNewCluster,
@@ -209,6 +216,15 @@ func StartEtcd(inCfg *Config) (e *Etcd, err error) {
 		LeaseCheckpointPersist:                   cfg.ExperimentalEnableLeaseCheckpointPersist,
 		CompactionBatchLimit:                     cfg.ExperimentalCompactionBatchLimit,
 		WatchProgressNotifyInterval:              cfg.ExperimentalWatchProgressNotifyInterval,
+		WatchSessionGracePeriod:                  cfg.ExperimentalWatchSessionGracePeriod,
+		WatchSessionBufferSize:                   cfg.ExperimentalWatchSessionBufferSize,
+		WatchSessionMaxParked:                    cfg.ExperimentalWatchSessionMaxParked,
+		WatchSessionMaxParkedPerUser:             cfg.ExperimentalWatchSessionMaxParkedPerUser,
+		WatchCacheRevisions:                      cfg.ExperimentalWatchCacheRevisions,
+		WatchStreamMaxPending:                    cfg.ExperimentalWatchStreamMaxPending,
+		SlowWatcherPolicy:                        cfg.ExperimentalSlowWatcherPolicy,
+		LinearizableReadMode:                     config.LinearizableReadMode(cfg.ExperimentalLinearizableReadMode),
+		LeaseReadClockDrift:                      cfg.ExperimentalLeaseReadClockDrift,
 		DowngradeCheckTime:                       cfg.ExperimentalDowngradeCheckTime,   // 两次降级状态检查之间的时间间隔.
 		WarningApplyDuration:                     cfg.ExperimentalWarningApplyDuration, // 是时间长度.如果应用请求的时间超过这个值.就会产生一个警告.
 		ExperimentalMemoryMlock:                  cfg.ExperimentalMemoryMlock,
@@ -329,6 +345,12 @@ func print(lg *zap.Logger, ec Config, sc config.ServerConfig, memberInitialized
 		zap.Bool("pre-vote", sc.PreVote),
 		zap.Bool("initial-corrupt-check", sc.InitialCorruptCheck),
 		zap.String("corrupt-check-time-interval", sc.CorruptCheckTime.String()),
+		zap.Bool("compact-hash-check-enabled", sc.CompactHashCheckEnabled),
+		zap.Bool("corrupt-auto-repair", sc.CorruptAutoRepair),
+		zap.Duration("slow-disk-alarm-fsync-p99", sc.SlowDiskAlarmFsyncP99),
+		zap.Duration("clock-skew-alarm-threshold", sc.ClockSkewAlarmThreshold),
+		zap.Uint64("apply-lag-alarm-entries", sc.ApplyLagAlarmEntries),
+		zap.Uint64("admission-memory-bytes", sc.AdmissionMemoryBytes),
 		zap.String("auto-compaction-mode", sc.AutoCompactionMode),
 		zap.Duration("auto-compaction-retention", sc.AutoCompactionRetention),
 		zap.String("auto-compaction-interval", sc.AutoCompactionRetention.String()),
@@ -256,6 +256,7 @@ func newConfig() *config {
 	fs.StringVar(&cfg.ec.AuthToken, "auth-token", cfg.ec.AuthToken, "指定验证令牌的具体选项. ('simple' or 'jwt')")
 	fs.UintVar(&cfg.ec.BcryptCost, "bcrypt-cost", cfg.ec.BcryptCost, "为散列身份验证密码指定bcrypt算法的成本/强度.有效值介于4和31之间.")
 	fs.UintVar(&cfg.ec.AuthTokenTTL, "auth-token-ttl", cfg.ec.AuthTokenTTL, "token过期时间")
+	fs.Var(flags.NewStringsValue(""), "client-cert-auth-rules", "逗号分隔的客户端证书映射规则, 格式 <cn|uri|dns|ou>:<pattern>=<user|role>:<name>, pattern以*结尾表示前缀匹配")
 
 	// gateway
 	fs.BoolVar(&cfg.ec.EnableGRPCGateway, "enable-grpc-gateway", cfg.ec.EnableGRPCGateway, "Enable GRPC gateway.")
@@ -263,12 +264,27 @@ func newConfig() *config {
 	// experimental
 	fs.BoolVar(&cfg.ec.ExperimentalInitialCorruptCheck, "experimental-initial-corrupt-check", cfg.ec.ExperimentalInitialCorruptCheck, "Enable to check data corruption before serving any client/peer traffic.")
 	fs.DurationVar(&cfg.ec.ExperimentalCorruptCheckTime, "experimental-corrupt-check-time", cfg.ec.ExperimentalCorruptCheckTime, "Duration of time between cluster corruption check passes.")
+	fs.DurationVar(&cfg.ec.ExperimentalSlowDiskAlarmFsyncP99, "experimental-slow-disk-alarm-fsync-p99", cfg.ec.ExperimentalSlowDiskAlarmFsyncP99, "Raise a SLOWDISK alarm when the p99 of recent WAL fsync durations exceeds this value. 0 disables the check.")
+	fs.DurationVar(&cfg.ec.ExperimentalClockSkewAlarmThreshold, "experimental-clock-skew-alarm-threshold", cfg.ec.ExperimentalClockSkewAlarmThreshold, "Raise a CLOCKSKEW alarm when the probed clock difference to any peer exceeds this value. 0 disables the check.")
+	fs.Uint64Var(&cfg.ec.ExperimentalApplyLagAlarmEntries, "experimental-apply-lag-alarm-entries", cfg.ec.ExperimentalApplyLagAlarmEntries, "Raise an APPLYLAG alarm when the applied index lags the committed index by more than this many entries. 0 disables the check.")
+	fs.Uint64Var(&cfg.ec.ExperimentalAdmissionMemoryBytes, "experimental-admission-memory-bytes", cfg.ec.ExperimentalAdmissionMemoryBytes, "Start shedding new proposals by priority class when the Go heap in use approaches this many bytes. 0 disables the memory signal.")
+	fs.BoolVar(&cfg.ec.ExperimentalCorruptAutoRepair, "experimental-corrupt-auto-repair", cfg.ec.ExperimentalCorruptAutoRepair, "Enable leader to repair a member with a CORRUPT alarm by replacing its backend with a fresh snapshot, then clear the alarm. Must be enabled on all members.")
+	fs.BoolVar(&cfg.ec.ExperimentalCompactHashCheckEnabled, "experimental-compact-hash-check-enabled", cfg.ec.ExperimentalCompactHashCheckEnabled, "Enable leader to check data consistency and pinpoint diverged key ranges after each compaction.")
 
 	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpoint, "experimental-enable-lease-checkpoint", true, "允许leader定期向其他成员发送检查点,以防止leader变化时剩余TTL重置")
 	// TODO: delete in v3.7
 	fs.BoolVar(&cfg.ec.ExperimentalEnableLeaseCheckpointPersist, "experimental-enable-lease-checkpoint-persist", true, "启用持续的剩余TTL,以防止长期租赁的无限期自动续约.在v3.6中始终启用.应使用该功能以确保从启用该功能的v3.5集群顺利升级.需要启用experimental-enable-lease-checkpoint.")
 	fs.IntVar(&cfg.ec.ExperimentalCompactionBatchLimit, "experimental-compaction-batch-limit", cfg.ec.ExperimentalCompactionBatchLimit, "Sets the maximum revisions deleted in each compaction batch.")
 	fs.DurationVar(&cfg.ec.ExperimentalWatchProgressNotifyInterval, "experimental-watch-progress-notify-interval", cfg.ec.ExperimentalWatchProgressNotifyInterval, "Duration of periodic watch progress notifications.")
+	fs.DurationVar(&cfg.ec.ExperimentalWatchSessionGracePeriod, "experimental-watch-session-grace-period", cfg.ec.ExperimentalWatchSessionGracePeriod, "可恢复的watch在连接断开后保留的时间, 0表示不支持恢复.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchSessionBufferSize, "experimental-watch-session-buffer-size", cfg.ec.ExperimentalWatchSessionBufferSize, "每个可恢复的watch最多缓存的事件数.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchSessionMaxParked, "experimental-watch-session-max-parked", cfg.ec.ExperimentalWatchSessionMaxParked, "等待恢复的watch会话总数上限, 超过后断开的会话直接关闭, 0表示不限制.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchSessionMaxParkedPerUser, "experimental-watch-session-max-parked-per-user", cfg.ec.ExperimentalWatchSessionMaxParkedPerUser, "每个用户等待恢复的watch会话数上限, 0表示不限制.")
+	fs.IntVar(&cfg.ec.ExperimentalWatchStreamMaxPending, "experimental-watch-stream-max-pending", cfg.ec.ExperimentalWatchStreamMaxPending, "每个watch流最多积压的事件数, 超过后按 experimental-slow-watcher-policy 处理, 0表示不限制.")
+	fs.StringVar(&cfg.ec.ExperimentalSlowWatcherPolicy, "experimental-slow-watcher-policy", cfg.ec.ExperimentalSlowWatcherPolicy, "watch流积压超限后的处理方式: 'block' 暂停发送并稍后补发, 'cancel' 丢弃积压的事件并取消watch, 客户端从返回的修订版本重新watch.")
+	fs.StringVar(&cfg.ec.ExperimentalLinearizableReadMode, "experimental-linearizable-read-mode", cfg.ec.ExperimentalLinearizableReadMode, "线性一致读的模式: 'safe' 每次读都由leader通过心跳确认身份, 'lease' leader在租约内直接读取, 省去一次往返, 依赖时钟漂移有上限. 集群所有成员应相同.")
+	fs.DurationVar(&cfg.ec.ExperimentalLeaseReadClockDrift, "experimental-lease-read-clock-drift", cfg.ec.ExperimentalLeaseReadClockDrift, "lease模式下leader租约比选举超时短的时长, 需覆盖节点间的时钟漂移和消息延迟.")
+	fs.Int64Var(&cfg.ec.ExperimentalWatchCacheRevisions, "experimental-watch-cache-revisions", cfg.ec.ExperimentalWatchCacheRevisions, "服务端缓存最近多少个修订版本的watch事件, 落后的watcher优先从缓存同步, 0表示不缓存.")
 	fs.DurationVar(&cfg.ec.ExperimentalDowngradeCheckTime, "experimental-downgrade-check-time", cfg.ec.ExperimentalDowngradeCheckTime, "两次降级状态检查之间的时间间隔.")
 	fs.DurationVar(&cfg.ec.ExperimentalWarningApplyDuration, "experimental-warning-apply-duration", cfg.ec.ExperimentalWarningApplyDuration, "时间长度.如果应用请求的时间超过这个值.就会产生一个警告.")
 	fs.BoolVar(&cfg.ec.ExperimentalMemoryMlock, "experimental-memory-mlock", cfg.ec.ExperimentalMemoryMlock, "启用强制执行etcd页面(特别是bbolt)留在RAM中.")
@@ -363,6 +379,7 @@ func (cfg *config) configFromCmdLine() error {
 	cfg.ec.HostWhitelist = flags.UniqueStringsMapFromFlag(cfg.cf.flagSet, "host-whitelist")
 
 	cfg.ec.CipherSuites = flags.StringsFromFlag(cfg.cf.flagSet, "cipher-suites")
+	cfg.ec.ClientCertAuthRules = flags.StringsFromFlag(cfg.cf.flagSet, "client-cert-auth-rules")
 
 	cfg.ec.LogOutputs = flags.UniqueStringsFromFlag(cfg.cf.flagSet, "log-outputs")
 
@@ -176,6 +176,8 @@ Auth:
     为散列身份验证密码指定bcrypt算法的成本/强度.有效值介于4和31之间.
   --auth-token-ttl 300
     token过期时间
+  --client-cert-auth-rules ''
+    逗号分隔的客户端证书映射规则, 格式 <cn|uri|dns|ou>:<pattern>=<user|role>:<name>, pattern以*结尾表示前缀匹配.
 
 Profiling and Monitoring:
   --enable-pprof 'false'
@@ -226,6 +228,18 @@ Experimental feature:
     Enable to check data corruption before serving any client/peer traffic.
   --experimental-corrupt-check-time '0s'
     Duration of time between cluster corruption check passes.
+  --experimental-compact-hash-check-enabled 'false'
+    Enable leader to check data consistency and pinpoint diverged key ranges after each compaction.
+  --experimental-slow-disk-alarm-fsync-p99 '0s'
+    Raise a SLOWDISK alarm when the p99 of recent WAL fsync durations exceeds this value. 0 disables the check.
+  --experimental-clock-skew-alarm-threshold '0s'
+    Raise a CLOCKSKEW alarm when the probed clock difference to any peer exceeds this value. 0 disables the check.
+  --experimental-apply-lag-alarm-entries '0'
+    Raise an APPLYLAG alarm when the applied index lags the committed index by more than this many entries. 0 disables the check.
+  --experimental-admission-memory-bytes '0'
+    Start shedding new proposals by priority class when the Go heap in use approaches this many bytes. 0 disables the memory signal.
+  --experimental-corrupt-auto-repair 'false'
+    Enable leader to repair a member with a CORRUPT alarm by replacing its backend with a fresh snapshot, then clear the alarm. Must be enabled on all members.
   --experimental-enable-v2v3 ''
     Serve v2 requests through the v3 backend under a given prefix. Deprecated and to be decommissioned in v3.6.
   --experimental-enable-lease-checkpoint 'false'
@@ -236,6 +250,24 @@ Experimental feature:
     跳过server 客户端证书中SAN字段的验证.默认false
   --experimental-watch-progress-notify-interval '10m'
     Duration of periodic watch progress notifications.
+  --experimental-watch-session-grace-period '30s'
+    可恢复的watch在连接断开后保留的时间, 0表示不支持恢复.
+  --experimental-watch-session-buffer-size 1000
+    每个可恢复的watch最多缓存的事件数.
+  --experimental-watch-session-max-parked 10000
+    等待恢复的watch会话总数上限, 超过后断开的会话直接关闭, 0表示不限制.
+  --experimental-watch-session-max-parked-per-user 100
+    每个用户等待恢复的watch会话数上限, 0表示不限制.
+  --experimental-watch-cache-revisions 0
+    服务端缓存最近多少个修订版本的watch事件, 落后的watcher优先从缓存同步, 0表示不缓存.
+  --experimental-watch-stream-max-pending 0
+    每个watch流最多积压的事件数, 超过后按 experimental-slow-watcher-policy 处理, 0表示不限制.
+  --experimental-slow-watcher-policy 'block'
+    watch流积压超限后的处理方式: 'block' 暂停发送并稍后补发, 'cancel' 丢弃积压的事件并取消watch, 客户端从返回的修订版本重新watch.
+  --experimental-linearizable-read-mode 'safe'
+    线性一致读的模式: 'safe' 每次读都由leader通过心跳确认身份, 'lease' leader在租约内直接读取, 省去一次往返, 依赖时钟漂移有上限. 集群所有成员应相同.
+  --experimental-lease-read-clock-drift '200ms'
+    lease模式下leader租约比选举超时短的时长, 需覆盖节点间的时钟漂移和消息延迟.
   --experimental-warning-apply-duration '100ms'
     时间长度.如果应用请求的时间超过这个值.就会产生一个警告.
   --experimental-txn-mode-write-with-shared-buffer 'true'
@@ -0,0 +1,168 @@
+package etcdserver
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// 自适应准入控制: 综合积压的日志、应用耗时、后端提交耗时与内存占用计算负载压力,
+// 压力升高时按优先级由低到高逐步拒绝新的提议, 并在 gRPC trailer 中给出建议的重试等待时间.
+//
+// 各信号按参考值归一化, 压力取其中的最大值:
+//
+//	backlog 已提交未应用的日志数 / maxGapBetweenApplyAndCommitIndex
+//	apply   按每条日志的平均应用耗时估算的积压清空时间 / admissionDrainTarget
+//	commit  后端事务的平均提交耗时 / admissionCommitTarget
+//	memory  堆内存占用 / AdmissionMemoryBytes
+const (
+	admissionSampleInterval = 500 * time.Millisecond
+	admissionDrainTarget    = time.Second
+	admissionCommitTarget   = 500 * time.Millisecond
+	// admissionApplyEWMAWeight 每批新样本在应用耗时滑动平均中的权重
+	admissionApplyEWMAWeight = 0.2
+	// admissionShedRamp 压力超过某类别的起点后, 该类别被拒绝的比例在这段区间内从0升到1
+	admissionShedRamp = 0.25
+	maxRetryAfter     = 5 * time.Second
+)
+
+// admissionShedStart 各优先级类别开始被拒绝时的压力; normal 在积压达到 maxGapBetweenApplyAndCommitIndex 时全部拒绝,
+// system 在积压达到其两倍时全部拒绝
+var admissionShedStart = [numPriorityClasses]float64{2.0 - admissionShedRamp, 1.0, 0.75, 0.5}
+
+// admissionHardLimit 压力达到该值时拒绝所有类别的提议, 已提交未应用的日志不会无限增长
+const admissionHardLimit = 2.0
+
+const (
+	signalBacklog = iota
+	signalApply
+	signalCommit
+	signalMemory
+	numAdmissionSignals
+)
+
+var admissionSignalNames = [numAdmissionSignals]string{"backlog", "apply", "commit", "memory"}
+
+type admissionController struct {
+	mu            sync.Mutex
+	applyPerEntry time.Duration // 每条日志应用耗时的滑动平均
+	commitSignal  float64
+	memorySignal  float64
+
+	lastCommits    int64
+	lastCommitTook time.Duration
+}
+
+// recordApply 在 apply 协程中记录一批日志的应用耗时
+func (ac *admissionController) recordApply(entries int, took time.Duration) {
+	per := took / time.Duration(entries)
+	ac.mu.Lock()
+	if ac.applyPerEntry == 0 {
+		ac.applyPerEntry = per
+	} else {
+		ac.applyPerEntry += time.Duration(admissionApplyEWMAWeight * float64(per-ac.applyPerEntry))
+	}
+	ac.mu.Unlock()
+}
+
+// currentPressure 返回当前的负载压力、各信号的值以及积压日志的预计清空时间
+func (s *EtcdServer) currentPressure() (float64, [numAdmissionSignals]float64, time.Duration) {
+	var gap uint64
+	if ci, ai := s.getCommittedIndex(), s.getAppliedIndex(); ci > ai {
+		gap = ci - ai
+	}
+	var signals [numAdmissionSignals]float64
+	ac := s.admission
+	ac.mu.Lock()
+	drain := time.Duration(gap) * ac.applyPerEntry
+	signals[signalCommit] = ac.commitSignal
+	signals[signalMemory] = ac.memorySignal
+	ac.mu.Unlock()
+	signals[signalBacklog] = float64(gap) / maxGapBetweenApplyAndCommitIndex
+	signals[signalApply] = float64(drain) / float64(admissionDrainTarget)
+
+	pressure := 0.0
+	for _, v := range signals {
+		pressure = math.Max(pressure, v)
+	}
+	return pressure, signals, drain
+}
+
+// admit 按负载压力与请求的优先级决定是否接受新的提议
+func (s *EtcdServer) admit(ctx context.Context, p priorityClass) error {
+	pressure, _, drain := s.currentPressure()
+	start := admissionShedStart[p]
+	if pressure < admissionHardLimit {
+		if pressure < start {
+			return nil
+		}
+		if pressure < start+admissionShedRamp && rand.Float64() >= (pressure-start)/admissionShedRamp {
+			return nil
+		}
+	}
+	admissionRejectedTotal.WithLabelValues(p.String()).Inc()
+	setRetryAfter(ctx, retryAfter(drain))
+	return ErrTooManyRequests
+}
+
+// retryAfter 建议的重试等待时间: 积压预计清空的时间, 至少到下一次采样
+func retryAfter(drain time.Duration) time.Duration {
+	if drain < admissionSampleInterval {
+		return admissionSampleInterval
+	}
+	if drain > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return drain
+}
+
+// setRetryAfter 在 gRPC trailer 中返回建议的重试等待时间; 不是 gRPC 请求时忽略
+func setRetryAfter(ctx context.Context, d time.Duration) {
+	grpc.SetTrailer(ctx, metadata.Pairs(rpctypes.MetadataRetryAfterKey, strconv.FormatInt(int64(d/time.Millisecond), 10)))
+}
+
+// monitorAdmission 定期采样后端提交耗时与内存占用, 并更新准入控制的指标
+func (s *EtcdServer) monitorAdmission() {
+	ac := s.admission
+	var ms runtime.MemStats
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(admissionSampleInterval):
+		}
+
+		memory := 0.0
+		if limit := s.Cfg.AdmissionMemoryBytes; limit > 0 {
+			runtime.ReadMemStats(&ms)
+			memory = float64(ms.HeapInuse) / float64(limit)
+		}
+		commits, took := s.Backend().CommitStats()
+
+		ac.mu.Lock()
+		// 后端被替换后计数从0开始, 这一次不计算
+		if n := commits - ac.lastCommits; n > 0 && took >= ac.lastCommitTook {
+			ac.commitSignal = float64((took-ac.lastCommitTook)/time.Duration(n)) / float64(admissionCommitTarget)
+		} else {
+			ac.commitSignal = 0
+		}
+		ac.lastCommits, ac.lastCommitTook = commits, took
+		ac.memorySignal = memory
+		ac.mu.Unlock()
+
+		pressure, signals, drain := s.currentPressure()
+		admissionPressure.Set(pressure)
+		for i, v := range signals {
+			admissionSignal.WithLabelValues(admissionSignalNames[i]).Set(v)
+		}
+		admissionRetryAfterSec.Set(retryAfter(drain).Seconds())
+	}
+}
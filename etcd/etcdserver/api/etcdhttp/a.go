@@ -153,6 +153,12 @@ func checkHealth(lg *zap.Logger, srv etcdserver.ServerV2, excludedAlarms AlarmSe
 				h.Reason = "ALARM NOSPACE"
 			case etcdserverpb.AlarmType_CORRUPT:
 				h.Reason = "ALARM CORRUPT"
+			case etcdserverpb.AlarmType_SLOWDISK:
+				h.Reason = "ALARM SLOWDISK"
+			case etcdserverpb.AlarmType_CLOCKSKEW:
+				h.Reason = "ALARM CLOCKSKEW"
+			case etcdserverpb.AlarmType_APPLYLAG:
+				h.Reason = "ALARM APPLYLAG"
 			default:
 				h.Reason = "ALARM UNKNOWN"
 			}
@@ -38,6 +38,26 @@ var (
 	statusErrorInterval      = 5 * time.Second
 )
 
+// addPeerToProber 探测远端节点的 /raft/probing, 记录往返时延与时钟差
+func addPeerToProber(lg *zap.Logger, p probing.Prober, id string, us []string, roundTripperName string) {
+	hus := make([]string, len(us))
+	for i := range us {
+		hus[i] = us[i] + ProbingPrefix
+	}
+
+	p.AddHTTP(id, proberInterval, hus)
+
+	s, err := p.Status(id)
+	if err != nil {
+		if lg != nil {
+			lg.Warn("failed to add peer into prober", zap.String("remote-peer-id", id), zap.Error(err))
+		}
+		return
+	}
+
+	go monitorProbingStatus(lg, s, id, roundTripperName, nil)
+}
+
 func monitorProbingStatus(lg *zap.Logger, s probing.Status, id string, roundTripperName string, rttSecProm *prometheus.HistogramVec) {
 	// set the first interval short to log error early.
 	interval := statusErrorInterval
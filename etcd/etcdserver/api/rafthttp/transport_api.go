@@ -66,6 +66,8 @@ type Transporter interface {
 	ActiveSince(id types.ID) time.Time // 返回与给定id的对等体的连接开始活动的时间
 	// ActivePeers returns the number of active peers.
 	ActivePeers() int
+	// ClockDiffs 返回探测到的与各远端节点的时钟差
+	ClockDiffs() map[types.ID]time.Duration
 	// Stop closes the connections and stops the transporter.
 	Stop()
 }
@@ -288,6 +290,8 @@ func (t *Transport) AddPeer(id types.ID, us []string) {
 	}
 	fs := t.LeaderStats.Follower(id.String())
 	t.peers[id] = startPeer(t, urls, id, fs)
+	addPeerToProber(t.Logger, t.pipelineProber, id.String(), us, RoundTripperNameSnapshot)
+	addPeerToProber(t.Logger, t.streamProber, id.String(), us, RoundTripperNameRaftMessage)
 
 	if t.Logger != nil {
 		t.Logger.Info(
@@ -353,6 +357,8 @@ func (t *Transport) UpdatePeer(id types.ID, us []string) {
 
 	t.pipelineProber.Remove(id.String())
 	t.streamProber.Remove(id.String())
+	addPeerToProber(t.Logger, t.pipelineProber, id.String(), us, RoundTripperNameSnapshot)
+	addPeerToProber(t.Logger, t.streamProber, id.String(), us, RoundTripperNameRaftMessage)
 
 	if t.Logger != nil {
 		t.Logger.Info(
@@ -419,3 +425,15 @@ func (t *Transport) ActivePeers() (cnt int) {
 	}
 	return cnt
 }
+
+func (t *Transport) ClockDiffs() map[types.ID]time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	diffs := make(map[types.ID]time.Duration, len(t.peers))
+	for id := range t.peers {
+		if s, err := t.streamProber.Status(id.String()); err == nil && s.Total() > 0 {
+			diffs[id] = s.ClockDiff()
+		}
+	}
+	return diffs
+}
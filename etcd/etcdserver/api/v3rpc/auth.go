@@ -164,3 +164,51 @@ func (as *AuthServer) UserChangePassword(ctx context.Context, r *pb.AuthUserChan
 	}
 	return resp, nil
 }
+
+func (as *AuthServer) UserTokenCreate(ctx context.Context, r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	resp, err := as.authenticator.UserTokenCreate(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) UserTokenList(ctx context.Context, r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	resp, err := as.authenticator.UserTokenList(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) UserTokenRevoke(ctx context.Context, r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	resp, err := as.authenticator.UserTokenRevoke(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) AuthCheck(ctx context.Context, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	resp, err := as.authenticator.AuthCheck(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) UserSetRateLimit(ctx context.Context, r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error) {
+	resp, err := as.authenticator.UserSetRateLimit(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
+
+func (as *AuthServer) RoleSetRateLimit(ctx context.Context, r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error) {
+	resp, err := as.authenticator.RoleSetRateLimit(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	return resp, nil
+}
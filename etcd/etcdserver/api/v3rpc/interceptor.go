@@ -22,6 +22,7 @@ import (
 	"github.com/ls-2018/etcd_cn/raft"
 
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
+	"github.com/ls-2018/etcd_cn/etcd/auth"
 	"github.com/ls-2018/etcd_cn/etcd/etcdserver"
 	"github.com/ls-2018/etcd_cn/etcd/etcdserver/api"
 	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
@@ -63,6 +64,15 @@ func newUnaryInterceptor(s *etcdserver.EtcdServer) grpc.UnaryServerInterceptor {
 			}
 		}
 
+		// 解析出的认证信息缓存在ctx上, 后续处理请求时不再重复解析令牌
+		authInfo, err := s.AuthInfoFromCtx(ctx)
+		if err == nil {
+			ctx = etcdserver.WithAuthInfo(ctx, authInfo)
+			if err = s.AuthStore().AllowRequest(authInfo, req); err != nil {
+				return nil, togRPCError(err)
+			}
+		}
+
 		return handler(ctx, req)
 	}
 }
@@ -104,10 +114,33 @@ func newStreamInterceptor(s *etcdserver.EtcdServer) grpc.StreamServerInterceptor
 			}
 		}
 
+		// 认证失败的流不在这里处理, 交给后续逻辑返回认证错误; 认证信息在流建立时解析一次, 之后的消息复用
+		if authInfo, err := s.AuthInfoFromCtx(ss.Context()); err == nil && authInfo != nil {
+			if err = s.AuthStore().AllowRequest(authInfo, nil); err != nil {
+				return togRPCError(err)
+			}
+			ss = rateLimitedServerStream{ServerStream: ss, as: s.AuthStore(), authInfo: authInfo}
+		}
+
 		return handler(srv, ss)
 	}
 }
 
+// rateLimitedServerStream 流上收到的每个消息都计入限速; 超过限制时延迟交付消息而不是返回错误,
+// 否则会结束整个流(LeaseKeepAlive上的租约随之过期, Watch上的所有watch被取消)
+type rateLimitedServerStream struct {
+	grpc.ServerStream
+	as       auth.AuthStore
+	authInfo *auth.AuthInfo
+}
+
+func (rs rateLimitedServerStream) RecvMsg(m interface{}) error {
+	if err := rs.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return rs.as.WaitRequest(rs.Context(), rs.authInfo, m)
+}
+
 // cancellableContext wraps a context with new cancellable context that allows a
 // specific cancellation error to be preserved and later retrieved using the
 // Context.Err() function. This is so downstream context users can disambiguate
@@ -42,6 +42,10 @@ func (s *kvServer) Put(ctx context.Context, r *pb.PutRequest) (*pb.PutResponse,
 	if err := checkPutRequest(r); err != nil {
 		return nil, err
 	}
+	if r.ValueRef != nil {
+		// 只有事务中才有可引用的操作
+		return nil, rpctypes.ErrGRPCInvalidValueRef
+	}
 
 	resp, err := s.kv.Put(ctx, r)
 	if err != nil {
@@ -113,6 +117,16 @@ func checkPutRequest(r *pb.PutRequest) error {
 	if r.IgnoreLease && r.Lease != 0 {
 		return rpctypes.ErrGRPCLeaseProvided
 	}
+	if r.ValueRef != nil && (r.IgnoreValue || len(r.Value) != 0) {
+		return rpctypes.ErrGRPCValueProvided
+	}
+	if r.Increment != nil && (r.IgnoreValue || r.ValueRef != nil || len(r.Value) != 0) {
+		return rpctypes.ErrGRPCValueProvided
+	}
+	// if_absent 与要求key存在的选项互斥
+	if r.IfAbsent && (r.IgnoreValue || r.IgnoreLease || r.IfModRevision != 0 || len(r.IfValueHash) != 0) {
+		return rpctypes.ErrGRPCInvalidPutCondition
+	}
 	return nil
 }
 
@@ -150,7 +164,27 @@ func checkTxnRequest(r *pb.TxnRequest, maxTxnOps int) error {
 			return err
 		}
 	}
+	if err := checkValueRefs(r.Success); err != nil {
+		return err
+	}
+	return checkValueRefs(r.Failure)
+}
 
+// checkValueRefs 检查同一分支中put对之前range操作的引用
+func checkValueRefs(reqs []*pb.RequestOp) error {
+	for i, u := range reqs {
+		if u.RequestOp_RequestPut == nil || u.RequestOp_RequestPut.RequestPut.ValueRef == nil {
+			continue
+		}
+		idx := u.RequestOp_RequestPut.RequestPut.ValueRef.OpIndex
+		if idx < 0 || idx >= int64(i) || reqs[idx].RequestOp_RequestRange == nil {
+			return rpctypes.ErrGRPCInvalidValueRef
+		}
+		rr := reqs[idx].RequestOp_RequestRange.RequestRange
+		if rr.KeysOnly || rr.CountOnly {
+			return rpctypes.ErrGRPCInvalidValueRef
+		}
+	}
 	return nil
 }
 
@@ -51,6 +51,10 @@ type Downgrader interface {
 	Downgrade(ctx context.Context, dr *pb.DowngradeRequest) (*pb.DowngradeResponse, error)
 }
 
+type MaintenanceModer interface {
+	MaintenanceMode(ctx context.Context, r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error)
+}
+
 type LeaderTransferrer interface {
 	MoveLeader(ctx context.Context, lead, target uint64) error
 }
@@ -74,10 +78,11 @@ type maintenanceServer struct {
 	hdr header
 	cs  ClusterStatusGetter
 	d   Downgrader
+	mm  MaintenanceModer
 }
 
 func NewMaintenanceServer(s *etcdserver.EtcdServer) pb.MaintenanceServer {
-	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, kg: s, bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, d: s}
+	srv := &maintenanceServer{lg: s.Cfg.Logger, rg: s, kg: s, bg: s, a: s, lt: s, hdr: newHeader(s), cs: s, d: s, mm: s}
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
@@ -121,6 +126,19 @@ func (ms *maintenanceServer) Downgrade(ctx context.Context, r *pb.DowngradeReque
 	return resp, nil
 }
 
+// MaintenanceMode 查询或切换只读维护模式
+func (ms *maintenanceServer) MaintenanceMode(ctx context.Context, r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error) {
+	resp, err := ms.mm.MaintenanceMode(ctx, r)
+	if err != nil {
+		return nil, togRPCError(err)
+	}
+	if resp.Header == nil {
+		resp.Header = &pb.ResponseHeader{}
+	}
+	ms.hdr.fill(resp.Header)
+	return resp, nil
+}
+
 type authMaintenanceServer struct {
 	*maintenanceServer
 	ag AuthGetter
@@ -170,6 +188,13 @@ func (ams *authMaintenanceServer) Downgrade(ctx context.Context, r *pb.Downgrade
 	return ams.maintenanceServer.Downgrade(ctx, r)
 }
 
+func (ams *authMaintenanceServer) MaintenanceMode(ctx context.Context, r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error) {
+	if err := ams.isAuthenticated(ctx); err != nil {
+		return nil, err
+	}
+	return ams.maintenanceServer.MaintenanceMode(ctx, r)
+}
+
 // ------------------------------------  OVER ---------------------------------------------------------------
 
 // Alarm ok
@@ -295,6 +320,15 @@ func (ms *maintenanceServer) Hash(ctx context.Context, r *pb.HashRequest) (*pb.H
 
 // HashKV OK
 func (ms *maintenanceServer) HashKV(ctx context.Context, r *pb.HashKVRequest) (*pb.HashKVResponse, error) {
+	if len(r.Ranges) > 0 {
+		resp, err := etcdserver.HashKVRanges(ms.kg.KV(), r)
+		if err != nil {
+			return nil, togRPCError(err)
+		}
+		ms.hdr.fill(resp.Header)
+		return resp, nil
+	}
+
 	h, rev, compactRev, err := ms.kg.KV().HashByRev(r.Revision)
 	if err != nil {
 		return nil, togRPCError(err)
@@ -0,0 +1,58 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	watchStreamPendingEvents = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "etcd",
+			Subsystem: "grpc",
+			Name:      "watch_stream_pending_events",
+			Help:      "Bucketed histogram of events queued on the server but not yet sent, sampled per watch stream.",
+
+			// 1, 4, 16 ... 4^11
+			Buckets: prometheus.ExponentialBuckets(1, 4, 12),
+		})
+
+	slowWatchersTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "etcd",
+			Subsystem: "grpc",
+			Name:      "slow_watchers_total",
+			Help:      "Counter of watchers whose stream exceeded the pending events limit, by policy (block/cancel).",
+		}, []string{"policy"})
+)
+
+func init() {
+	prometheus.MustRegister(watchStreamPendingEvents)
+	prometheus.MustRegister(slowWatchersTotal)
+}
+
+// reportWatchStreamStats 记录一个watch流的积压情况, last 是上一次记录时的统计, 用于计算计数器的增量
+func reportWatchStreamStats(cur, last mvcc.WatchStreamStats) {
+	watchStreamPendingEvents.Observe(float64(cur.Pending))
+	if d := cur.Blocked - last.Blocked; d > 0 {
+		slowWatchersTotal.WithLabelValues(string(mvcc.SlowWatcherBlock)).Add(float64(d))
+	}
+	if d := cur.Canceled - last.Canceled; d > 0 {
+		slowWatchersTotal.WithLabelValues(string(mvcc.SlowWatcherCancel)).Add(float64(d))
+	}
+}
@@ -15,9 +15,12 @@
 package v3rpc
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"math/rand"
+	"regexp"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,6 +36,12 @@ import (
 
 const minWatchProgressInterval = 100 * time.Millisecond
 
+// watchAuthCheckInterval 检查watch的鉴权版本是否落后的周期; 版本未变化时不会重新校验权限
+const watchAuthCheckInterval = time.Second
+
+// watchStatsInterval 采样watch流积压情况的周期
+const watchStatsInterval = 5 * time.Second
+
 type watchServer struct {
 	lg              *zap.Logger
 	clusterID       int64
@@ -41,6 +50,7 @@ type watchServer struct {
 	sg              etcdserver.RaftStatusGetter
 	watchable       mvcc.WatchableKV
 	ag              AuthGetter
+	sessions        *watchSessionStore // 为nil表示不支持可恢复的watch
 }
 
 var (
@@ -77,16 +87,26 @@ type serverWatchStream struct {
 	gRPCStream      pb.Watch_WatchServer   // 与客户端进行连接的 Stream
 	watchStream     mvcc.WatchStream       // key 变动的消息管道
 	ctrlStream      chan *pb.WatchResponse // 用来发送控制响应的Chan,比如watcher创建和取消.
+	sessions        *watchSessionStore
+	sessionc        chan mvcc.WatchResponse // 可恢复的watcher的事件
 
-	// mu protects progress, prevKV, fragment
+	// mu protects progress, prevKV, fragment, coalesce, perms, revoked, watchSessions, nextWatchID
 	mu sync.RWMutex
 	// tracks the watchID that stream might need to send progress to
 	// TODO: combine progress and prevKV into a single struct?
-	progress map[mvcc.WatchID]bool // 该类型的 watch,服务端会定时发送类似心跳消息
-	prevKV   map[mvcc.WatchID]bool // 该类型表明,对于/a/b 这样的监听范围, 如果 b 变化了, 前缀/a也需要通知
-	fragment map[mvcc.WatchID]bool // 该类型表明,传输数据量大于阈值,需要拆分发送
+	progress map[mvcc.WatchID]bool       // 该类型的 watch,服务端会定时发送类似心跳消息
+	prevKV   map[mvcc.WatchID]bool       // 该类型表明,对于/a/b 这样的监听范围, 如果 b 变化了, 前缀/a也需要通知
+	fragment map[mvcc.WatchID]bool       // 该类型表明,传输数据量大于阈值,需要拆分发送
+	perms    map[mvcc.WatchID]*watchPerm // 创建watch时的身份和范围, 鉴权版本变化后据此重新校验
+	revoked  map[mvcc.WatchID]bool       // 因权限变更被取消的watch, 丢弃其尚未发送的事件; 由 sendLoop 在不再需要时删除
 	closec   chan struct{}
 	wg       sync.WaitGroup // 等待send loop 完成
+
+	coalesce map[mvcc.WatchID]time.Duration // 按key合并事件的窗口
+
+	// 可恢复的watcher不在 watchStream 中, watch id 由 nextWatchID 统一分配以免冲突
+	watchSessions map[mvcc.WatchID]*watchSession
+	nextWatchID   mvcc.WatchID
 }
 
 // Watch 创建一个watcher stream
@@ -102,9 +122,15 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 		gRPCStream:      stream, //
 		watchStream:     ws.watchable.NewWatchStream(),
 		ctrlStream:      make(chan *pb.WatchResponse, ctrlStreamBufLen), // 用来发送控制响应的Chan,比如watcher创建和取消.
+		sessions:        ws.sessions,
+		sessionc:        make(chan mvcc.WatchResponse),
 		progress:        make(map[mvcc.WatchID]bool),
 		prevKV:          make(map[mvcc.WatchID]bool),
 		fragment:        make(map[mvcc.WatchID]bool),
+		perms:           make(map[mvcc.WatchID]*watchPerm),
+		revoked:         make(map[mvcc.WatchID]bool),
+		coalesce:        make(map[mvcc.WatchID]time.Duration),
+		watchSessions:   make(map[mvcc.WatchID]*watchSession),
 		closec:          make(chan struct{}),
 	}
 
@@ -143,16 +169,97 @@ func (ws *watchServer) Watch(stream pb.Watch_WatchServer) (err error) {
 	return err
 }
 
-func (sws *serverWatchStream) isWatchPermitted(wcr *pb.WatchCreateRequest) bool {
+// watchPerm 记录watch创建时通过校验的身份和范围
+type watchPerm struct {
+	authInfo auth.AuthInfo
+	key      []byte
+	rangeEnd []byte
+	rev      uint64 // 最近一次校验通过时的鉴权版本
+}
+
+func (sws *serverWatchStream) isWatchPermitted(wcr *pb.WatchCreateRequest) (*watchPerm, bool) {
 	authInfo, err := sws.ag.AuthInfoFromCtx(sws.gRPCStream.Context())
 	if err != nil {
-		return false
+		return nil, false
 	}
 	if authInfo == nil {
 		// if auth is enabled, IsRangePermitted() can cause an error
 		authInfo = &auth.AuthInfo{}
 	}
-	return sws.ag.AuthStore().IsRangePermitted(authInfo, []byte(wcr.Key), []byte(wcr.RangeEnd)) == nil
+	// 先读取版本, 校验期间发生的变更会在之后重新校验
+	wp := &watchPerm{authInfo: *authInfo, key: []byte(wcr.Key), rangeEnd: []byte(wcr.RangeEnd), rev: sws.ag.AuthStore().Revision()}
+	return wp, sws.ag.AuthStore().IsRangePermitted(authInfo, wp.key, wp.rangeEnd) == nil
+}
+
+// isStillPermitted 按当前的鉴权数据重新校验watch;
+// stream的令牌在创建后不会更新, 因此沿用创建时的身份, 只以当前版本评估其权限
+func isStillPermitted(as auth.AuthStore, wp *watchPerm, rev uint64) bool {
+	authInfo := wp.authInfo
+	authInfo.Revision = rev
+	return as.IsRangePermitted(&authInfo, wp.key, wp.rangeEnd) == nil
+}
+
+// revokeUnpermitted 重新校验鉴权版本落后的watch, 取消不再被允许的, 返回被取消的watch
+func (sws *serverWatchStream) revokeUnpermitted() []revokedWatch {
+	as := sws.ag.AuthStore()
+	rev := as.Revision()
+	sws.mu.Lock()
+	var denied []mvcc.WatchID
+	for id, wp := range sws.perms {
+		if wp.rev >= rev {
+			continue
+		}
+		if isStillPermitted(as, wp, rev) {
+			wp.rev = rev
+		} else {
+			denied = append(denied, id)
+		}
+	}
+	sws.mu.Unlock()
+
+	var revoked []revokedWatch
+	for _, id := range denied {
+		sws.mu.RLock()
+		_, isSession := sws.watchSessions[id]
+		sws.mu.RUnlock()
+		if err := sws.cancelWatch(id); err != nil {
+			// 已被客户端取消
+			continue
+		}
+		// 取消后mvcc不会再发送这个watcher的事件, 但 watchStream 缓冲中可能还有;
+		// 可恢复的watcher在 cancelWatch 返回时已停止转发
+		inflight := 0
+		if !isSession {
+			inflight = len(sws.watchStream.Chan())
+		}
+		sws.mu.Lock()
+		wp := sws.perms[id]
+		delete(sws.progress, id)
+		delete(sws.prevKV, id)
+		delete(sws.fragment, id)
+		delete(sws.coalesce, id)
+		delete(sws.perms, id)
+		sws.revoked[id] = true
+		sws.mu.Unlock()
+
+		sws.lg.Warn(
+			"权限变更后取消了不再被允许的watch",
+			zap.Int64("watch-id", int64(id)),
+			zap.String("user-name", wp.authInfo.Username),
+			zap.ByteString("key", wp.key),
+			zap.ByteString("range-end", wp.rangeEnd),
+			zap.Uint64("auth-revision", rev),
+		)
+		revoked = append(revoked, revokedWatch{id: id, inflight: inflight})
+	}
+	return revoked
+}
+
+// revokedWatch 因权限变更被取消的watch; inflight 为取消时 watchStream 缓冲中可能属于它的响应数
+type revokedWatch struct {
+	id       mvcc.WatchID
+	inflight int
+	created  bool // 创建响应是否已经发出
 }
 
 // 接收watch请求,可以是创建、取消、和
@@ -189,7 +296,8 @@ func (sws *serverWatchStream) recvLoop() error {
 				creq.RangeEnd = string([]byte{})
 			}
 			// 权限校验
-			if !sws.isWatchPermitted(creq) { // 当前请求 权限不允许
+			wp, permitted := sws.isWatchPermitted(creq)
+			if !permitted { // 当前请求 权限不允许
 				wr := &pb.WatchResponse{
 					Header:       sws.newResponseHeader(sws.watchStream.Rev()),
 					WatchId:      creq.WatchId,
@@ -206,14 +314,38 @@ func (sws *serverWatchStream) recvLoop() error {
 				}
 			}
 
-			filters := FiltersFromRequest(creq) // server端  从watch请求中 获取一些过滤调价
+			filters, ferr := FiltersFromRequest(creq) // server端  从watch请求中 获取一些过滤调价
+			if ferr != nil {
+				sws.lg.Debug("watch 的过滤条件无效", zap.String("value-regex", creq.ValueRegex), zap.Error(ferr))
+				wr := &pb.WatchResponse{
+					Header:       sws.newResponseHeader(sws.watchStream.Rev()),
+					WatchId:      creq.WatchId,
+					Canceled:     true,
+					Created:      true,
+					CancelReason: rpctypes.ErrGRPCInvalidWatchFilter.Error(),
+				}
+
+				select {
+				case sws.ctrlStream <- wr:
+					continue
+				case <-sws.closec:
+					return nil
+				}
+			}
+
+			if creq.WatchId != int64(mvcc.AutoWatchID) {
+				sws.mu.Lock()
+				// 复用了因权限变更而取消的watch ID
+				delete(sws.revoked, mvcc.WatchID(creq.WatchId))
+				sws.mu.Unlock()
+			}
 
 			wsrev := sws.watchStream.Rev() // 获取当前kv的修订版本
 			rev := creq.StartRevision      // 监听从哪个修订版本之后的变更,没穿就是当前
 			if rev == 0 {
 				rev = wsrev + 1
 			}
-			id, err := sws.watchStream.Watch(mvcc.WatchID(creq.WatchId), []byte(creq.Key), []byte(creq.RangeEnd), rev, filters...)
+			id, sess, err := sws.watch(creq, wp, rev, filters)
 			if err == nil {
 				sws.mu.Lock()
 				if creq.ProgressNotify { // 默认FALSE
@@ -225,6 +357,10 @@ func (sws *serverWatchStream) recvLoop() error {
 				if creq.Fragment { // 拆分大的事件
 					sws.fragment[id] = true
 				}
+				if creq.CoalesceWindowMs > 0 {
+					sws.coalesce[id] = time.Duration(creq.CoalesceWindowMs) * time.Millisecond
+				}
+				sws.perms[id] = wp
 				sws.mu.Unlock()
 			}
 			wr := &pb.WatchResponse{
@@ -236,18 +372,24 @@ func (sws *serverWatchStream) recvLoop() error {
 			if err != nil {
 				wr.CancelReason = err.Error()
 			}
+			if sess != nil {
+				wr.SessionId = sess.id
+			}
 			select {
 			case sws.ctrlStream <- wr: // 客户端创建watch的响应
 			case <-sws.closec:
 				return nil
 			}
+			if sess != nil {
+				sess.attach(watchSessionAttach{out: sws.sessionc, id: id, donec: sws.closec, startRev: rev})
+			}
 		}
 		if req.WatchRequest_CancelRequest != nil { // 删除watcher ✅
 			uv := &pb.WatchRequest_CancelRequest{}
 			uv = req.WatchRequest_CancelRequest
 			if uv.CancelRequest != nil {
 				id := uv.CancelRequest.WatchId
-				err := sws.watchStream.Cancel(mvcc.WatchID(id))
+				err := sws.cancelWatch(mvcc.WatchID(id))
 				if err == nil {
 					sws.ctrlStream <- &pb.WatchResponse{
 						Header:   sws.newResponseHeader(sws.watchStream.Rev()),
@@ -258,6 +400,8 @@ func (sws *serverWatchStream) recvLoop() error {
 					delete(sws.progress, mvcc.WatchID(id))
 					delete(sws.prevKV, mvcc.WatchID(id))
 					delete(sws.fragment, mvcc.WatchID(id))
+					delete(sws.coalesce, mvcc.WatchID(id))
+					delete(sws.perms, mvcc.WatchID(id))
 					sws.mu.Unlock()
 				}
 			}
@@ -265,7 +409,13 @@ func (sws *serverWatchStream) recvLoop() error {
 		if req.WatchRequest_ProgressRequest != nil {
 			uv := &pb.WatchRequest_ProgressRequest{}
 			uv = req.WatchRequest_ProgressRequest
-			if uv.ProgressRequest != nil {
+			if uv.ProgressRequest != nil && uv.ProgressRequest.Targeted {
+				// 只通知指定的watcher, 响应与它的事件经同一channel发出, 保证顺序
+				id := mvcc.WatchID(uv.ProgressRequest.WatchId)
+				if err := sws.requestWatchProgress(id); err != nil {
+					sws.lg.Debug("未能请求watch进度", zap.Int64("watch-id", int64(id)), zap.Error(err))
+				}
+			} else if uv.ProgressRequest != nil {
 				sws.ctrlStream <- &pb.WatchResponse{
 					Header:  sws.newResponseHeader(sws.watchStream.Rev()),
 					WatchId: -1, // 如果发送了密钥更新,则忽略下一次进度更新
@@ -284,75 +434,217 @@ func (sws *serverWatchStream) sendLoop() {
 
 	interval := GetProgressReportInterval() // interval   10m44s
 	progressTicker := time.NewTicker(interval)
+	authTicker := time.NewTicker(watchAuthCheckInterval)
+	statsTicker := time.NewTicker(watchStatsInterval)
+	var lastStats mvcc.WatchStreamStats
+
+	// 按key合并事件的watcher, 以及最早到期的合并窗口
+	coalescers := make(map[mvcc.WatchID]*watchCoalescer)
+	var coalescec <-chan time.Time
+	var coalesceAt time.Time
 
 	defer func() {
 		progressTicker.Stop()
+		authTicker.Stop()
+		statsTicker.Stop()
+		reportWatchStreamStats(sws.watchStream.Stats(), lastStats)
 	}()
 
-	for {
-		select {
-		case wresp, ok := <-sws.watchStream.Chan(): // watchStream Channel中提取event发送
-			if !ok {
-				return
+	// 被撤销的watch, 创建响应发出且缓冲中的旧事件消费完之前保留在 sws.revoked 中
+	revoking := make(map[mvcc.WatchID]*revokedWatch)
+	forgetRevoked := func(rw *revokedWatch) {
+		if !rw.created || rw.inflight > 0 {
+			return
+		}
+		delete(revoking, rw.id)
+		sws.mu.Lock()
+		delete(sws.revoked, rw.id)
+		sws.mu.Unlock()
+	}
+
+	// 鉴权数据变更后取消不再被允许的watch; 创建响应已发出的立即通知客户端, 其余的在发送创建响应时通知
+	revoke := func() bool {
+		for _, rw := range sws.revokeUnpermitted() {
+			rw := rw
+			id := rw.id
+			delete(pending, id)
+			delete(coalescers, id)
+			_, okID := ids[id]
+			rw.created = okID
+			revoking[id] = &rw
+			forgetRevoked(&rw)
+			if !okID {
+				continue
 			}
-			evs := wresp.Events
-			events := make([]*mvccpb.Event, len(evs))
-			sws.mu.RLock()
-			needPrevKV := sws.prevKV[wresp.WatchID]
-			sws.mu.RUnlock()
-			for i := range evs {
-				events[i] = &evs[i]
-				if needPrevKV && !IsCreateEvent(evs[i]) {
-					opt := mvcc.RangeOptions{Rev: evs[i].Kv.ModRevision - 1}
-					r, err := sws.watchable.Range(context.TODO(), []byte(evs[i].Kv.Key), nil, opt)
-					if err == nil && len(r.KVs) != 0 {
-						events[i].PrevKv = &(r.KVs[0])
-					}
+			delete(ids, id)
+			wr := &pb.WatchResponse{
+				Header:       sws.newResponseHeader(sws.watchStream.Rev()),
+				WatchId:      int64(id),
+				Canceled:     true,
+				CancelReason: rpctypes.ErrGRPCWatchPermissionRevoked.Error(),
+			}
+			if err := sws.gRPCStream.Send(wr); err != nil {
+				if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
+					sws.lg.Debug("未能向gRPC流发送watch取消响应", zap.Error(err))
+				} else {
+					sws.lg.Warn("向gRPC流发送watch取消响应失败", zap.Error(err))
 				}
+				return false
 			}
+		}
+		return true
+	}
 
-			canceled := wresp.CompactRevision != 0
-			wr := &pb.WatchResponse{
-				Header:          sws.newResponseHeader(wresp.Revision),
-				WatchId:         int64(wresp.WatchID),
-				Events:          events,
-				CompactRevision: wresp.CompactRevision,
-				Canceled:        canceled,
-			}
-			_, okID := ids[wresp.WatchID]
-			if !okID { // 当前id 不活跃
-				// 缓冲,如果ID尚未公布
-				wrs := append(pending[wresp.WatchID], wr)
-				pending[wresp.WatchID] = wrs
-				continue
-			}
+	// 向客户端发送一个watch响应; watch的创建响应发出前先缓存, 返回false表示流已不可用
+	sendResponse := func(wr *pb.WatchResponse) bool {
+		id := mvcc.WatchID(wr.WatchId)
+		_, okID := ids[id]
+		if !okID { // 当前id 不活跃
+			// 缓冲,如果ID尚未公布
+			wrs := append(pending[id], wr)
+			pending[id] = wrs
+			return true
+		}
 
-			sws.mu.RLock()
-			fragmented, ok := sws.fragment[wresp.WatchID] // 是否 拆分大的数据
-			sws.mu.RUnlock()
+		sws.mu.RLock()
+		fragmented, ok := sws.fragment[id] // 是否 拆分大的数据
+		sws.mu.RUnlock()
+
+		var serr error
+		if !fragmented && !ok {
+			serr = sws.gRPCStream.Send(wr)
+		} else {
+			serr = sendFragments(wr, sws.maxRequestBytes, sws.gRPCStream.Send)
+		}
 
-			var serr error
-			if !fragmented && !ok {
-				serr = sws.gRPCStream.Send(wr)
+		if serr != nil {
+			if isClientCtxErr(sws.gRPCStream.Context().Err(), serr) {
+				sws.lg.Debug("未能向gRPC流发送watch响应", zap.Error(serr))
 			} else {
-				serr = sendFragments(wr, sws.maxRequestBytes, sws.gRPCStream.Send)
+				sws.lg.Warn("向gRPC流发送watch响应失败", zap.Error(serr))
 			}
+			return false
+		}
 
-			if serr != nil {
-				if isClientCtxErr(sws.gRPCStream.Context().Err(), serr) {
-					sws.lg.Debug("未能向gRPC流发送watch响应", zap.Error(serr))
-				} else {
-					sws.lg.Warn("向gRPC流发送watch响应失败", zap.Error(serr))
+		sws.mu.Lock()
+		if len(wr.Events) > 0 && sws.progress[id] {
+			// 如果发送了密钥更新,则忽略下一次进度更新
+			sws.progress[id] = false
+		}
+		sws.mu.Unlock()
+		return true
+	}
+
+	// 发送合并窗口中的事件
+	flushCoalesced := func(id mvcc.WatchID) bool {
+		c := coalescers[id]
+		if c == nil || !c.pending() {
+			return true
+		}
+		events, rev := c.flush()
+		return sendResponse(&pb.WatchResponse{
+			Header:  sws.newResponseHeader(rev),
+			WatchId: int64(id),
+			Events:  events,
+		})
+	}
+
+	// 发送mvcc中的事件, 来自 sws.watchStream 或可恢复的watcher; 返回false表示流已不可用
+	sendEvents := func(wresp mvcc.WatchResponse) bool {
+		// 发送事件前确认watch的权限没有因鉴权数据变更而失效
+		sws.mu.RLock()
+		wp := sws.perms[wresp.WatchID]
+		stale := wp != nil && wp.rev < sws.ag.AuthStore().Revision()
+		sws.mu.RUnlock()
+		if stale && !revoke() {
+			return false
+		}
+		sws.mu.RLock()
+		revoked := sws.revoked[wresp.WatchID]
+		sws.mu.RUnlock()
+		if revoked {
+			return true
+		}
+
+		evs := wresp.Events
+		events := make([]*mvccpb.Event, len(evs))
+		sws.mu.RLock()
+		needPrevKV := sws.prevKV[wresp.WatchID]
+		sws.mu.RUnlock()
+		for i := range evs {
+			events[i] = &evs[i]
+			if needPrevKV && !IsCreateEvent(evs[i]) {
+				opt := mvcc.RangeOptions{Rev: evs[i].Kv.ModRevision - 1}
+				r, err := sws.watchable.Range(context.TODO(), []byte(evs[i].Kv.Key), nil, opt)
+				if err == nil && len(r.KVs) != 0 {
+					events[i].PrevKv = &(r.KVs[0])
+				}
+			}
+		}
+
+		canceled := wresp.CompactRevision != 0 || wresp.ResumeRevision != 0
+		wr := &pb.WatchResponse{
+			Header:          sws.newResponseHeader(wresp.Revision),
+			WatchId:         int64(wresp.WatchID),
+			Events:          events,
+			CompactRevision: wresp.CompactRevision,
+			Canceled:        canceled,
+		}
+		if wresp.ResumeRevision != 0 {
+			// 积压超限被取消, 客户端从 ResumeRevision 重新watch
+			wr.CancelReason = rpctypes.ErrGRPCWatchSlowConsumer.Error()
+			wr.ResumeRevision = wresp.ResumeRevision
+			sws.lg.Warn(
+				"watch积压的事件过多, 已取消",
+				zap.Int64("watch-id", int64(wresp.WatchID)),
+				zap.Int64("resume-revision", wresp.ResumeRevision),
+			)
+		}
+
+		sws.mu.RLock()
+		window := sws.coalesce[wresp.WatchID]
+		sws.mu.RUnlock()
+		if window > 0 {
+			c := coalescers[wresp.WatchID]
+			if c == nil {
+				c = newWatchCoalescer(window)
+				coalescers[wresp.WatchID] = c
+			}
+			if len(events) > 0 && !canceled {
+				c.add(time.Now(), events, wresp.Revision)
+				if coalescec == nil || c.deadline.Before(coalesceAt) {
+					coalescec, coalesceAt = time.After(time.Until(c.deadline)), c.deadline
+				}
+				return true
+			}
+			// 进度通知、取消等响应之前先发出合并中的事件, 保证顺序
+			if !flushCoalesced(wresp.WatchID) {
+				return false
+			}
+		}
+		return sendResponse(wr)
+	}
+
+	for {
+		select {
+		case wresp, ok := <-sws.watchStream.Chan(): // watchStream Channel中提取event发送
+			if !ok {
+				return
+			}
+			for _, rw := range revoking {
+				if rw.inflight > 0 {
+					rw.inflight--
+					forgetRevoked(rw)
 				}
+			}
+			if !sendEvents(wresp) {
 				return
 			}
 
-			sws.mu.Lock()
-			if len(evs) > 0 && sws.progress[wresp.WatchID] {
-				// 如果发送了密钥更新,则忽略下一次进度更新
-				sws.progress[wresp.WatchID] = false
+		case wresp := <-sws.sessionc: // 可恢复的watcher的事件
+			if !sendEvents(wresp) {
+				return
 			}
-			sws.mu.Unlock()
 
 		case c, ok := <-sws.ctrlStream: // 流控制信号  ✅
 			// 给client回复的响应
@@ -360,6 +652,35 @@ func (sws *serverWatchStream) sendLoop() {
 				return // channel关闭了
 			}
 
+			if c.Created && !c.Canceled {
+				sws.mu.RLock()
+				revoked := sws.revoked[mvcc.WatchID(c.WatchId)]
+				sws.mu.RUnlock()
+				if revoked {
+					// 创建响应发出前权限已被撤销
+					c.Canceled = true
+					c.CancelReason = rpctypes.ErrGRPCWatchPermissionRevoked.Error()
+				}
+				if rw := revoking[mvcc.WatchID(c.WatchId)]; rw != nil {
+					if revoked {
+						rw.created = true
+						forgetRevoked(rw)
+					} else {
+						// id 已被新的watch复用
+						delete(revoking, rw.id)
+					}
+				}
+			}
+
+			if c.WatchId == -1 {
+				// 广播的进度通知之前先发出所有合并中的事件, 否则客户端会先收到更高的进度修订版本
+				for id := range coalescers {
+					if !flushCoalesced(id) {
+						return
+					}
+				}
+			}
+
 			if err := sws.gRPCStream.Send(c); err != nil {
 				if isClientCtxErr(sws.gRPCStream.Context().Err(), err) {
 					sws.lg.Debug("未能向gRPC流发送watch控制响应", zap.Error(err))
@@ -373,6 +694,7 @@ func (sws *serverWatchStream) sendLoop() {
 			wid := mvcc.WatchID(c.WatchId) // 第一次创建watcher  ,id 是0
 			if c.Canceled {
 				delete(ids, wid)
+				delete(coalescers, wid)
 				continue
 			}
 			if c.Created {
@@ -390,11 +712,41 @@ func (sws *serverWatchStream) sendLoop() {
 				delete(pending, wid)
 			}
 
+		case <-authTicker.C:
+			if !revoke() {
+				return
+			}
+
+		case <-coalescec: // 发送到期的合并事件
+			now := time.Now()
+			coalescec, coalesceAt = nil, time.Time{}
+			for id, c := range coalescers {
+				if !c.pending() {
+					continue
+				}
+				if !c.deadline.After(now) {
+					if !flushCoalesced(id) {
+						return
+					}
+				} else if coalescec == nil || c.deadline.Before(coalesceAt) {
+					coalescec, coalesceAt = time.After(time.Until(c.deadline)), c.deadline
+				}
+			}
+
+		case <-statsTicker.C:
+			st := sws.watchStream.Stats()
+			reportWatchStreamStats(st, lastStats)
+			lastStats = st
+
 		case <-progressTicker.C: // 定时同步状态
 			sws.mu.Lock()
 			for id, ok := range sws.progress {
 				if ok {
-					sws.watchStream.RequestProgress(id)
+					if sess, isSession := sws.watchSessions[id]; isSession {
+						sess.requestProgress()
+					} else {
+						sws.watchStream.RequestProgress(id)
+					}
 				}
 				sws.progress[id] = true
 			}
@@ -456,6 +808,10 @@ func NewWatchServer(s *etcdserver.EtcdServer) pb.WatchServer {
 	if srv.lg == nil {
 		srv.lg = zap.NewNop()
 	}
+	if s.Cfg.WatchSessionGracePeriod > 0 && s.Cfg.WatchSessionBufferSize > 0 {
+		srv.sessions = newWatchSessionStore(srv.lg, srv.watchable, s.Cfg.WatchSessionGracePeriod, s.Cfg.WatchSessionBufferSize,
+			s.Cfg.WatchSessionMaxParked, s.Cfg.WatchSessionMaxParkedPerUser)
+	}
 	if s.Cfg.WatchProgressNotifyInterval > 0 {
 		if s.Cfg.WatchProgressNotifyInterval < minWatchProgressInterval {
 			srv.lg.Warn("将watch 进度通知时间间隔调整为最小周期", zap.Duration("min-watch-progress-notify-interval", minWatchProgressInterval))
@@ -479,7 +835,8 @@ func GetProgressReportInterval() time.Duration {
 	return interval + jitter
 }
 
-func FiltersFromRequest(creq *pb.WatchCreateRequest) []mvcc.FilterFunc {
+// FiltersFromRequest 将创建请求中的过滤条件转换为在mvcc中、事件发送前执行的过滤函数
+func FiltersFromRequest(creq *pb.WatchCreateRequest) ([]mvcc.FilterFunc, error) {
 	filters := make([]mvcc.FilterFunc, 0, len(creq.Filters))
 	for _, ft := range creq.Filters {
 		switch ft {
@@ -490,7 +847,23 @@ func FiltersFromRequest(creq *pb.WatchCreateRequest) []mvcc.FilterFunc {
 		default:
 		}
 	}
-	return filters
+	if len(creq.KeySuffixes) > 0 {
+		filters = append(filters, filterKeySuffix(creq.KeySuffixes))
+	}
+	if len(creq.ValuePrefix) > 0 {
+		filters = append(filters, filterValuePrefix(creq.ValuePrefix))
+	}
+	if len(creq.ValueRegex) > 0 {
+		re, err := regexp.Compile(creq.ValueRegex)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filterValueRegex(re))
+	}
+	if creq.Lease != 0 {
+		filters = append(filters, filterLease(creq.Lease))
+	}
+	return filters, nil
 }
 
 // 当前的修订版本
@@ -511,6 +884,106 @@ func (sws *serverWatchStream) close() {
 	sws.watchStream.Close()
 	close(sws.closec)
 	sws.wg.Wait()
+
+	// 可恢复的watcher在宽限期内等待客户端重连
+	sws.mu.Lock()
+	sessions := sws.watchSessions
+	sws.watchSessions = nil
+	sws.mu.Unlock()
+	for _, sess := range sessions {
+		sws.sessions.park(sess)
+	}
+}
+
+// watch 创建watcher; 可恢复的watcher由会话持有, 连接断开后不会被取消
+func (sws *serverWatchStream) watch(creq *pb.WatchCreateRequest, wp *watchPerm, rev int64, filters []mvcc.FilterFunc) (mvcc.WatchID, *watchSession, error) {
+	key, end := []byte(creq.Key), []byte(creq.RangeEnd)
+	if len(end) != 0 && bytes.Compare(key, end) != -1 {
+		return -1, nil, mvcc.ErrEmptyWatcherRange
+	}
+
+	sws.mu.Lock()
+	id, ok := sws.unsafeAllocWatchID(mvcc.WatchID(creq.WatchId))
+	sws.mu.Unlock()
+	if !ok {
+		return -1, nil, mvcc.ErrWatcherDuplicateID
+	}
+
+	if sws.sessions == nil || (creq.ResumeSessionId == "" && !creq.Resumable) {
+		id, err := sws.watchStream.Watch(id, key, end, rev, filters...)
+		return id, nil, err
+	}
+
+	var (
+		sess *watchSession
+		err  error
+		opts = newWatchSessionOptions(creq)
+	)
+	if creq.ResumeSessionId != "" {
+		if sess, err = sws.sessions.resume(creq.ResumeSessionId, wp.authInfo.Username, key, end, opts, rev); err != nil {
+			return -1, nil, err
+		}
+	}
+	if sess == nil {
+		// 会话不存在或无法覆盖 rev 时创建新的会话, 由后端同步历史事件
+		if sess, err = sws.sessions.create(wp.authInfo.Username, key, end, rev, opts, filters); err != nil {
+			return -1, nil, err
+		}
+	}
+
+	sws.mu.Lock()
+	if sws.watchSessions == nil {
+		// 流已关闭
+		sws.mu.Unlock()
+		sws.sessions.park(sess)
+		return -1, nil, rpctypes.ErrGRPCWatchCanceled
+	}
+	sws.watchSessions[id] = sess
+	sws.mu.Unlock()
+	return id, sess, nil
+}
+
+// unsafeAllocWatchID 分配watch id; 调用方需持有 sws.mu
+func (sws *serverWatchStream) unsafeAllocWatchID(id mvcc.WatchID) (mvcc.WatchID, bool) {
+	if id != mvcc.AutoWatchID {
+		_, used := sws.perms[id]
+		return id, !used
+	}
+	for {
+		// 被撤销的id在其缓冲的事件被丢弃之前不能复用
+		_, used := sws.perms[sws.nextWatchID]
+		if !used && !sws.revoked[sws.nextWatchID] {
+			break
+		}
+		sws.nextWatchID++
+	}
+	id = sws.nextWatchID
+	sws.nextWatchID++
+	return id, true
+}
+
+// cancelWatch 取消watcher, 可恢复的watcher同时关闭其会话
+func (sws *serverWatchStream) cancelWatch(id mvcc.WatchID) error {
+	sws.mu.Lock()
+	sess, ok := sws.watchSessions[id]
+	delete(sws.watchSessions, id)
+	sws.mu.Unlock()
+	if ok {
+		sess.close()
+		return nil
+	}
+	return sws.watchStream.Cancel(id)
+}
+
+// requestWatchProgress 请求单个watcher的进度, 即使它尚未同步
+func (sws *serverWatchStream) requestWatchProgress(id mvcc.WatchID) error {
+	sws.mu.RLock()
+	sess, ok := sws.watchSessions[id]
+	sws.mu.RUnlock()
+	if ok {
+		return sess.requestWatchProgress()
+	}
+	return sws.watchStream.RequestWatchProgress(id)
 }
 
 func filterNoDelete(e mvccpb.Event) bool {
@@ -520,3 +993,34 @@ func filterNoDelete(e mvccpb.Event) bool {
 func filterNoPut(e mvccpb.Event) bool {
 	return e.Type == mvccpb.PUT
 }
+
+// 以下过滤函数中, 值和租约只对PUT事件有意义, DELETE事件总是保留
+
+func filterKeySuffix(suffixes []string) mvcc.FilterFunc {
+	return func(e mvccpb.Event) bool {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(e.Kv.Key, suffix) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func filterValuePrefix(prefix string) mvcc.FilterFunc {
+	return func(e mvccpb.Event) bool {
+		return e.Type == mvccpb.PUT && !strings.HasPrefix(e.Kv.Value, prefix)
+	}
+}
+
+func filterValueRegex(re *regexp.Regexp) mvcc.FilterFunc {
+	return func(e mvccpb.Event) bool {
+		return e.Type == mvccpb.PUT && !re.MatchString(e.Kv.Value)
+	}
+}
+
+func filterLease(lease int64) mvcc.FilterFunc {
+	return func(e mvccpb.Event) bool {
+		return e.Type == mvccpb.PUT && e.Kv.Lease != lease
+	}
+}
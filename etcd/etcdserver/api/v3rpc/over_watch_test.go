@@ -0,0 +1,136 @@
+// Copyright 2021 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
+	"github.com/ls-2018/etcd_cn/etcd/auth"
+	"github.com/ls-2018/etcd_cn/etcd/lease"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	betesting "github.com/ls-2018/etcd_cn/etcd/mvcc/backend/testing"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+)
+
+type fakeRaftStatusGetter struct{}
+
+func (fakeRaftStatusGetter) ID() types.ID           { return 1 }
+func (fakeRaftStatusGetter) Leader() types.ID       { return 1 }
+func (fakeRaftStatusGetter) CommittedIndex() uint64 { return 0 }
+func (fakeRaftStatusGetter) AppliedIndex() uint64   { return 0 }
+func (fakeRaftStatusGetter) Term() uint64           { return 1 }
+
+type fakeAuthStore struct{ auth.AuthStore }
+
+func (fakeAuthStore) Revision() uint64 { return 0 }
+
+type fakeAuthGetter struct{}
+
+func (fakeAuthGetter) AuthInfoFromCtx(context.Context) (*auth.AuthInfo, error) { return nil, nil }
+func (fakeAuthGetter) AuthStore() auth.AuthStore                               { return fakeAuthStore{} }
+
+type fakeWatchServer struct {
+	pb.Watch_WatchServer
+	sentc chan *pb.WatchResponse
+}
+
+func (s *fakeWatchServer) Send(wr *pb.WatchResponse) error {
+	s.sentc <- wr
+	return nil
+}
+
+func (s *fakeWatchServer) Context() context.Context { return context.Background() }
+
+// TestSendLoopFlushesCoalescedBeforeProgress 广播的进度通知不能越过合并窗口中尚未发出的事件
+func TestSendLoopFlushesCoalescedBeforeProgress(t *testing.T) {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	defer func() {
+		kv.Close()
+		betesting.Close(t, be)
+	}()
+
+	stream := &fakeWatchServer{sentc: make(chan *pb.WatchResponse, 16)}
+	sws := &serverWatchStream{
+		lg:              lg,
+		maxRequestBytes: 1024 * 1024,
+		sg:              fakeRaftStatusGetter{},
+		watchable:       kv,
+		ag:              fakeAuthGetter{},
+		gRPCStream:      stream,
+		watchStream:     kv.NewWatchStream(),
+		ctrlStream:      make(chan *pb.WatchResponse, ctrlStreamBufLen),
+		sessionc:        make(chan mvcc.WatchResponse),
+		progress:        make(map[mvcc.WatchID]bool),
+		prevKV:          make(map[mvcc.WatchID]bool),
+		fragment:        make(map[mvcc.WatchID]bool),
+		perms:           make(map[mvcc.WatchID]*watchPerm),
+		revoked:         make(map[mvcc.WatchID]bool),
+		coalesce:        make(map[mvcc.WatchID]time.Duration),
+		watchSessions:   make(map[mvcc.WatchID]*watchSession),
+		closec:          make(chan struct{}),
+	}
+	sws.wg.Add(1)
+	go func() {
+		sws.sendLoop()
+		sws.wg.Done()
+	}()
+	defer sws.close()
+
+	id, err := sws.watchStream.Watch(mvcc.AutoWatchID, []byte("foo"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sws.mu.Lock()
+	sws.coalesce[id] = time.Hour
+	sws.mu.Unlock()
+	sws.ctrlStream <- &pb.WatchResponse{Header: sws.newResponseHeader(kv.Rev()), WatchId: int64(id), Created: true}
+	if wr := <-stream.sentc; !wr.Created {
+		t.Fatalf("expected created response, got %+v", wr)
+	}
+
+	kv.Put([]byte("foo"), []byte("bar"), lease.NoLease)
+	// 等待 sendLoop 取走事件, 之后的控制响应一定在它处理完事件后才被处理
+	for len(sws.watchStream.Chan()) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	rev := kv.Rev()
+	sws.ctrlStream <- &pb.WatchResponse{Header: sws.newResponseHeader(rev), WatchId: -1}
+
+	var got []*pb.WatchResponse
+	for len(got) < 2 {
+		select {
+		case wr := <-stream.sentc:
+			got = append(got, wr)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for responses, got %d", len(got))
+		}
+	}
+	if got[0].WatchId != int64(id) || len(got[0].Events) != 1 || got[0].Events[0].Type != mvccpb.PUT {
+		t.Fatalf("expected coalesced event first, got %+v", got[0])
+	}
+	if got[0].Header.Revision != rev {
+		t.Errorf("expected event revision %d, got %d", rev, got[0].Header.Revision)
+	}
+	if got[1].WatchId != -1 || got[1].Header.Revision != rev {
+		t.Errorf("expected progress response at revision %d, got %+v", rev, got[1])
+	}
+}
@@ -44,6 +44,7 @@ var toGRPCErrorMap = map[error]error{
 	mvcc.ErrFutureRev:             rpctypes.ErrGRPCFutureRev,
 	etcdserver.ErrRequestTooLarge: rpctypes.ErrGRPCRequestTooLarge,
 	etcdserver.ErrNoSpace:         rpctypes.ErrGRPCNoSpace,
+	etcdserver.ErrReadOnly:        rpctypes.ErrGRPCReadOnly,
 	etcdserver.ErrTooManyRequests: rpctypes.ErrTooManyRequests,
 
 	etcdserver.ErrNoLeader:                   rpctypes.ErrGRPCNoLeader,
@@ -55,6 +56,12 @@ var toGRPCErrorMap = map[error]error{
 	etcdserver.ErrTimeoutDueToConnectionLost: rpctypes.ErrGRPCTimeoutDueToConnectionLost,
 	etcdserver.ErrUnhealthy:                  rpctypes.ErrGRPCUnhealthy,
 	etcdserver.ErrKeyNotFound:                rpctypes.ErrGRPCKeyNotFound,
+	etcdserver.ErrKeyExists:                  rpctypes.ErrGRPCKeyExists,
+	etcdserver.ErrKeyWrittenInTxn:            rpctypes.ErrGRPCKeyWrittenInTxn,
+	etcdserver.ErrModRevisionMismatch:        rpctypes.ErrGRPCModRevisionMismatch,
+	etcdserver.ErrValueHashMismatch:          rpctypes.ErrGRPCValueHashMismatch,
+	etcdserver.ErrValueNotNumber:             rpctypes.ErrGRPCValueNotNumber,
+	etcdserver.ErrCounterOverflow:            rpctypes.ErrGRPCCounterOverflow,
 	etcdserver.ErrCorrupt:                    rpctypes.ErrGRPCCorrupt,
 	etcdserver.ErrBadLeaderTransferee:        rpctypes.ErrGRPCBadLeaderTransferee,
 
@@ -86,6 +93,14 @@ var toGRPCErrorMap = map[error]error{
 	auth.ErrInvalidAuthMgmt:      rpctypes.ErrGRPCInvalidAuthMgmt,
 	auth.ErrAuthOldRevision:      rpctypes.ErrGRPCAuthOldRevision,
 
+	auth.ErrTokenNameEmpty:         rpctypes.ErrGRPCTokenNameEmpty,
+	auth.ErrTokenAlreadyExist:      rpctypes.ErrGRPCTokenAlreadyExist,
+	auth.ErrTokenNotFound:          rpctypes.ErrGRPCTokenNotFound,
+	auth.ErrTokenExpired:           rpctypes.ErrGRPCTokenExpired,
+	auth.ErrInvalidTokenTTL:        rpctypes.ErrGRPCInvalidTokenTTL,
+	auth.ErrInvalidTokenPermission: rpctypes.ErrGRPCInvalidTokenPermission,
+	auth.ErrRateLimited:            rpctypes.ErrGRPCRateLimited,
+
 	// In sync with status.FromContextError
 	context.Canceled:         rpctypes.ErrGRPCCanceled,
 	context.DeadlineExceeded: rpctypes.ErrGRPCDeadlineExceeded,
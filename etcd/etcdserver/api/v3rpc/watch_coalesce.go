@@ -0,0 +1,71 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+)
+
+// watchCoalescer 在窗口内按key合并一个watcher的事件, 每个key只保留最终状态.
+// 只在 sendLoop 中使用, 不需要加锁.
+type watchCoalescer struct {
+	window   time.Duration
+	deadline time.Time // 有缓存的事件时, 到期发送的时间
+	events   map[string]*mvccpb.Event
+	rev      int64 // 最近一次响应的修订版本
+}
+
+func newWatchCoalescer(window time.Duration) *watchCoalescer {
+	return &watchCoalescer{window: window, events: make(map[string]*mvccpb.Event)}
+}
+
+func (c *watchCoalescer) pending() bool {
+	return len(c.events) != 0
+}
+
+// add 合并事件; 窗口从第一个缓存的事件开始计时, PrevKv 保留窗口开始前的值
+func (c *watchCoalescer) add(now time.Time, events []*mvccpb.Event, rev int64) {
+	if !c.pending() {
+		c.deadline = now.Add(c.window)
+	}
+	for _, ev := range events {
+		// 事件可能与其他watcher共享, 复制后再修改
+		e := *ev
+		if old, ok := c.events[e.Kv.Key]; ok {
+			e.PrevKv = old.PrevKv
+		}
+		c.events[e.Kv.Key] = &e
+	}
+	c.rev = rev
+}
+
+// flush 按修订版本顺序返回合并后的事件, 以及它们对应的响应修订版本
+func (c *watchCoalescer) flush() ([]*mvccpb.Event, int64) {
+	events := make([]*mvccpb.Event, 0, len(c.events))
+	for _, ev := range c.events {
+		events = append(events, ev)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Kv.ModRevision != events[j].Kv.ModRevision {
+			return events[i].Kv.ModRevision < events[j].Kv.ModRevision
+		}
+		return events[i].Kv.Key < events[j].Kv.Key
+	})
+	c.events = make(map[string]*mvccpb.Event)
+	return events, c.rev
+}
@@ -0,0 +1,202 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/etcd/auth"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+	"go.uber.org/zap"
+)
+
+// listWatchDefaultPageSize 请求未指定 page_size 时每页的key数
+const listWatchDefaultPageSize = 1000
+
+// ListWatch 先按页发送范围在某个修订版本时的状态, 再发送 ListDone 响应, 之后转发该修订版本之后的事件.
+// watcher在读取第一页之前就从下一个修订版本开始watch, 两个阶段之间不会丢失或重复事件.
+// 与Watch一样读取本地状态, 不保证线性一致.
+func (ws *watchServer) ListWatch(req *pb.ListWatchRequest, stream pb.Watch_ListWatchServer) error {
+	key, end := []byte(req.Key), []byte(req.RangeEnd)
+	if len(key) == 0 {
+		// \x00 is the smallest key
+		key = []byte{0}
+	}
+	// 与 Watch 一致: range_end 为 \x00 表示不小于key的全部key
+	fromKey := len(end) == 1 && end[0] == 0
+	if fromKey {
+		end = []byte{}
+	}
+	if len(end) != 0 && bytes.Compare(key, end) != -1 {
+		return rpctypes.ErrGRPCEmptyKey
+	}
+
+	authInfo, err := ws.ag.AuthInfoFromCtx(stream.Context())
+	if err != nil {
+		return err
+	}
+	if authInfo == nil {
+		authInfo = &auth.AuthInfo{}
+	}
+	as := ws.ag.AuthStore()
+	wp := &watchPerm{authInfo: *authInfo, key: key, rangeEnd: end, rev: as.Revision()}
+	if err = as.IsRangePermitted(authInfo, key, end); err != nil {
+		return rpctypes.ErrGRPCPermissionDenied
+	}
+
+	rev := req.Revision
+	if rev == 0 {
+		rev = ws.watchable.Rev()
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = listWatchDefaultPageSize
+	}
+
+	header := func(rev int64) *pb.ResponseHeader {
+		return &pb.ResponseHeader{
+			ClusterId: uint64(ws.clusterID),
+			MemberId:  uint64(ws.memberID),
+			Revision:  rev,
+			RaftTerm:  ws.sg.Term(),
+		}
+	}
+	compacted := func(compactRev int64) error {
+		return stream.Send(&pb.ListWatchResponse{
+			Header:          header(rev),
+			CompactRevision: compactRev,
+			Canceled:        true,
+			CancelReason:    rpctypes.ErrGRPCCompacted.Error(),
+		})
+	}
+
+	// 先建立watcher: 列出期间的变更由mvcc从后端同步, 不需要在此缓存
+	wstream := ws.watchable.NewWatchStream()
+	defer wstream.Close()
+	id, err := wstream.Watch(0, key, end, rev+1)
+	if err != nil {
+		return err
+	}
+
+	for next := key; ; {
+		r, err := ws.watchable.Range(stream.Context(), next, end, mvcc.RangeOptions{Limit: pageSize, Rev: rev})
+		if err == mvcc.ErrCompacted {
+			return compacted(ws.watchable.FirstRev())
+		}
+		if err == mvcc.ErrFutureRev {
+			return rpctypes.ErrGRPCFutureRev
+		}
+		if err != nil {
+			return err
+		}
+		if len(r.KVs) == 0 {
+			break
+		}
+		kvs := make([]*mvccpb.KeyValue, len(r.KVs))
+		for i := range r.KVs {
+			kvs[i] = &r.KVs[i]
+		}
+		if err = stream.Send(&pb.ListWatchResponse{Header: header(rev), Kvs: kvs}); err != nil {
+			return err
+		}
+		if int64(len(r.KVs)) < pageSize || (len(end) == 0 && !fromKey) {
+			// 最后一页或者只请求了单个key
+			break
+		}
+		// 下一页从最后一个key之后开始
+		next = append([]byte(r.KVs[len(r.KVs)-1].Key), 0)
+	}
+	if err = stream.Send(&pb.ListWatchResponse{Header: header(rev), ListDone: true}); err != nil {
+		return err
+	}
+
+	var progressc <-chan time.Time
+	if req.ProgressNotify {
+		progressTicker := time.NewTicker(GetProgressReportInterval())
+		defer progressTicker.Stop()
+		progressc = progressTicker.C
+	}
+	authTicker := time.NewTicker(watchAuthCheckInterval)
+	defer authTicker.Stop()
+
+	sent := false // 上一个进度周期内是否发送过事件
+	for {
+		select {
+		case wresp, ok := <-wstream.Chan():
+			if !ok {
+				return nil
+			}
+			if wresp.CompactRevision != 0 {
+				return compacted(wresp.CompactRevision)
+			}
+			if wresp.ResumeRevision != 0 {
+				return stream.Send(&pb.ListWatchResponse{
+					Header:       header(wresp.Revision),
+					Canceled:     true,
+					CancelReason: rpctypes.ErrGRPCWatchSlowConsumer.Error(),
+				})
+			}
+			events := make([]*mvccpb.Event, len(wresp.Events))
+			for i := range wresp.Events {
+				events[i] = &wresp.Events[i]
+				if req.PrevKv && !IsCreateEvent(wresp.Events[i]) {
+					opt := mvcc.RangeOptions{Rev: events[i].Kv.ModRevision - 1}
+					r, err := ws.watchable.Range(context.TODO(), []byte(events[i].Kv.Key), nil, opt)
+					if err == nil && len(r.KVs) != 0 {
+						events[i].PrevKv = &(r.KVs[0])
+					}
+				}
+			}
+			if err = stream.Send(&pb.ListWatchResponse{Header: header(wresp.Revision), Events: events}); err != nil {
+				return err
+			}
+			sent = len(events) > 0
+
+		case <-progressc:
+			if !sent {
+				wstream.RequestProgress(id)
+			}
+			sent = false
+
+		case <-authTicker.C:
+			if cur := as.Revision(); wp.rev < cur {
+				if !isStillPermitted(as, wp, cur) {
+					ws.lg.Warn(
+						"权限变更后取消了不再被允许的list-watch",
+						zap.String("user-name", wp.authInfo.Username),
+						zap.ByteString("key", wp.key),
+						zap.ByteString("range-end", wp.rangeEnd),
+						zap.Uint64("auth-revision", cur),
+					)
+					return rpctypes.ErrGRPCPermissionDenied
+				}
+				wp.rev = cur
+			}
+
+		case <-stream.Context().Done():
+			err = stream.Context().Err()
+			if err == context.Canceled {
+				err = rpctypes.ErrGRPCWatchCanceled
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3rpc
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+	"go.uber.org/zap"
+)
+
+// 可恢复的watcher独占一个mvcc.WatchStream, 不随gRPC流关闭; 它会缓存最近的事件,
+// 连接断开后在宽限期内继续接收事件, 客户端带着会话ID重连时直接从缓存重放, 不需要读取后端.
+
+// watchSessionStore 保存连接已断开、等待恢复的会话
+type watchSessionStore struct {
+	lg        *zap.Logger
+	watchable mvcc.WatchableKV
+	grace     time.Duration // 断开后保留的时间
+	bufSize   int           // 每个会话最多缓存的事件数
+
+	// 每个等待恢复的会话都占用一个watch stream、一个goroutine和事件缓存, 0表示不限制
+	maxParked        int
+	maxParkedPerUser int
+
+	mu           sync.Mutex
+	sessions     map[string]*watchSession
+	parkedByUser map[string]int
+}
+
+func newWatchSessionStore(lg *zap.Logger, watchable mvcc.WatchableKV, grace time.Duration, bufSize, maxParked, maxParkedPerUser int) *watchSessionStore {
+	return &watchSessionStore{
+		lg:               lg,
+		watchable:        watchable,
+		grace:            grace,
+		bufSize:          bufSize,
+		maxParked:        maxParked,
+		maxParkedPerUser: maxParkedPerUser,
+		sessions:         make(map[string]*watchSession),
+		parkedByUser:     make(map[string]int),
+	}
+}
+
+// create 创建一个可恢复的watcher, startRev 为实际开始监听的修订版本
+func (st *watchSessionStore) create(user string, key, end []byte, startRev int64, opts watchSessionOptions, filters []mvcc.FilterFunc) (*watchSession, error) {
+	ws := st.watchable.NewWatchStream()
+	wid, err := ws.Watch(mvcc.AutoWatchID, key, end, startRev, filters...)
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+	s := &watchSession{
+		id:          newWatchSessionID(),
+		user:        user,
+		key:         key,
+		end:         end,
+		opts:        opts,
+		ws:          ws,
+		wid:         wid,
+		bufSize:     st.bufSize,
+		coveredFrom: startRev,
+		attachc:     make(chan watchSessionAttach),
+		stopc:       make(chan struct{}),
+		donec:       make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// park 连接断开后保留会话, 宽限期内没有恢复则关闭; 超过上限的会话直接关闭
+func (st *watchSessionStore) park(s *watchSession) {
+	if s.isCompacted() {
+		s.close()
+		return
+	}
+	st.mu.Lock()
+	if st.maxParked > 0 && len(st.sessions) >= st.maxParked {
+		st.mu.Unlock()
+		s.close()
+		st.lg.Warn("等待恢复的watch会话过多, 直接关闭", zap.String("session-id", s.id), zap.Int("max-parked", st.maxParked))
+		return
+	}
+	if st.maxParkedPerUser > 0 && st.parkedByUser[s.user] >= st.maxParkedPerUser {
+		st.mu.Unlock()
+		s.close()
+		st.lg.Warn("用户等待恢复的watch会话过多, 直接关闭", zap.String("session-id", s.id), zap.String("user", s.user), zap.Int("max-parked-per-user", st.maxParkedPerUser))
+		return
+	}
+	st.sessions[s.id] = s
+	st.parkedByUser[s.user]++
+	s.timer = time.AfterFunc(st.grace, func() { st.expire(s) })
+	st.mu.Unlock()
+}
+
+// unsafeRemove 取出等待恢复的会话; 调用方需持有 st.mu
+func (st *watchSessionStore) unsafeRemove(s *watchSession) {
+	delete(st.sessions, s.id)
+	if st.parkedByUser[s.user]--; st.parkedByUser[s.user] <= 0 {
+		delete(st.parkedByUser, s.user)
+	}
+}
+
+func (st *watchSessionStore) expire(s *watchSession) {
+	st.mu.Lock()
+	if st.sessions[s.id] != s {
+		// 已被恢复
+		st.mu.Unlock()
+		return
+	}
+	st.unsafeRemove(s)
+	st.mu.Unlock()
+
+	s.close()
+	st.lg.Debug("可恢复的watch超过宽限期未恢复, 已关闭", zap.String("session-id", s.id))
+}
+
+// resume 取出可以从 startRev 恢复的会话; 会话必须属于同一用户且监听同一范围,
+// 过滤条件、prev_kv、fragment 等选项与创建时不一致时拒绝恢复, 会话继续等待
+func (st *watchSessionStore) resume(id, user string, key, end []byte, opts watchSessionOptions, startRev int64) (*watchSession, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	s, ok := st.sessions[id]
+	if !ok || s.user != user || !bytes.Equal(s.key, key) || !bytes.Equal(s.end, end) {
+		return nil, nil
+	}
+	if !s.opts.equal(opts) {
+		return nil, rpctypes.ErrGRPCWatchSessionMismatch
+	}
+	st.unsafeRemove(s)
+	s.timer.Stop()
+	if !s.resumable(startRev) {
+		// 客户端会重新创建watcher, 这个会话不再有用
+		s.close()
+		return nil, nil
+	}
+	return s, nil
+}
+
+// watchSessionOptions 决定会话中缓存了哪些事件以及如何转发, 恢复时必须一致
+type watchSessionOptions struct {
+	filters     []pb.WatchCreateRequest_FilterType
+	keySuffixes []string
+	valuePrefix string
+	valueRegex  string
+	lease       int64
+	prevKV      bool
+	fragment    bool
+}
+
+func newWatchSessionOptions(creq *pb.WatchCreateRequest) watchSessionOptions {
+	opts := watchSessionOptions{
+		filters:     append([]pb.WatchCreateRequest_FilterType(nil), creq.Filters...),
+		keySuffixes: append([]string(nil), creq.KeySuffixes...),
+		valuePrefix: creq.ValuePrefix,
+		valueRegex:  creq.ValueRegex,
+		lease:       creq.Lease,
+		prevKV:      creq.PrevKv,
+		fragment:    creq.Fragment,
+	}
+	// 过滤条件与顺序无关
+	sort.Slice(opts.filters, func(i, j int) bool { return opts.filters[i] < opts.filters[j] })
+	sort.Strings(opts.keySuffixes)
+	return opts
+}
+
+func (o watchSessionOptions) equal(other watchSessionOptions) bool {
+	if o.valuePrefix != other.valuePrefix || o.valueRegex != other.valueRegex || o.lease != other.lease ||
+		o.prevKV != other.prevKV || o.fragment != other.fragment ||
+		len(o.filters) != len(other.filters) || len(o.keySuffixes) != len(other.keySuffixes) {
+		return false
+	}
+	for i := range o.filters {
+		if o.filters[i] != other.filters[i] {
+			return false
+		}
+	}
+	for i := range o.keySuffixes {
+		if o.keySuffixes[i] != other.keySuffixes[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type watchSessionAttach struct {
+	out      chan<- mvcc.WatchResponse
+	id       mvcc.WatchID    // 在当前gRPC流中的watch id
+	donec    <-chan struct{} // 当前gRPC流关闭时关闭
+	startRev int64           // 从缓存中重放修订版本不小于它的事件
+}
+
+type watchSession struct {
+	id       string
+	user     string
+	key, end []byte
+	opts     watchSessionOptions
+	ws       mvcc.WatchStream
+	wid      mvcc.WatchID
+	bufSize  int
+	timer    *time.Timer // 等待恢复时的宽限期, 受 watchSessionStore.mu 保护
+
+	// mu protects buf, bufEvents, coveredFrom, compacted
+	mu          sync.Mutex
+	buf         []mvcc.WatchResponse // 最近的事件, 按修订版本递增
+	bufEvents   int
+	coveredFrom int64 // 修订版本不小于它的事件都在buf中
+	compacted   bool
+
+	attachc   chan watchSessionAttach
+	stopc     chan struct{}
+	donec     chan struct{} // run 退出后关闭
+	closeOnce sync.Once
+}
+
+func newWatchSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// resumable 会话的缓存是否包含了修订版本不小于 startRev 的全部事件
+func (s *watchSession) resumable(startRev int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.compacted && s.coveredFrom <= startRev
+}
+
+func (s *watchSession) isCompacted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.compacted
+}
+
+// attach 将会话接入gRPC流, 先重放缓存中的事件, 再转发新的事件
+func (s *watchSession) attach(a watchSessionAttach) {
+	select {
+	case s.attachc <- a:
+	case <-s.stopc:
+	}
+}
+
+func (s *watchSession) requestProgress() {
+	s.ws.RequestProgress(s.wid)
+}
+
+func (s *watchSession) requestWatchProgress() error {
+	return s.ws.RequestWatchProgress(s.wid)
+}
+
+// close 返回后会话不会再向gRPC流转发任何事件
+func (s *watchSession) close() {
+	s.closeOnce.Do(func() {
+		close(s.stopc)
+		s.ws.Close()
+	})
+	<-s.donec
+}
+
+// run 是唯一向gRPC流转发事件的goroutine, 保证重放的事件在新事件之前
+func (s *watchSession) run() {
+	defer close(s.donec)
+	var cur watchSessionAttach
+	send := func(wr mvcc.WatchResponse) {
+		if cur.out == nil {
+			return
+		}
+		wr.WatchID = cur.id
+		select {
+		case cur.out <- wr:
+		case <-cur.donec:
+			// 连接已断开, 之后的事件只进入缓存
+			cur = watchSessionAttach{}
+		case <-s.stopc:
+		}
+	}
+
+	for {
+		select {
+		case a := <-s.attachc:
+			cur = a
+			for _, wr := range s.replay(a.startRev) {
+				send(wr)
+			}
+
+		case wr, ok := <-s.ws.Chan():
+			if !ok {
+				return
+			}
+			s.record(wr)
+			send(wr)
+
+		case <-s.stopc:
+			return
+		}
+	}
+}
+
+func (s *watchSession) record(wr mvcc.WatchResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if wr.CompactRevision != 0 || wr.ResumeRevision != 0 {
+		// 被压缩或因积压被取消后不再接收事件
+		s.compacted = true
+		return
+	}
+	if len(wr.Events) == 0 {
+		// 进度通知不需要缓存
+		return
+	}
+	s.buf = append(s.buf, wr)
+	s.bufEvents += len(wr.Events)
+	for s.bufEvents > s.bufSize && len(s.buf) > 1 {
+		s.bufEvents -= len(s.buf[0].Events)
+		s.coveredFrom = s.buf[0].Revision + 1
+		s.buf[0] = mvcc.WatchResponse{}
+		s.buf = s.buf[1:]
+	}
+}
+
+func (s *watchSession) replay(startRev int64) []mvcc.WatchResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var wrs []mvcc.WatchResponse
+	for _, wr := range s.buf {
+		if wr.Revision < startRev {
+			continue
+		}
+		var evs []mvccpb.Event
+		for _, ev := range wr.Events {
+			if ev.Kv.ModRevision >= startRev {
+				evs = append(evs, ev)
+			}
+		}
+		if len(evs) == 0 {
+			continue
+		}
+		wrs = append(wrs, mvcc.WatchResponse{Events: evs, Revision: wr.Revision})
+	}
+	return wrs
+}
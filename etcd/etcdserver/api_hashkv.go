@@ -50,7 +50,15 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "反序列化请求数据失败", http.StatusBadRequest)
 		return
 	}
-	hash, rev, compactRev, err := h.server.KV().HashByRev(req.Revision)
+	var resp *pb.HashKVResponse
+	if len(req.Ranges) != 0 {
+		resp, err = HashKVRanges(h.server.KV(), req)
+	} else {
+		var hash uint32
+		var rev, compactRev int64
+		hash, rev, compactRev, err = h.server.KV().HashByRev(req.Revision)
+		resp = &pb.HashKVResponse{Header: &pb.ResponseHeader{Revision: rev}, Hash: hash, CompactRevision: compactRev}
+	}
 	if err != nil {
 		h.lg.Warn(
 			"获取hash值失败",
@@ -60,7 +68,6 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	resp := &pb.HashKVResponse{Header: &pb.ResponseHeader{Revision: rev}, Hash: hash, CompactRevision: compactRev}
 	respBytes, err := json.Marshal(resp)
 	if err != nil {
 		h.lg.Warn("failed to marshal hashKV response", zap.Error(err))
@@ -75,8 +82,12 @@ func (h *hashKVHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // getPeerHashKVHTTP 通过对给定网址的http调用在给定的rev中获取kv存储的哈希值.
 func (s *EtcdServer) getPeerHashKVHTTP(ctx context.Context, url string, rev int64) (*pb.HashKVResponse, error) {
+	return s.peerHashKVHTTP(ctx, url, &pb.HashKVRequest{Revision: rev}) // revision是哈希操作的键值存储修订版.
+}
+
+// peerHashKVHTTP 向给定网址发送 HashKV 请求
+func (s *EtcdServer) peerHashKVHTTP(ctx context.Context, url string, hashReq *pb.HashKVRequest) (*pb.HashKVResponse, error) {
 	cc := &http.Client{Transport: s.peerRt}
-	hashReq := &pb.HashKVRequest{Revision: rev} // revision是哈希操作的键值存储修订版.
 	hashReqBytes, err := json.Marshal(hashReq)
 	if err != nil {
 		return nil, err
@@ -47,17 +47,23 @@ func (aa *authApplierV3) Apply(r *pb.InternalRaftRequest, shouldApplyV3 membersh
 		// 当internalRaftRequest没有header时,向后兼容3.0之前的版本
 		aa.authInfo.Username = r.Header.Username
 		aa.authInfo.Revision = r.Header.AuthRevision
+		aa.authInfo.ScopedToken = r.Header.ScopedToken
+		aa.authInfo.Roles = r.Header.Roles
 	}
 	if needAdminPermission(r) {
 		if err := aa.as.IsAdminPermitted(&aa.authInfo); err != nil {
 			aa.authInfo.Username = ""
 			aa.authInfo.Revision = 0
+			aa.authInfo.ScopedToken = ""
+			aa.authInfo.Roles = nil
 			return &applyResult{err: err}
 		}
 	}
 	ret := aa.applierV3.Apply(r, shouldApplyV3)
 	aa.authInfo.Username = ""
 	aa.authInfo.Revision = 0
+	aa.authInfo.ScopedToken = ""
+	aa.authInfo.Roles = nil
 	return ret
 }
 
@@ -212,6 +218,49 @@ func (aa *authApplierV3) RoleGet(r *pb.AuthRoleGetRequest) (*pb.AuthRoleGetRespo
 	return aa.applierV3.RoleGet(r)
 }
 
+// isTokenOwnerPermitted 管理员或用户本人(非scoped token)可以管理该用户的token
+func (aa *authApplierV3) isTokenOwnerPermitted(user string) error {
+	err := aa.as.IsAdminPermitted(&aa.authInfo)
+	if err != nil && (user != aa.authInfo.Username || aa.authInfo.ScopedToken != "") {
+		aa.authInfo.Username = ""
+		aa.authInfo.Revision = 0
+		return err
+	}
+	return nil
+}
+
+func (aa *authApplierV3) UserTokenCreate(r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	if err := aa.isTokenOwnerPermitted(r.User); err != nil {
+		return &pb.AuthUserTokenCreateResponse{}, err
+	}
+	return aa.applierV3.UserTokenCreate(r)
+}
+
+func (aa *authApplierV3) UserTokenList(r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	if err := aa.isTokenOwnerPermitted(r.User); err != nil {
+		return &pb.AuthUserTokenListResponse{}, err
+	}
+	return aa.applierV3.UserTokenList(r)
+}
+
+func (aa *authApplierV3) UserTokenRevoke(r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	if err := aa.isTokenOwnerPermitted(r.User); err != nil {
+		return &pb.AuthUserTokenRevokeResponse{}, err
+	}
+	return aa.applierV3.UserTokenRevoke(r)
+}
+
+// AuthCheck 管理员可以检查任意用户, 普通用户只能检查自己
+func (aa *authApplierV3) AuthCheck(_ *auth.AuthInfo, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	err := aa.as.IsAdminPermitted(&aa.authInfo)
+	if err != nil && r.User != aa.authInfo.Username {
+		aa.authInfo.Username = ""
+		aa.authInfo.Revision = 0
+		return &pb.AuthCheckResponse{}, err
+	}
+	return aa.applierV3.AuthCheck(&aa.authInfo, r)
+}
+
 func needAdminPermission(r *pb.InternalRaftRequest) bool {
 	switch {
 	case r.AuthEnable != nil:
@@ -242,6 +291,10 @@ func needAdminPermission(r *pb.InternalRaftRequest) bool {
 		return true
 	case r.AuthRoleList != nil:
 		return true
+	case r.AuthUserSetRateLimit != nil:
+		return true
+	case r.AuthRoleSetRateLimit != nil:
+		return true
 	default:
 		return false
 	}
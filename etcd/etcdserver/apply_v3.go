@@ -17,8 +17,13 @@ package etcdserver
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/coreos/go-semver/semver"
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
@@ -78,6 +83,15 @@ type applierV3 interface {
 	RoleDelete(ua *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ua *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ua *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	UserTokenCreate(ua *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error)
+	UserTokenList(ua *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error)
+	UserTokenRevoke(ua *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error)
+	AuthTokenPurge(ua *pb.InternalAuthTokenPurgeRequest) (*pb.EmptyResponse, error)
+	// AuthCheck authInfo 是发起检查的请求者, 检查自己时需要其证书映射的角色和 scoped token; 由 authApplierV3 填充
+	AuthCheck(authInfo *auth.AuthInfo, ua *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error)
+	UserSetRateLimit(ua *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error)
+	RoleSetRateLimit(ua *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error)
+	MaintenanceMode(r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error)
 }
 
 type checkReqFunc func(mvcc.ReadView, *pb.RequestOp) error
@@ -105,7 +119,11 @@ func (s *EtcdServer) newApplierV3Internal() applierV3Internal {
 }
 
 func (s *EtcdServer) newApplierV3() applierV3 {
-	return newAuthApplierV3(s.AuthStore(), newQuotaApplierV3(s, s.newApplierV3Backend()), s.lessor)
+	a := newAuthApplierV3(s.AuthStore(), newQuotaApplierV3(s, s.newApplierV3Backend()), s.lessor)
+	if s.isReadOnly() {
+		return newApplierV3ReadOnly(a)
+	}
+	return a
 }
 
 // Put raft 传递之后 实际上将k,v存储到应用内的逻辑
@@ -122,6 +140,8 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 		)
 	}
 	val, leaseID := p.Value, lease.LeaseID(p.Lease)
+	// 事务中的写入条件已在checkRequests中按执行顺序检查过, 执行时不再检查, 保证执行结果与检查结果一致
+	inTxn := txn != nil
 	if txn == nil { // 写事务
 		if leaseID != lease.NoLease {
 			if l := a.s.lessor.Lookup(leaseID); l == nil { // 查找租约
@@ -134,7 +154,8 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 	}
 
 	var rr *mvcc.RangeResult
-	if p.IgnoreValue || p.IgnoreLease || p.PrevKv {
+	checkCond := !inTxn && hasPutCondition(p)
+	if p.IgnoreValue || p.IgnoreLease || p.PrevKv || p.Increment != nil || checkCond {
 		trace.StepWithFunction(func() {
 			rr, err = txn.Range(context.TODO(), []byte(p.Key), nil, mvcc.RangeOptions{})
 		}, "得到之前的kv对")
@@ -143,6 +164,12 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 			return nil, nil, err
 		}
 	}
+	// 条件与写入在同一个写事务中, 检查结果在写入时仍然成立
+	if checkCond {
+		if err = checkPutCondition(p, rr); err != nil {
+			return nil, nil, err
+		}
+	}
 	if p.IgnoreValue || p.IgnoreLease {
 		if rr == nil || len(rr.KVs) == 0 {
 			// ignore_{lease,value} flag expects previous key-value pair
@@ -152,6 +179,12 @@ func (a *applierV3backend) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.Put
 	if p.IgnoreValue {
 		val = rr.KVs[0].Value
 	}
+	if p.Increment != nil {
+		if resp.Counter, err = incrementCounter(p, rr); err != nil {
+			return nil, nil, err
+		}
+		val = strconv.FormatInt(resp.Counter, 10)
+	}
 	if p.IgnoreLease {
 		leaseID = lease.LeaseID(rr.KVs[0].Lease)
 	}
@@ -223,6 +256,10 @@ func (a *applierV3backend) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnR
 			txn.End()
 			return nil, nil, err
 		}
+		if _, err := checkTxnWrites(rt, txnPath, &txnWriteSet{}); err != nil {
+			txn.End()
+			return nil, nil, err
+		}
 	}
 	if _, err := checkRequests(txn, rt, txnPath, a.checkRange); err != nil {
 		txn.End()
@@ -321,6 +358,9 @@ func applyCompare(rv mvcc.ReadView, c *pb.Compare) bool {
 	// * rewrite rules for common patterns:
 	//	ex. "[a, b) createrev > 0" => "limit 1 /\ kvs > 0"
 	// * caching
+	if c.Aggregate != pb.Compare_NONE {
+		return applyAggregateCompare(rv, c)
+	}
 	rr, err := rv.Range(context.TODO(), []byte(c.Key), mkGteRange([]byte(c.RangeEnd)), mvcc.RangeOptions{})
 	if err != nil {
 		return false
@@ -341,6 +381,75 @@ func applyCompare(rv mvcc.ReadView, c *pb.Compare) bool {
 	return true
 }
 
+// applyAggregateCompare 把范围内的key聚合为一个值后比较一次; 空范围按零值比较, 与单个key不存在时一致
+func applyAggregateCompare(rv mvcc.ReadView, c *pb.Compare) bool {
+	key, end := []byte(c.Key), mkGteRange([]byte(c.RangeEnd))
+	if c.Aggregate == pb.Compare_COUNT {
+		rr, err := rv.Range(context.TODO(), key, end, mvcc.RangeOptions{Count: true})
+		if err != nil {
+			return false
+		}
+		return compareResult(c.Result, compareInt64(int64(rr.Count), c.Count))
+	}
+	if c.Aggregate != pb.Compare_MAX && c.Aggregate != pb.Compare_MIN {
+		return false
+	}
+
+	rr, err := rv.Range(context.TODO(), key, end, mvcc.RangeOptions{})
+	if err != nil {
+		return false
+	}
+	if len(rr.KVs) == 0 {
+		if c.Target == pb.Compare_VALUE {
+			return false
+		}
+		return compareKV(c, mvccpb.KeyValue{})
+	}
+	agg := rr.KVs[0]
+	for _, kv := range rr.KVs[1:] {
+		r := compareTarget(c.Target, c.Numeric, kv, agg)
+		if (c.Aggregate == pb.Compare_MAX && r > 0) || (c.Aggregate == pb.Compare_MIN && r < 0) {
+			agg = kv
+		}
+	}
+	return compareKV(c, agg)
+}
+
+// compareTarget 按 target 比较两个kv
+func compareTarget(target pb.Compare_CompareTarget, numeric bool, a, b mvccpb.KeyValue) int {
+	switch target {
+	case pb.Compare_VALUE:
+		if numeric {
+			return compareNumbers(a.Value, b.Value)
+		}
+		return bytes.Compare([]byte(a.Value), []byte(b.Value))
+	case pb.Compare_CREATE:
+		return compareInt64(a.CreateRevision, b.CreateRevision)
+	case pb.Compare_MOD:
+		return compareInt64(a.ModRevision, b.ModRevision)
+	case pb.Compare_VERSION:
+		return compareInt64(a.Version, b.Version)
+	case pb.Compare_LEASE:
+		return compareInt64(a.Lease, b.Lease)
+	}
+	return 0
+}
+
+// compareNumbers 按数值比较两个值, 不是数字的值排在所有数字之前
+func compareNumbers(a, b string) int {
+	an, aok := parseNumber(a)
+	bn, bok := parseNumber(b)
+	switch {
+	case aok && bok:
+		return compareInt64(an, bn)
+	case aok:
+		return 1
+	case bok:
+		return -1
+	}
+	return bytes.Compare([]byte(a), []byte(b))
+}
+
 func compareKV(c *pb.Compare, ckv mvccpb.KeyValue) bool {
 	var result int
 	rev := int64(0)
@@ -351,6 +460,15 @@ func compareKV(c *pb.Compare, ckv mvccpb.KeyValue) bool {
 		if c.Compare_Value != nil {
 			v = []byte(c.Compare_Value.Value)
 		}
+		if c.Numeric {
+			a, aok := parseNumber(ckv.Value)
+			b, bok := parseNumber(string(v))
+			if !aok || !bok {
+				return false
+			}
+			result = compareInt64(a, b)
+			break
+		}
 
 		result = bytes.Compare([]byte(ckv.Value), v)
 	case pb.Compare_CREATE:
@@ -374,7 +492,11 @@ func compareKV(c *pb.Compare, ckv mvccpb.KeyValue) bool {
 		}
 		result = compareInt64(ckv.Lease, rev)
 	}
-	switch c.Result {
+	return compareResult(c.Result, result)
+}
+
+func compareResult(op pb.Compare_CompareResult, result int) bool {
+	switch op {
 	case pb.Compare_EQUAL:
 		return result == 0
 	case pb.Compare_NOT_EQUAL:
@@ -415,11 +537,17 @@ func (a *applierV3backend) applyTxn(ctx context.Context, txn mvcc.TxnWrite, rt *
 		if req.RequestOp_RequestPut != nil {
 			respi := tresp.Responses[i].ResponseOp_ResponsePut
 			tv := req.RequestOp_RequestPut
+			put, ok := resolveValueRef(tresp.Responses, tv.RequestPut)
+			if !ok {
+				// 被引用的范围为空, 跳过该put
+				respi.ResponsePut = &pb.PutResponse{Header: &pb.ResponseHeader{}}
+				continue
+			}
 			trace.StartSubTrace(
 				traceutil.Field{Key: "req_type", Value: "put"},
-				traceutil.Field{Key: "key", Value: string(tv.RequestPut.Key)},
-				traceutil.Field{Key: "req_size", Value: tv.RequestPut.Size()})
-			resp, _, err := a.Put(ctx, txn, tv.RequestPut)
+				traceutil.Field{Key: "key", Value: string(put.Key)},
+				traceutil.Field{Key: "req_size", Value: put.Size()})
+			resp, _, err := a.Put(ctx, txn, put)
 			if err != nil {
 				lg.Panic("unexpected error during txn", zap.Error(err))
 			}
@@ -448,6 +576,22 @@ func (a *applierV3backend) applyTxn(ctx context.Context, txn mvcc.TxnWrite, rt *
 	return txns
 }
 
+// resolveValueRef 用同一分支中之前range操作的结果作为put的值, 被引用的范围为空时返回false.
+// 事务内的range能看到之前的写入, 引用的是执行到该操作时的结果.
+func resolveValueRef(resps []*pb.ResponseOp, p *pb.PutRequest) (*pb.PutRequest, bool) {
+	if p.ValueRef == nil {
+		return p, true
+	}
+	ref := resps[p.ValueRef.OpIndex].ResponseOp_ResponseRange
+	if ref == nil || ref.ResponseRange == nil || len(ref.ResponseRange.Kvs) == 0 {
+		return nil, false
+	}
+	np := *p
+	np.Value = ref.ResponseRange.Kvs[0].Value
+	np.ValueRef = nil
+	return &np, true
+}
+
 // Compaction 移除kv 历史事件
 func (a *applierV3backend) Compaction(compaction *pb.CompactionRequest) (*pb.CompactionResponse, <-chan struct{}, *traceutil.Trace, error) {
 	resp := &pb.CompactionResponse{}
@@ -524,6 +668,92 @@ func checkRequests(rv mvcc.ReadView, rt *pb.TxnRequest, txnPath []bool, f checkR
 	return txnCount, nil
 }
 
+// txnWriteSet 记录所选分支中到当前操作为止已写入或删除的key
+type txnWriteSet struct {
+	puts map[string]struct{}
+	dels []txnDelRange
+}
+
+// txnDelRange 删除的范围, end为空表示单个key, end为"\x00"表示 >= key
+type txnDelRange struct {
+	key, end string
+}
+
+func (ws *txnWriteSet) put(key string) {
+	if ws.puts == nil {
+		ws.puts = make(map[string]struct{})
+	}
+	ws.puts[key] = struct{}{}
+}
+
+func (ws *txnWriteSet) del(key, end string) {
+	ws.dels = append(ws.dels, txnDelRange{key: key, end: end})
+}
+
+// touched key是否被之前的操作写入或删除
+func (ws *txnWriteSet) touched(key string) bool {
+	if _, ok := ws.puts[key]; ok {
+		return true
+	}
+	for _, d := range ws.dels {
+		switch {
+		case len(d.end) == 0:
+			if key == d.key {
+				return true
+			}
+		case d.end == "\x00":
+			if key >= d.key {
+				return true
+			}
+		default:
+			if key >= d.key && key < d.end {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkTxnWrites 按执行顺序模拟所选分支(包括嵌套事务).
+// checkRequestPut 使用事务开始前的读视图, 而执行时事务内的range能看到同一事务之前的写入;
+// 依赖key当前状态的put只有在该key未被同一分支之前的操作修改时, 检查结果才与执行结果一致, 否则拒绝整个事务,
+// 避免执行期间出错导致所有成员panic.
+func checkTxnWrites(rt *pb.TxnRequest, txnPath []bool, ws *txnWriteSet) (int, error) {
+	txnCount := 0
+	reqs := rt.Success
+	if !txnPath[0] {
+		reqs = rt.Failure
+	}
+	for _, req := range reqs {
+		switch {
+		case req.RequestOp_RequestTxn != nil && req.RequestOp_RequestTxn.RequestTxn != nil:
+			txns, err := checkTxnWrites(req.RequestOp_RequestTxn.RequestTxn, txnPath[1:], ws)
+			if err != nil {
+				return 0, err
+			}
+			txnCount += txns + 1
+			txnPath = txnPath[txns+1:]
+		case req.RequestOp_RequestPut != nil && req.RequestOp_RequestPut.RequestPut != nil:
+			p := req.RequestOp_RequestPut.RequestPut
+			if dependsOnPrevKV(p) && ws.touched(p.Key) {
+				return 0, ErrKeyWrittenInTxn
+			}
+			// value_ref的值在执行时才从之前的range结果中解析, 被引用的范围为空时会跳过写入;
+			// 检查时无法确定, 一律按已写入处理
+			ws.put(p.Key)
+		case req.RequestOp_RequestDeleteRange != nil && req.RequestOp_RequestDeleteRange.RequestDeleteRange != nil:
+			dr := req.RequestOp_RequestDeleteRange.RequestDeleteRange
+			ws.del(dr.Key, dr.RangeEnd)
+		}
+	}
+	return txnCount, nil
+}
+
+// dependsOnPrevKV put的检查是否依赖key当前的状态; 自增的结果同样依赖当前值, 执行时不能因非数字或溢出而失败
+func dependsOnPrevKV(p *pb.PutRequest) bool {
+	return p.IgnoreValue || p.IgnoreLease || p.Increment != nil || hasPutCondition(p)
+}
+
 func (a *applierV3backend) checkRequestPut(rv mvcc.ReadView, reqOp *pb.RequestOp) error {
 	if reqOp.RequestOp_RequestPut == nil {
 		return nil
@@ -533,13 +763,22 @@ func (a *applierV3backend) checkRequestPut(rv mvcc.ReadView, reqOp *pb.RequestOp
 	}
 
 	req := reqOp.RequestOp_RequestPut.RequestPut
-	if req.IgnoreValue || req.IgnoreLease {
+	if req.IgnoreValue || req.IgnoreLease || req.Increment != nil || hasPutCondition(req) {
 		// expects previous key-value, error if not exist
 		rr, err := rv.Range(context.TODO(), []byte(req.Key), nil, mvcc.RangeOptions{})
 		if err != nil {
 			return err
 		}
-		if rr == nil || len(rr.KVs) == 0 {
+		// 事务中的条件需在执行前检查, 执行期间的错误会导致panic
+		if err = checkPutCondition(req, rr); err != nil {
+			return err
+		}
+		if req.Increment != nil {
+			if _, err = incrementCounter(req, rr); err != nil {
+				return err
+			}
+		}
+		if (req.IgnoreValue || req.IgnoreLease) && (rr == nil || len(rr.KVs) == 0) {
 			return ErrKeyNotFound
 		}
 	}
@@ -551,6 +790,60 @@ func (a *applierV3backend) checkRequestPut(rv mvcc.ReadView, reqOp *pb.RequestOp
 	return nil
 }
 
+// hasPutCondition 请求是否带有写入条件
+func hasPutCondition(p *pb.PutRequest) bool {
+	return p.IfAbsent || p.IfModRevision != 0 || len(p.IfValueHash) != 0
+}
+
+// checkPutCondition 根据key当前的状态检查写入条件, rr为该key的范围查询结果
+func checkPutCondition(p *pb.PutRequest, rr *mvcc.RangeResult) error {
+	if !hasPutCondition(p) {
+		return nil
+	}
+	var cur *mvccpb.KeyValue
+	if rr != nil && len(rr.KVs) != 0 {
+		cur = &rr.KVs[0]
+	}
+	if p.IfAbsent && cur != nil {
+		return ErrKeyExists
+	}
+	if p.IfModRevision != 0 && (cur == nil || cur.ModRevision != p.IfModRevision) {
+		return ErrModRevisionMismatch
+	}
+	if len(p.IfValueHash) != 0 {
+		if cur == nil {
+			return ErrValueHashMismatch
+		}
+		sum := sha256.Sum256([]byte(cur.Value))
+		if hex.EncodeToString(sum[:]) != strings.ToLower(p.IfValueHash) {
+			return ErrValueHashMismatch
+		}
+	}
+	return nil
+}
+
+// incrementCounter 计算计数器加上增量后的值, 不存在的key视为0
+func incrementCounter(p *pb.PutRequest, rr *mvcc.RangeResult) (int64, error) {
+	var cur int64
+	if rr != nil && len(rr.KVs) != 0 {
+		var ok bool
+		if cur, ok = parseNumber(rr.KVs[0].Value); !ok {
+			return 0, ErrValueNotNumber
+		}
+	}
+	d := p.Increment.Delta
+	if (d > 0 && cur > math.MaxInt64-d) || (d < 0 && cur < math.MinInt64-d) {
+		return 0, ErrCounterOverflow
+	}
+	return cur + d, nil
+}
+
+// parseNumber 按计数器的编码(十进制 int64)解析值
+func parseNumber(v string) (int64, bool) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	return n, err == nil
+}
+
 func (a *applierV3backend) checkRequestRange(rv mvcc.ReadView, reqOp *pb.RequestOp) error {
 	if reqOp.RequestOp_RequestRange == nil {
 		return nil
@@ -798,6 +1091,8 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 			a.s.applyV3 = newApplierV3Corrupt(a)
 		case pb.AlarmType_NOSPACE:
 			a.s.applyV3 = newApplierV3Capped(a)
+		case pb.AlarmType_SLOWDISK, pb.AlarmType_CLOCKSKEW, pb.AlarmType_APPLYLAG:
+			// 只用于提示, 不限制请求
 		default:
 			lg.Panic("未实现的警报", zap.String("alarm", fmt.Sprintf("%+v", m)))
 		}
@@ -816,6 +1111,8 @@ func (a *applierV3backend) Alarm(ar *pb.AlarmRequest) (*pb.AlarmResponse, error)
 		case pb.AlarmType_NOSPACE, pb.AlarmType_CORRUPT:
 			lg.Warn("警报解除", zap.String("alarm", m.Alarm.String()), zap.String("from", types.ID(m.MemberID).String()))
 			a.s.applyV3 = a.s.newApplierV3()
+		case pb.AlarmType_SLOWDISK, pb.AlarmType_CLOCKSKEW, pb.AlarmType_APPLYLAG:
+			lg.Info("警报解除", zap.String("alarm", m.Alarm.String()), zap.String("from", types.ID(m.MemberID).String()))
 		default:
 			lg.Warn("未实现的警报解除类型", zap.String("alarm", fmt.Sprintf("%+v", m)))
 		}
@@ -877,6 +1174,58 @@ func (a *applierV3backend) RoleRevokePermission(r *pb.AuthRoleRevokePermissionRe
 	return resp, err
 }
 
+func (a *applierV3backend) UserTokenCreate(r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	resp, err := a.s.AuthStore().UserTokenCreate(r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) UserTokenList(r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	resp, err := a.s.AuthStore().UserTokenList(r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) UserTokenRevoke(r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	resp, err := a.s.AuthStore().UserTokenRevoke(r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) AuthTokenPurge(r *pb.InternalAuthTokenPurgeRequest) (*pb.EmptyResponse, error) {
+	return a.s.AuthStore().PurgeExpiredScopedTokens(r)
+}
+
+func (a *applierV3backend) AuthCheck(authInfo *auth.AuthInfo, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	resp, err := a.s.AuthStore().AuthCheck(authInfo, r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) UserSetRateLimit(r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error) {
+	resp, err := a.s.AuthStore().UserSetRateLimit(r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
+func (a *applierV3backend) RoleSetRateLimit(r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error) {
+	resp, err := a.s.AuthStore().RoleSetRateLimit(r)
+	if resp != nil {
+		resp.Header = newHeader(a.s)
+	}
+	return resp, err
+}
+
 func (a *applierV3backend) UserAdd(r *pb.AuthUserAddRequest) (*pb.AuthUserAddResponse, error) {
 	resp, err := a.s.AuthStore().UserAdd(r)
 	if resp != nil {
@@ -0,0 +1,231 @@
+// Copyright 2021 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/ls-2018/etcd_cn/etcd/lease"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	betesting "github.com/ls-2018/etcd_cn/etcd/mvcc/backend/testing"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestApplierV3(t *testing.T) *applierV3backend {
+	lg := zaptest.NewLogger(t)
+	be, _ := betesting.NewDefaultTmpBackend(t)
+	kv := mvcc.New(lg, be, &lease.FakeLessor{}, mvcc.StoreConfig{})
+	t.Cleanup(func() {
+		kv.Close()
+		betesting.Close(t, be)
+	})
+	s := &EtcdServer{lgMu: new(sync.RWMutex), lg: lg, kv: kv, lessor: &lease.FakeLessor{}}
+	return s.newApplierV3Backend().(*applierV3backend)
+}
+
+func putOp(p *pb.PutRequest) *pb.RequestOp {
+	return &pb.RequestOp{RequestOp_RequestPut: &pb.RequestOp_RequestPut{RequestPut: p}}
+}
+
+func rangeOp(key string) *pb.RequestOp {
+	return &pb.RequestOp{RequestOp_RequestRange: &pb.RequestOp_RequestRange{RequestRange: &pb.RangeRequest{Key: key}}}
+}
+
+func deleteOp(key, end string) *pb.RequestOp {
+	return &pb.RequestOp{RequestOp_RequestDeleteRange: &pb.RequestOp_RequestDeleteRange{RequestDeleteRange: &pb.DeleteRangeRequest{Key: key, RangeEnd: end}}}
+}
+
+func txnOp(success ...*pb.RequestOp) *pb.RequestOp {
+	return &pb.RequestOp{RequestOp_RequestTxn: &pb.RequestOp_RequestTxn{RequestTxn: &pb.TxnRequest{Success: success}}}
+}
+
+// TestApplyTxnWriteThenConditionalPut 条件写入的key已被同一分支之前的操作修改时, 事务在执行前被拒绝而不是在执行中panic
+func TestApplyTxnWriteThenConditionalPut(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bool
+		ops      []*pb.RequestOp
+	}{
+		{
+			"put then if-absent",
+			false,
+			[]*pb.RequestOp{putOp(&pb.PutRequest{Key: "k", Value: "a"}), putOp(&pb.PutRequest{Key: "k", Value: "b", IfAbsent: true})},
+		},
+		{
+			"delete then if-mod-revision",
+			true,
+			[]*pb.RequestOp{deleteOp("j", "l"), putOp(&pb.PutRequest{Key: "k", Value: "b", IfModRevision: 2})},
+		},
+		{
+			"nested put then if-absent",
+			false,
+			[]*pb.RequestOp{txnOp(putOp(&pb.PutRequest{Key: "k", Value: "a"})), putOp(&pb.PutRequest{Key: "k", Value: "b", IfAbsent: true})},
+		},
+		{
+			"delete from key then ignore-value",
+			true,
+			[]*pb.RequestOp{deleteOp("a", "\x00"), txnOp(putOp(&pb.PutRequest{Key: "k", IgnoreValue: true}))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApplierV3(t)
+			if tt.existing {
+				if _, _, err := a.Put(context.TODO(), nil, &pb.PutRequest{Key: "k", Value: "v"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: tt.ops}); err != ErrKeyWrittenInTxn {
+				t.Fatalf("expected %v, got %v", ErrKeyWrittenInTxn, err)
+			}
+		})
+	}
+}
+
+// TestApplyTxnConditionalPut 分支中未被修改的key的条件在执行前检查, 执行时不再检查
+func TestApplyTxnConditionalPut(t *testing.T) {
+	a := newTestApplierV3(t)
+	resp, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{
+		putOp(&pb.PutRequest{Key: "a", Value: "1"}),
+		putOp(&pb.PutRequest{Key: "k", Value: "v", IfAbsent: true}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Revision != 2 {
+		t.Fatalf("expected revision 2, got %d", resp.Header.Revision)
+	}
+	_, _, err = a.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{
+		putOp(&pb.PutRequest{Key: "k", Value: "w", IfAbsent: true}),
+	}})
+	if err != ErrKeyExists {
+		t.Fatalf("expected %v, got %v", ErrKeyExists, err)
+	}
+}
+
+// TestApplyTxnWriteThenIncrement 自增的key已被同一分支之前的操作修改时, 事务在执行前被拒绝
+func TestApplyTxnWriteThenIncrement(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []*pb.RequestOp
+	}{
+		{
+			"put non-number then increment",
+			[]*pb.RequestOp{putOp(&pb.PutRequest{Key: "k", Value: "abc"}), putOp(&pb.PutRequest{Key: "k", Increment: &pb.Increment{Delta: 1}})},
+		},
+		{
+			"increment twice past max",
+			[]*pb.RequestOp{
+				putOp(&pb.PutRequest{Key: "k", Increment: &pb.Increment{Delta: math.MaxInt64}}),
+				txnOp(putOp(&pb.PutRequest{Key: "k", Increment: &pb.Increment{Delta: 1}})),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestApplierV3(t)
+			if _, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: tt.ops}); err != ErrKeyWrittenInTxn {
+				t.Fatalf("expected %v, got %v", ErrKeyWrittenInTxn, err)
+			}
+		})
+	}
+}
+
+// TestApplyTxnIncrement 同一分支中不同key的自增在执行前按事务开始时的值检查
+func TestApplyTxnIncrement(t *testing.T) {
+	a := newTestApplierV3(t)
+	if _, _, err := a.Put(context.TODO(), nil, &pb.PutRequest{Key: "b", Value: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{
+		putOp(&pb.PutRequest{Key: "a", Value: "1"}),
+		putOp(&pb.PutRequest{Key: "k", Increment: &pb.Increment{Delta: 5}}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := resp.Responses[1].ResponseOp_ResponsePut.ResponsePut.Counter; c != 5 {
+		t.Fatalf("expected counter 5, got %d", c)
+	}
+	_, _, err = a.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{
+		putOp(&pb.PutRequest{Key: "b", Increment: &pb.Increment{Delta: 1}}),
+	}})
+	if err != ErrValueNotNumber {
+		t.Fatalf("expected %v, got %v", ErrValueNotNumber, err)
+	}
+}
+
+func valueRefPutOp(key string, opIndex int64, opts ...func(*pb.PutRequest)) *pb.RequestOp {
+	p := &pb.PutRequest{Key: key, ValueRef: &pb.ValueRef{OpIndex: opIndex}}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return putOp(p)
+}
+
+// TestApplyTxnValueRefChain 同一分支中先读后写的链: value_ref引用执行到该操作时的range结果,
+// 之后对同一key的条件写入或自增在执行前被拒绝
+func TestApplyTxnValueRefChain(t *testing.T) {
+	a := newTestApplierV3(t)
+	resp, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: []*pb.RequestOp{
+		putOp(&pb.PutRequest{Key: "a", Value: "1"}),
+		rangeOp("a"),
+		valueRefPutOp("b", 1),
+		rangeOp("b"),
+		valueRefPutOp("c", 3, func(p *pb.PutRequest) { p.IfAbsent = true }),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kvs := resp.Responses[3].ResponseOp_ResponseRange.ResponseRange.Kvs; len(kvs) != 1 || kvs[0].Value != "1" {
+		t.Fatalf("expected b=1 in the same branch, got %v", kvs)
+	}
+	rr, err := a.s.KV().Range(context.TODO(), []byte("c"), nil, mvcc.RangeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rr.KVs) != 1 || rr.KVs[0].Value != "1" {
+		t.Fatalf("expected c=1, got %v", rr.KVs)
+	}
+
+	tests := []struct {
+		name string
+		ops  []*pb.RequestOp
+	}{
+		{
+			"value-ref then if-absent",
+			[]*pb.RequestOp{rangeOp("a"), valueRefPutOp("k", 0), putOp(&pb.PutRequest{Key: "k", Value: "v", IfAbsent: true})},
+		},
+		{
+			"skipped value-ref then increment",
+			[]*pb.RequestOp{rangeOp("missing"), valueRefPutOp("k", 0), txnOp(putOp(&pb.PutRequest{Key: "k", Increment: &pb.Increment{Delta: 1}}))},
+		},
+		{
+			"write then conditional value-ref",
+			[]*pb.RequestOp{putOp(&pb.PutRequest{Key: "k", Value: "v"}), rangeOp("a"), valueRefPutOp("k", 1, func(p *pb.PutRequest) { p.IfAbsent = true })},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := a.Txn(context.TODO(), &pb.TxnRequest{Success: tt.ops}); err != ErrKeyWrittenInTxn {
+				t.Fatalf("expected %v, got %v", ErrKeyWrittenInTxn, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package etcdserver
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
+	"github.com/ls-2018/etcd_cn/etcd/etcdserver/api/v2http/httptypes"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"github.com/ls-2018/etcd_cn/raft/raftpb"
+	"go.uber.org/zap"
+)
+
+// 损坏成员自动修复流程:
+//  1. leader 发现某成员有 CORRUPT 警报; 若损坏的是 leader 自己, 先让出 leader, 由新 leader 修复
+//  2. checkHashKV 只拿 follower 与 leader 比较, 无法区分是谁损坏; 修复前确认多数投票成员的哈希与 leader 一致,
+//     否则拒绝修复并对 leader 自己发出 CORRUPT 警报, 转到步骤 1
+//  3. leader 在 apply 协程中按当前已应用的状态生成合并快照, 经 sendMergedSnap 发给损坏的成员
+//  4. 损坏的成员不把该快照交给 raft, 而是在 apply 协程中丢弃原有后端, 换成快照中的后端;
+//     raft 日志与 v2store 不变, 快照之后的日志按一致性索引正常应用
+//  5. leader 确认该成员的哈希与自己一致后解除警报, 成员重新加入服务
+//
+// 替换后端之前不把损坏的成员降为 learner:
+//   - 损坏的只是 mvcc 后端, 成员投票和确认日志只依赖 raft 日志, 替换后端也不触碰 raft 日志, 它继续投票不会影响一致性;
+//   - CORRUPT 警报期间所有成员都使用 applierV3Corrupt 拒绝读写, 损坏的数据不会被客户端读到;
+//   - 降级要走成员变更, 修复期间集群少一个投票成员, 3 节点集群再坏一个就失去多数; membership 也不支持把已有成员改为 learner.
+//
+// 因此 repairEventDemoted 记录的是损坏的 leader 让出 leader 身份.
+const (
+	corruptRepairInterval = 5 * time.Second
+	// corruptRepairTimeout 发送修复快照后等待成员哈希一致的时间, 超时后重新发送
+	corruptRepairTimeout = time.Minute
+)
+
+// repairSnapshotContext 标记 leader 为修复损坏的成员而主动发送的快照
+var repairSnapshotContext = []byte("corrupt-repair")
+
+// 修复过程中记录的事件
+const (
+	repairEventDemoted         = "demoted"
+	repairEventSnapshotSent    = "snapshot_sent"
+	repairEventSnapshotRefused = "snapshot_refused"
+	repairEventSourceSuspected = "source_suspected"
+	repairEventBackendReplaced = "backend_replaced"
+	repairEventTimedOut        = "timed_out"
+	repairEventRejoined        = "rejoined"
+	repairEventAlarmCleared    = "alarm_cleared"
+)
+
+func (s *EtcdServer) recordRepairEvent(event string, member types.ID, fields ...zap.Field) {
+	corruptRepairEventsTotal.WithLabelValues(event).Inc()
+	s.Logger().Info(
+		"corrupt repair event",
+		append([]zap.Field{
+			zap.String("event", event),
+			zap.String("local-member-id", s.ID().String()),
+			zap.String("corrupted-member-id", member.String()),
+		}, fields...)...,
+	)
+}
+
+// monitorCorruptRepair leader 逐个修复有 CORRUPT 警报的成员
+func (s *EtcdServer) monitorCorruptRepair() {
+	if !s.Cfg.CorruptAutoRepair {
+		return
+	}
+	lg := s.Logger()
+	lg.Info("启用损坏成员自动修复", zap.String("local-member-id", s.ID().String()))
+
+	var (
+		repairing types.ID // 已发送修复快照, 等待哈希一致的成员
+		sentAt    time.Time
+	)
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(corruptRepairInterval):
+		}
+		if !s.isLeader() {
+			repairing = 0
+			continue
+		}
+		id, ok := s.corruptedMember()
+		if !ok {
+			repairing = 0
+			continue
+		}
+
+		if id == s.ID() {
+			s.recordRepairEvent(repairEventDemoted, id)
+			if err := s.TransferLeadership(); err != nil {
+				lg.Warn("failed to transfer leadership away from corrupted member", zap.Error(err))
+			}
+			continue
+		}
+
+		matched, err := s.peerHashMatches()
+		if err != nil {
+			lg.Warn("failed to check hash of corrupted member", zap.String("corrupted-member-id", id.String()), zap.Error(err))
+			continue
+		}
+		if matched[id] {
+			s.recordRepairEvent(repairEventRejoined, id)
+			if err := s.clearCorruptAlarm(id); err != nil {
+				lg.Warn("failed to clear corrupt alarm", zap.String("corrupted-member-id", id.String()), zap.Error(err))
+				continue
+			}
+			s.recordRepairEvent(repairEventAlarmCleared, id)
+			repairing = 0
+			continue
+		}
+
+		if repairing == id {
+			if time.Since(sentAt) < corruptRepairTimeout {
+				continue
+			}
+			s.recordRepairEvent(repairEventTimedOut, id, zap.Duration("since-snapshot-sent", time.Since(sentAt)))
+		}
+
+		// 修复快照总是来自 leader, 多数投票成员与 leader 一致才能确认损坏的不是 leader 自己.
+		// 快照在稍后的已应用索引上生成, 之后应用的日志在各成员上相同, 不影响这里的结论.
+		if agreed, quorum := s.votersMatched(matched), len(s.cluster.VotingMembers())/2+1; agreed < quorum {
+			s.recordRepairEvent(repairEventSourceSuspected, id, zap.Int("agreed-voters", agreed), zap.Int("quorum", quorum))
+			if err := s.raiseCorruptAlarm(s.ID()); err != nil {
+				lg.Warn("failed to raise corrupt alarm against leader", zap.Error(err))
+			}
+			repairing = 0
+			continue
+		}
+		select {
+		case s.repairc <- id:
+			repairing, sentAt = id, time.Now()
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// corruptedMember 返回第一个有 CORRUPT 警报的成员; leader 自己有警报时优先返回自己, 先让出 leader
+func (s *EtcdServer) corruptedMember() (types.ID, bool) {
+	if s.hasAlarm(pb.AlarmType_CORRUPT, s.ID()) {
+		return s.ID(), true
+	}
+	for _, a := range s.Alarms() {
+		if a.Alarm == pb.AlarmType_CORRUPT {
+			return types.ID(a.MemberID), true
+		}
+	}
+	return 0, false
+}
+
+// peerHashMatches 各成员在当前修订版本上的哈希是否与本地一致; 取不到哈希的成员视为不一致
+func (s *EtcdServer) peerHashMatches() (map[types.ID]bool, error) {
+	h, rev, crev, err := s.kv.HashByRev(0)
+	if err != nil {
+		return nil, err
+	}
+	matched := map[types.ID]bool{s.ID(): true}
+	for _, p := range s.getPeerHashKVs(rev) {
+		if p.resp == nil {
+			continue
+		}
+		matched[p.id] = p.resp.CompactRevision == crev && p.resp.Hash == h
+	}
+	return matched, nil
+}
+
+// votersMatched 哈希与本地一致的投票成员数, 包括本成员
+func (s *EtcdServer) votersMatched(matched map[types.ID]bool) int {
+	n := 0
+	for _, m := range s.cluster.VotingMembers() {
+		if matched[m.ID] {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *EtcdServer) raiseCorruptAlarm(id types.ID) error {
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	defer cancel()
+	_, err := s.raftRequest(ctx, pb.InternalRaftRequest{Alarm: &pb.AlarmRequest{
+		MemberID: uint64(id),
+		Action:   pb.AlarmRequest_ACTIVATE,
+		Alarm:    pb.AlarmType_CORRUPT,
+	}})
+	return err
+}
+
+func (s *EtcdServer) clearCorruptAlarm(id types.ID) error {
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	defer cancel()
+	_, err := s.raftRequest(ctx, pb.InternalRaftRequest{Alarm: &pb.AlarmRequest{
+		MemberID: uint64(id),
+		Action:   pb.AlarmRequest_DEACTIVATE,
+		Alarm:    pb.AlarmType_CORRUPT,
+	}})
+	return err
+}
+
+// sendRepairSnapshot 在 apply 协程中按已应用的状态生成合并快照, 发给损坏的成员
+func (s *EtcdServer) sendRepairSnapshot(ep *etcdProgress, id types.ID) {
+	m := raftpb.Message{
+		Type:    raftpb.MsgSnap,
+		To:      uint64(id),
+		From:    uint64(s.ID()),
+		Term:    s.Term(),
+		Context: repairSnapshotContext,
+	}
+	merged := s.createMergedSnapshotMessage(m, ep.appliedt, ep.appliedi, ep.confState)
+	s.sendMergedSnap(merged)
+	s.recordRepairEvent(repairEventSnapshotSent, id, zap.Uint64("snapshot-index", ep.appliedi))
+}
+
+// receiveRepairSnapshot 只接受当前 leader 发来的、针对本成员 CORRUPT 警报的修复快照
+func (s *EtcdServer) receiveRepairSnapshot(m raftpb.Message) error {
+	var reason string
+	switch {
+	case !s.Cfg.CorruptAutoRepair:
+		reason = "损坏成员自动修复未启用"
+	case m.From != s.Lead():
+		reason = "修复快照不是来自当前leader"
+	case !s.hasAlarm(pb.AlarmType_CORRUPT, s.ID()):
+		reason = "本成员没有损坏警报"
+	default:
+		select {
+		case s.repairSnapc <- m.Snapshot:
+			return nil
+		default:
+			reason = "已有修复快照正在处理"
+		}
+	}
+	s.recordRepairEvent(repairEventSnapshotRefused, s.ID(), zap.String("reason", reason))
+	s.removeRepairSnapshotDB(m.Snapshot.Metadata.Index)
+	return httptypes.NewHTTPError(http.StatusPreconditionFailed, reason)
+}
+
+// applyRepairSnapshot 在 apply 协程中丢弃本成员损坏的后端, 换成修复快照中的后端
+func (s *EtcdServer) applyRepairSnapshot(ep *etcdProgress, snapshot raftpb.Snapshot) {
+	lg := s.Logger()
+	index := snapshot.Metadata.Index
+	// 已应用的日志无法回退, 等 leader 用更新的快照重试; 快照已包含的未应用日志在新后端上会按一致性索引跳过
+	if ep.appliedi > index {
+		s.recordRepairEvent(repairEventSnapshotRefused, s.ID(),
+			zap.String("reason", "修复快照比已应用的日志旧"),
+			zap.Uint64("snapshot-index", index),
+			zap.Uint64("applied-index", ep.appliedi),
+		)
+		s.removeRepairSnapshotDB(index)
+		return
+	}
+
+	newbe, err := openSnapshotBackend(s.Cfg, s.snapshotter, snapshot, s.beHooks)
+	if err != nil {
+		lg.Warn("failed to open repair snapshot backend", zap.Uint64("snapshot-index", index), zap.Error(err))
+		s.removeRepairSnapshotDB(index)
+		return
+	}
+	s.recoverV3Backend(newbe)
+	s.cluster.SetBackend(newbe)
+	s.recordRepairEvent(repairEventBackendReplaced, s.ID(),
+		zap.Uint64("snapshot-index", index),
+		zap.Uint64("applied-index", ep.appliedi),
+	)
+}
+
+func (s *EtcdServer) removeRepairSnapshotDB(index uint64) {
+	if fn, err := s.snapshotter.DBFilePath(index); err == nil {
+		os.Remove(fn)
+	}
+}
@@ -34,9 +34,16 @@ var (
 	ErrNotLeader                     = errors.New("etcdserver: 不是leader")
 	ErrRequestTooLarge               = errors.New("etcdserver: 请求太多")
 	ErrNoSpace                       = errors.New("etcdserver: 没有空间")
+	ErrReadOnly                      = errors.New("etcdserver: 集群处于只读维护模式")
 	ErrTooManyRequests               = errors.New("etcdserver: 太多的请求")
 	ErrUnhealthy                     = errors.New("etcdserver: 集群不健康")
 	ErrKeyNotFound                   = errors.New("etcdserver: key没找到")
+	ErrKeyExists                     = errors.New("etcdserver: key已存在")
+	ErrKeyWrittenInTxn               = errors.New("etcdserver: key已被同一事务分支中之前的操作修改")
+	ErrModRevisionMismatch           = errors.New("etcdserver: key的mod_revision与条件不符")
+	ErrValueHashMismatch             = errors.New("etcdserver: key的值哈希与条件不符")
+	ErrValueNotNumber                = errors.New("etcdserver: key的值不是十进制整数")
+	ErrCounterOverflow               = errors.New("etcdserver: 计数器溢出")
 	ErrCorrupt                       = errors.New("etcdserver: 损坏的集群")
 	ErrBadLeaderTransferee           = errors.New("etcdserver: bad leader transferee")
 	ErrClusterVersionUnavailable     = errors.New("etcdserver: cluster version not found during downgrade")
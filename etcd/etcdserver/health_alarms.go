@@ -0,0 +1,115 @@
+package etcdserver
+
+import (
+	"context"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
+	"github.com/ls-2018/etcd_cn/etcd/wal"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"go.uber.org/zap"
+)
+
+const (
+	healthAlarmInterval = 10 * time.Second
+	// minFsyncSamples 样本太少时 p99 近似于最大值, 不据此触发 SLOWDISK 警报
+	minFsyncSamples = 20
+)
+
+// monitorHealthAlarms 按配置的阈值检查本成员的磁盘、时钟与应用进度, 超过阈值时触发对应警报, 恢复后解除
+func (s *EtcdServer) monitorHealthAlarms() {
+	if s.Cfg.SlowDiskAlarmFsyncP99 == 0 && s.Cfg.ClockSkewAlarmThreshold == 0 && s.Cfg.ApplyLagAlarmEntries == 0 {
+		return
+	}
+	lg := s.Logger()
+	lg.Info(
+		"启用健康警报检查",
+		zap.String("local-member-id", s.ID().String()),
+		zap.Duration("slow-disk-alarm-fsync-p99", s.Cfg.SlowDiskAlarmFsyncP99),
+		zap.Duration("clock-skew-alarm-threshold", s.Cfg.ClockSkewAlarmThreshold),
+		zap.Uint64("apply-lag-alarm-entries", s.Cfg.ApplyLagAlarmEntries),
+	)
+
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-time.After(healthAlarmInterval):
+		}
+
+		if threshold := s.Cfg.SlowDiskAlarmFsyncP99; threshold > 0 {
+			p99, n := wal.RecentFsyncP99()
+			s.setHealthAlarm(pb.AlarmType_SLOWDISK, n >= minFsyncSamples && p99 > threshold,
+				zap.Duration("wal-fsync-p99", p99),
+				zap.Int("samples", n),
+				zap.Duration("threshold", threshold),
+			)
+		}
+
+		if threshold := s.Cfg.ClockSkewAlarmThreshold; threshold > 0 {
+			var (
+				peer types.ID
+				skew time.Duration
+			)
+			for id, d := range s.r.transport.ClockDiffs() {
+				if d < 0 {
+					d = -d
+				}
+				if d > skew {
+					peer, skew = id, d
+				}
+			}
+			s.setHealthAlarm(pb.AlarmType_CLOCKSKEW, skew > threshold,
+				zap.String("remote-peer-id", peer.String()),
+				zap.Duration("clock-skew", skew),
+				zap.Duration("threshold", threshold),
+			)
+		}
+
+		if threshold := s.Cfg.ApplyLagAlarmEntries; threshold > 0 {
+			ci, ai := s.getCommittedIndex(), s.getAppliedIndex()
+			s.setHealthAlarm(pb.AlarmType_APPLYLAG, ci > ai && ci-ai > threshold,
+				zap.Uint64("committed-index", ci),
+				zap.Uint64("applied-index", ai),
+				zap.Uint64("threshold", threshold),
+			)
+		}
+	}
+}
+
+// setHealthAlarm 当本成员的警报状态与检查结果不一致时, 通过raft触发或解除警报
+func (s *EtcdServer) setHealthAlarm(at pb.AlarmType, firing bool, fields ...zap.Field) {
+	if firing == s.hasAlarm(at, s.ID()) {
+		return
+	}
+	lg := s.Logger()
+	fields = append([]zap.Field{zap.String("local-member-id", s.ID().String()), zap.String("alarm", at.String())}, fields...)
+	action := pb.AlarmRequest_DEACTIVATE
+	if firing {
+		action = pb.AlarmRequest_ACTIVATE
+		lg.Warn("health check exceeded threshold; raising alarm", fields...)
+	} else {
+		lg.Info("health check recovered; clearing alarm", fields...)
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, s.Cfg.ReqTimeout())
+	_, err := s.raftRequest(ctx, pb.InternalRaftRequest{Alarm: &pb.AlarmRequest{
+		MemberID: uint64(s.ID()),
+		Action:   action,
+		Alarm:    at,
+	}})
+	cancel()
+	if err != nil {
+		lg.Warn("failed to update alarm", append(fields, zap.Error(err))...)
+	}
+}
+
+// hasAlarm 成员是否有指定类型的警报
+func (s *EtcdServer) hasAlarm(at pb.AlarmType, id types.ID) bool {
+	for _, a := range s.Alarms() {
+		if a.Alarm == at && types.ID(a.MemberID) == id {
+			return true
+		}
+	}
+	return false
+}
@@ -50,6 +50,7 @@ func (s *EtcdServer) CheckInitialHashKV() error {
 				if crev == p.resp.CompactRevision {
 					lg.Warn("found different hash values from remote peer", fields...)
 					mismatch++
+					s.pinpointCorruption(p.peerInfo, rev)
 				} else {
 					lg.Warn("found different compact revision values from remote peer", fields...)
 				}
@@ -211,6 +212,7 @@ func (s *EtcdServer) checkHashKV() error {
 				zap.String("follower-peer-id", types.ID(id).String()),
 			)
 			mismatch(id)
+			s.pinpointCorruption(p.peerInfo, rev)
 		}
 	}
 	lg.Info("finished peer corruption check", zap.Int("number-of-peers-checked", checkedCount))
@@ -0,0 +1,154 @@
+package etcdserver
+
+import (
+	"context"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"github.com/ls-2018/etcd_cn/pkg/hashtree"
+	"go.uber.org/zap"
+)
+
+// maxHashKVSplits 限制每个范围返回的切分点数量, 避免响应过大
+const maxHashKVSplits = 256
+
+// HashKVRanges 处理设置了 ranges 的 HashKV 请求: 计算各key范围在请求的修订版本时状态的哈希
+func HashKVRanges(kv mvcc.KV, r *pb.HashKVRequest) (*pb.HashKVResponse, error) {
+	splits := int(r.Splits)
+	if splits > maxHashKVSplits {
+		splits = maxHashKVSplits
+	}
+	hashes, rev, compactRev, err := kv.HashRanges(r.Revision, rangesFromPb(r.Ranges), splits)
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.HashKVResponse{
+		Header:          &pb.ResponseHeader{Revision: rev},
+		CompactRevision: compactRev,
+		RangeHashes:     make([]*pb.KeyRangeHash, len(hashes)),
+	}
+	for i, h := range hashes {
+		resp.RangeHashes[i] = &pb.KeyRangeHash{
+			Key:            h.Key,
+			RangeEnd:       hashtree.RangeEndToPb(h.End),
+			Hash:           h.Hash,
+			Count:          h.Count,
+			MaxModRevision: h.MaxModRevision,
+			FirstKey:       h.FirstKey,
+			LastKey:        h.LastKey,
+			SplitKeys:      h.SplitKeys,
+		}
+	}
+	return resp, nil
+}
+
+// rangesFromPb 转换请求中的key范围; range_end 为 "\x00" 表示不限上界, 为空表示单个key
+func rangesFromPb(ranges []*pb.KeyRange) []hashtree.Range {
+	rs := make([]hashtree.Range, len(ranges))
+	for i, r := range ranges {
+		rs[i] = hashtree.Range{Key: r.Key, End: r.RangeEnd}
+		switch r.RangeEnd {
+		case "\x00":
+			rs[i].End = ""
+		case "":
+			rs[i].End = r.Key + "\x00"
+		}
+	}
+	return rs
+}
+
+// pinpointCorruption 与哈希不一致的成员逐层比较各key范围在rev时的哈希, 记录无法再细分的不一致范围
+func (s *EtcdServer) pinpointCorruption(p peerInfo, rev int64) {
+	lg := s.Logger()
+
+	local := func(ranges []hashtree.Range, splits int) ([]hashtree.Hash, error) {
+		hashes, _, _, err := s.kv.HashRanges(rev, ranges, splits)
+		return hashes, err
+	}
+	remote := func(ranges []hashtree.Range, splits int) ([]hashtree.Hash, error) {
+		req := &pb.HashKVRequest{Revision: rev, Ranges: hashtree.RangesToPb(ranges), Splits: int64(splits)}
+		var err error
+		for _, ep := range p.eps {
+			ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.ReqTimeout())
+			var resp *pb.HashKVResponse
+			resp, err = s.peerHashKVHTTP(ctx, ep, req)
+			cancel()
+			if err == nil {
+				return hashtree.HashesFromPb(resp.RangeHashes), nil
+			}
+		}
+		return nil, err
+	}
+
+	mismatches, err := hashtree.Diff(local, remote, hashtree.DefaultConfig)
+	for _, m := range mismatches {
+		lg.Warn(
+			"found key range mismatch",
+			zap.String("local-member-id", s.ID().String()),
+			zap.String("remote-peer-id", p.id.String()),
+			zap.Int64("revision", rev),
+			zap.String("range-begin", m.Key),
+			zap.String("range-end", m.End),
+			zap.Int64("local-count", m.Local.Count),
+			zap.String("local-first-key", m.Local.FirstKey),
+			zap.String("local-last-key", m.Local.LastKey),
+			zap.Int64("local-max-mod-revision", m.Local.MaxModRevision),
+			zap.Uint32("local-hash", m.Local.Hash),
+			zap.Int64("remote-count", m.Remote.Count),
+			zap.String("remote-first-key", m.Remote.FirstKey),
+			zap.String("remote-last-key", m.Remote.LastKey),
+			zap.Int64("remote-max-mod-revision", m.Remote.MaxModRevision),
+			zap.Uint32("remote-hash", m.Remote.Hash),
+		)
+	}
+	if err != nil {
+		lg.Warn(
+			"failed to pinpoint key range mismatch",
+			zap.String("remote-peer-id", p.id.String()),
+			zap.Int64("revision", rev),
+			zap.Int("number-of-mismatches-found", len(mismatches)),
+			zap.Error(err),
+		)
+	}
+}
+
+// notifyCompacted 压缩落盘后通知 monitorCompactHash
+func (s *EtcdServer) notifyCompacted(physc <-chan struct{}) {
+	if !s.Cfg.CompactHashCheckEnabled || physc == nil {
+		return
+	}
+	s.GoAttach(func() {
+		select {
+		case <-physc:
+		case <-s.stopping:
+			return
+		}
+		select {
+		case s.compactedc <- struct{}{}:
+		default:
+		}
+	})
+}
+
+// monitorCompactHash 每次压缩完成后由leader检查一次数据是否一致.
+// 所有成员按同一修订版本压缩, 压缩后立即检查时各成员的压缩修订版本相同, 哈希可以直接比较.
+func (s *EtcdServer) monitorCompactHash() {
+	if !s.Cfg.CompactHashCheckEnabled {
+		return
+	}
+	lg := s.Logger()
+	lg.Info("启用压缩后的损坏检查", zap.String("local-member-id", s.ID().String()))
+	for {
+		select {
+		case <-s.stopping:
+			return
+		case <-s.compactedc:
+		}
+		if !s.isLeader() {
+			continue
+		}
+		if err := s.checkHashKV(); err != nil {
+			lg.Warn("failed to check hash KV after compaction", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package etcdserver
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/backend"
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/buckets"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"github.com/ls-2018/etcd_cn/pkg/traceutil"
+	"go.uber.org/zap"
+)
+
+// 只读维护模式: 由运维通过 Maintenance RPC 开启/关闭, 经raft复制到所有成员并持久化到后端.
+// 开启后拒绝写入key与创建租约, 删除、压缩、撤销租约照常执行, 便于迁移期间冻结集群.
+
+// MaintenanceMode 查询或切换集群的只读维护模式
+func (s *EtcdServer) MaintenanceMode(ctx context.Context, r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error) {
+	if r.Action == pb.MaintenanceModeRequest_GET {
+		if err := s.linearizeReadNotify(ctx); err != nil {
+			return nil, err
+		}
+		return &pb.MaintenanceModeResponse{Header: &pb.ResponseHeader{}, ReadOnly: s.isReadOnly()}, nil
+	}
+	resp, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{MaintenanceMode: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.MaintenanceModeResponse), nil
+}
+
+func (s *EtcdServer) isReadOnly() bool { return atomic.LoadUint32(&s.readOnly) != 0 }
+
+func (s *EtcdServer) setReadOnly(readOnly bool) {
+	var v uint32
+	if readOnly {
+		v = 1
+	}
+	atomic.StoreUint32(&s.readOnly, v)
+}
+
+// MaintenanceMode 持久化只读标记, 并按新的状态重建 applyV3
+func (a *applierV3backend) MaintenanceMode(r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error) {
+	switch r.Action {
+	case pb.MaintenanceModeRequest_ENABLE, pb.MaintenanceModeRequest_DISABLE:
+		readOnly := r.Action == pb.MaintenanceModeRequest_ENABLE
+		if readOnly != a.s.isReadOnly() {
+			saveReadOnly(a.s.Backend(), readOnly)
+			a.s.setReadOnly(readOnly)
+			a.s.applyV3 = a.s.newApplierV3WithAlarms()
+			a.s.Logger().Warn("维护模式变更", zap.Bool("read-only", readOnly))
+		}
+	}
+	return &pb.MaintenanceModeResponse{Header: newHeader(a.s), ReadOnly: a.s.isReadOnly()}, nil
+}
+
+// newApplierV3WithAlarms 在 newApplierV3 之上叠加当前警报对应的限制
+func (s *EtcdServer) newApplierV3WithAlarms() applierV3 {
+	a := s.newApplierV3()
+	if len(s.alarmStore.Get(pb.AlarmType_NOSPACE)) > 0 {
+		a = newApplierV3Capped(a)
+	}
+	if len(s.alarmStore.Get(pb.AlarmType_CORRUPT)) > 0 {
+		a = newApplierV3Corrupt(a)
+	}
+	return a
+}
+
+func saveReadOnly(be backend.Backend, readOnly bool) {
+	tx := be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+	if readOnly {
+		tx.UnsafePut(buckets.Meta, buckets.MetaReadOnlyKeyName, []byte{1})
+	} else {
+		tx.UnsafeDelete(buckets.Meta, buckets.MetaReadOnlyKeyName)
+	}
+}
+
+func loadReadOnly(be backend.Backend) bool {
+	tx := be.BatchTx()
+	tx.Lock()
+	defer tx.Unlock()
+	tx.UnsafeCreateBucket(buckets.Meta)
+	_, vs := tx.UnsafeRange(buckets.Meta, buckets.MetaReadOnlyKeyName, nil, 0)
+	return len(vs) != 0
+}
+
+type applierV3ReadOnly struct {
+	applierV3
+}
+
+func newApplierV3ReadOnly(base applierV3) applierV3 { return &applierV3ReadOnly{base} }
+
+func (a *applierV3ReadOnly) Put(ctx context.Context, txn mvcc.TxnWrite, p *pb.PutRequest) (*pb.PutResponse, *traceutil.Trace, error) {
+	return nil, nil, ErrReadOnly
+}
+
+func (a *applierV3ReadOnly) Txn(ctx context.Context, rt *pb.TxnRequest) (*pb.TxnResponse, *traceutil.Trace, error) {
+	if txnHasPut(rt) {
+		return nil, nil, ErrReadOnly
+	}
+	return a.applierV3.Txn(ctx, rt)
+}
+
+func (a *applierV3ReadOnly) LeaseGrant(lc *pb.LeaseGrantRequest) (*pb.LeaseGrantResponse, error) {
+	return nil, ErrReadOnly
+}
+
+// txnHasPut 事务的任一分支(含嵌套事务)中是否有写入操作
+func txnHasPut(r *pb.TxnRequest) bool {
+	for _, ops := range [][]*pb.RequestOp{r.Success, r.Failure} {
+		for _, op := range ops {
+			if op.GetRequestPut() != nil {
+				return true
+			}
+			if t := op.GetRequestTxn(); t != nil && txnHasPut(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdserver
+
+import (
+	"github.com/ls-2018/etcd_cn/etcd/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	readIndexDurationSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "read_index_duration_seconds",
+		Help:      "The latency distributions of confirming the read index of one batch of linearizable reads, by read mode (safe/lease).",
+
+		// lowest bucket start of upper bound 0.0001 sec (0.1 ms) with factor 2
+		// highest bucket start of 0.0001 sec * 2^15 == 3.2768 sec
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}, []string{"mode"})
+
+	linearizableReadWaitSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "linearizable_read_wait_duration_seconds",
+		Help:      "The latency distributions of a linearizable read waiting until the local state machine may serve it, by read mode (safe/lease).",
+
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}, []string{"mode"})
+
+	boundedStaleReadsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "bounded_stale_reads_total",
+		Help:      "The total number of bounded staleness reads, by how the bound was satisfied (local/read_index).",
+	}, []string{"served"})
+
+	corruptRepairEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "corrupt_repair_events_total",
+		Help:      "The total number of corrupted member repair events recorded by this member, by event.",
+	}, []string{"event"})
+
+	proposalsQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "proposals_queued",
+		Help:      "The current number of proposals waiting to be handed to raft, by priority class.",
+	}, []string{"priority"})
+
+	proposalsShedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "proposals_shed_total",
+		Help:      "The total number of proposals rejected because the queue of their priority class was full, by priority class.",
+	}, []string{"priority"})
+
+	proposalQueueDurationSec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "proposal_queue_duration_seconds",
+		Help:      "The latency distributions of proposals waiting in the queue of their priority class before being handed to raft.",
+
+		Buckets: prometheus.ExponentialBuckets(0.0001, 2, 16),
+	}, []string{"priority"})
+
+	admissionPressure = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "admission_pressure",
+		Help:      "The current admission pressure. Proposals of lower priority classes are shed first as it grows; normal proposals are all shed at 1.",
+	})
+
+	admissionSignal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "admission_signal",
+		Help:      "The current value of each admission signal normalized by its reference value, by signal (backlog/apply/commit/memory).",
+	}, []string{"signal"})
+
+	admissionRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "admission_rejected_total",
+		Help:      "The total number of proposals rejected by admission control, by priority class.",
+	}, []string{"priority"})
+
+	admissionRetryAfterSec = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcd",
+		Subsystem: "server",
+		Name:      "admission_retry_after_seconds",
+		Help:      "The retry-after hint currently returned to clients whose proposals are rejected by admission control.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(readIndexDurationSec)
+	prometheus.MustRegister(linearizableReadWaitSec)
+	prometheus.MustRegister(boundedStaleReadsTotal)
+	prometheus.MustRegister(corruptRepairEventsTotal)
+	prometheus.MustRegister(proposalsQueued)
+	prometheus.MustRegister(proposalsShedTotal)
+	prometheus.MustRegister(proposalQueueDurationSec)
+	prometheus.MustRegister(admissionPressure)
+	prometheus.MustRegister(admissionSignal)
+	prometheus.MustRegister(admissionRejectedTotal)
+	prometheus.MustRegister(admissionRetryAfterSec)
+}
+
+// readModeLabel 线性一致读模式的指标标签
+func (s *EtcdServer) readModeLabel() string {
+	if s.Cfg.LinearizableReadMode == "" {
+		return string(config.LinearizableReadDefault)
+	}
+	return string(s.Cfg.LinearizableReadMode)
+}
@@ -16,12 +16,16 @@ import (
 type notifier struct {
 	c   chan struct{}
 	err error
+
+	confirmedc chan struct{} // 读索引确认后关闭, 之后 index 有效; 出错时不会关闭
+	index      uint64
 }
 
 // 通知
 func newNotifier() *notifier {
 	return &notifier{
-		c: make(chan struct{}),
+		c:          make(chan struct{}),
+		confirmedc: make(chan struct{}),
 	}
 }
 
@@ -30,6 +34,17 @@ func (nc *notifier) notify(err error) {
 	close(nc.c)
 }
 
+func (nc *notifier) confirm(index uint64) {
+	nc.index = index
+	close(nc.confirmedc)
+}
+
+// readIndexMark 最近一次确认的读索引: 在 at 发出ReadIndex时, leader已提交的索引不小于 index
+type readIndexMark struct {
+	index uint64
+	at    time.Time
+}
+
 // 线性一致性读,保证强一致性  , 阻塞,直到applyid >= 当前生成的ID
 func (s *EtcdServer) linearizableReadLoop() {
 	for {
@@ -56,6 +71,7 @@ func (s *EtcdServer) linearizableReadLoop() {
 		// 这里会监听 readwaitc,发送MsgReadIndex 并等待 MsgReadIndexRsp
 		// 同时获取当前已提交的日志索引
 		// 串行执行的
+		start := time.Now()
 		confirmedIndex, err := s.requestCurrentIndex(leaderChangedNotifier, requestId) // MsgReadIndex 携带requestId经过raft走一圈
 		if isStopped(err) {
 			return
@@ -64,6 +80,11 @@ func (s *EtcdServer) linearizableReadLoop() {
 			nr.notify(err)
 			continue
 		}
+		readIndexDurationSec.WithLabelValues(s.readModeLabel()).Observe(time.Since(start).Seconds())
+		s.readMu.Lock()
+		s.readMark = readIndexMark{index: confirmedIndex, at: start}
+		s.readMu.Unlock()
+		nr.confirm(confirmedIndex)
 
 		trace.Step("收到要读的索引")
 		trace.AddField(traceutil.Field{Key: "readStateIndex", Value: confirmedIndex})
@@ -172,6 +193,7 @@ func (s *EtcdServer) linearizeReadNotify(ctx context.Context) error {
 	nc := s.readNotifier
 	s.readMu.RUnlock()
 
+	start := time.Now()
 	select {
 	case s.readwaitc <- struct{}{}: // linearizableReadLoop就会开始结束阻塞开始工作
 	default:
@@ -180,6 +202,9 @@ func (s *EtcdServer) linearizeReadNotify(ctx context.Context) error {
 	// 等待读状态通知
 	select {
 	case <-nc.c:
+		if nc.err == nil {
+			linearizableReadWaitSec.WithLabelValues(s.readModeLabel()).Observe(time.Since(start).Seconds())
+		}
 		return nc.err
 	case <-ctx.Done():
 		return ctx.Err()
@@ -187,3 +212,52 @@ func (s *EtcdServer) linearizeReadNotify(ctx context.Context) error {
 		return ErrStopped
 	}
 }
+
+// boundedStaleReadNotify 等待本地状态满足请求的陈旧度上限, 用于有界陈旧读.
+// 最近一次确认的读索引在 maxLag 内且已应用时直接返回; 否则发起一次ReadIndex, 只等待到上限要求的索引.
+// 每个raft日志项最多产生一个修订版本, 因此落后的日志项数不小于落后的修订版本数.
+func (s *EtcdServer) boundedStaleReadNotify(ctx context.Context, maxLag time.Duration, maxRevs int64) error {
+	s.readMu.RLock()
+	mark := s.readMark
+	nc := s.readNotifier
+	s.readMu.RUnlock()
+
+	// 修订版本的上限需要知道leader当前的位置, 只能通过ReadIndex确认
+	if maxLag > 0 && maxRevs == 0 && !mark.at.IsZero() && time.Since(mark.at) <= maxLag && s.getAppliedIndex() >= mark.index {
+		boundedStaleReadsTotal.WithLabelValues("local").Inc()
+		return nil
+	}
+	boundedStaleReadsTotal.WithLabelValues("read_index").Inc()
+
+	select {
+	case s.readwaitc <- struct{}{}:
+	default:
+	}
+	select {
+	case <-nc.confirmedc:
+	case <-nc.c:
+		if nc.err != nil {
+			return nc.err
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return ErrStopped
+	}
+
+	target := nc.index
+	if maxLag == 0 && uint64(maxRevs) < target {
+		target -= uint64(maxRevs)
+	}
+	if s.getAppliedIndex() >= target {
+		return nil
+	}
+	select {
+	case <-s.applyWait.Wait(target):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.done:
+		return ErrStopped
+	}
+}
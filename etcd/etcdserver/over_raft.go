@@ -141,6 +141,24 @@ type raftNode struct {
 }
 
 // 启动节点
+// setReadOnlyOption 按配置的线性一致读模式设置raft处理ReadIndex的方式
+func setReadOnlyOption(c *raft.Config, cfg config.ServerConfig) {
+	if cfg.LinearizableReadMode != config.LinearizableReadLease {
+		return
+	}
+	c.ReadOnlyOption = raft.ReadOnlyLeaseBased
+	c.LeaseClockDriftTicks = cfg.LeaseReadClockDriftTicks()
+	if c.LeaseClockDriftTicks >= c.ElectionTick {
+		// 向上取整后可能等于选举超时, 至少保留一个tick的租约
+		c.LeaseClockDriftTicks = c.ElectionTick - 1
+	}
+	cfg.Logger.Info(
+		"线性一致读使用leader租约",
+		zap.Int("election-ticks", c.ElectionTick),
+		zap.Int("lease-clock-drift-ticks", c.LeaseClockDriftTicks),
+	)
+}
+
 func startNode(cfg config.ServerConfig, cl *membership.RaftCluster, ids []types.ID) (id types.ID, n raft.RaftNodeInterFace, s *raft.MemoryStorage, w *wal.WAL) {
 	var err error
 	member := cl.MemberByName(cfg.Name)
@@ -183,6 +201,7 @@ func startNode(cfg config.ServerConfig, cl *membership.RaftCluster, ids []types.
 		PreVote:         cfg.PreVote,       // true      // 是否启用PreVote扩展,建议开启
 		Logger:          NewRaftLoggerZap(cfg.Logger.Named("raft")),
 	}
+	setReadOnlyOption(c, cfg)
 
 	_ = membership.NewClusterFromURLsMap
 	if len(peers) == 0 {
@@ -229,6 +248,7 @@ func restartNode(cfg config.ServerConfig, snapshot *raftpb.Snapshot) (types.ID,
 		PreVote:         cfg.PreVote, // PreVote 是否启用PreVote
 		Logger:          NewRaftLoggerZap(cfg.Logger.Named("raft")),
 	}
+	setReadOnlyOption(c, cfg)
 
 	n := raft.RestartNode(c)
 	raftStatusMu.Lock()
@@ -303,6 +323,7 @@ func restartAsStandaloneNode(cfg config.ServerConfig, snapshot *raftpb.Snapshot)
 		PreVote:         cfg.PreVote, // PreVote 是否启用PreVote
 		Logger:          NewRaftLoggerZap(cfg.Logger.Named("raft")),
 	}
+	setReadOnlyOption(c, cfg)
 
 	n := raft.RestartNode(c)
 	raftStatus = n.Status
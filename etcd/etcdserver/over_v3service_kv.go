@@ -114,7 +114,12 @@ func (s *EtcdServer) Range(ctx context.Context, r *pb.RangeRequest) (*pb.RangeRe
 	// 如果需要线性一致性读,执行 linearizableReadNotify
 	// 此处将会一直阻塞直到 apply index >= read index
 	if !r.Serializable {
-		err = s.linearizeReadNotify(ctx) // 发准备信号,并等待结果
+		if r.MaxStalenessMs > 0 || r.MaxStalenessRevisions > 0 {
+			// 有界陈旧读, 满足上限时由本地直接读取
+			err = s.boundedStaleReadNotify(ctx, time.Duration(r.MaxStalenessMs)*time.Millisecond, r.MaxStalenessRevisions)
+		} else {
+			err = s.linearizeReadNotify(ctx) // 发准备信号,并等待结果
+		}
 		trace.Step("在线性化读数之前,raft节点之间的一致.")
 		if err != nil {
 			return nil, err
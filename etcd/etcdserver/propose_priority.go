@@ -0,0 +1,185 @@
+package etcdserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"google.golang.org/grpc/metadata"
+)
+
+// 提议按优先级类别排队后再交给 raftNode.Propose, 大量低价值的写入不会拖慢租约与集群内部的提议.
+// 已提交的日志仍按raft顺序应用, 各成员的状态机必须一致, 所以优先级只作用于提议阶段.
+
+type priorityClass int
+
+const (
+	prioritySystem priorityClass = iota // 集群成员属性、集群版本、降级、警报与维护模式等内部请求
+	priorityLease                       // 租约的创建、撤销与检查点
+	priorityNormal                      // 普通的读写请求
+	priorityBulk                        // 客户端标记的批量请求
+	numPriorityClasses
+)
+
+var priorityClassNames = [numPriorityClasses]string{
+	rpctypes.MetadataPrioritySystem,
+	rpctypes.MetadataPriorityLease,
+	rpctypes.MetadataPriorityNormal,
+	rpctypes.MetadataPriorityBulk,
+}
+
+func (p priorityClass) String() string { return priorityClassNames[p] }
+
+var (
+	// priorityWeights 每一轮中各类别最多出队的提议数; bulk 每轮至少出队一个, 不会被饿死
+	priorityWeights = [numPriorityClasses]int{8, 4, 2, 1}
+	// priorityQueueLimits 各类别排队的提议数上限, 超过时拒绝新的提议
+	priorityQueueLimits = [numPriorityClasses]int{1024, 1024, 4096, 1024}
+)
+
+// requestPriority 按请求类型确定优先级; 客户端可以通过元数据降低优先级, 但不能提高
+func requestPriority(ctx context.Context, r *pb.InternalRaftRequest) priorityClass {
+	p := priorityOf(r)
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return p
+	}
+	if vs := md[rpctypes.MetadataPriorityKey]; len(vs) > 0 {
+		for c, name := range priorityClassNames {
+			if vs[0] == name && priorityClass(c) > p {
+				return priorityClass(c)
+			}
+		}
+	}
+	return p
+}
+
+// priorityOf 只有明确列出的内部请求属于 system; 客户端可以调用的请求(包括 Authenticate 与 AuthCheck)
+// 不能以最高权重占用raft
+func priorityOf(r *pb.InternalRaftRequest) priorityClass {
+	switch {
+	case r.Alarm != nil, r.ClusterMemberAttrSet != nil, r.ClusterVersionSet != nil, r.DowngradeInfoSet != nil, r.MaintenanceMode != nil:
+		return prioritySystem
+	case r.LeaseGrant != nil, r.LeaseRevoke != nil, r.LeaseCheckpoint != nil:
+		return priorityLease
+	default:
+		return priorityNormal
+	}
+}
+
+type queuedProposal struct {
+	ctx      context.Context
+	data     []byte
+	queuedAt time.Time
+	errc     chan error
+}
+
+// proposeScheduler 各优先级类别分别排队, 按权重轮流出队
+type proposeScheduler struct {
+	mu      sync.Mutex
+	queues  [numPriorityClasses][]*queuedProposal
+	credits [numPriorityClasses]int // 本轮各类别剩余可出队的提议数
+	notify  chan struct{}
+}
+
+func newProposeScheduler() *proposeScheduler {
+	return &proposeScheduler{notify: make(chan struct{}, 1)}
+}
+
+func (ps *proposeScheduler) enqueue(p priorityClass, qp *queuedProposal) bool {
+	ps.mu.Lock()
+	if len(ps.queues[p]) >= priorityQueueLimits[p] {
+		ps.mu.Unlock()
+		return false
+	}
+	ps.queues[p] = append(ps.queues[p], qp)
+	ps.mu.Unlock()
+	proposalsQueued.WithLabelValues(p.String()).Inc()
+
+	select {
+	case ps.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// next 取出下一个提议: 优先级高的类别先出队, 用完本轮额度后让给其他类别; 所有有提议的类别都用完额度后开始新的一轮
+func (ps *proposeScheduler) next() (*queuedProposal, priorityClass, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for round := 0; round < 2; round++ {
+		for p := prioritySystem; p < numPriorityClasses; p++ {
+			if len(ps.queues[p]) == 0 || ps.credits[p] == 0 {
+				continue
+			}
+			qp := ps.queues[p][0]
+			ps.queues[p][0] = nil
+			ps.queues[p] = ps.queues[p][1:]
+			ps.credits[p]--
+			return qp, p, true
+		}
+		ps.credits = priorityWeights
+	}
+	return nil, 0, false
+}
+
+// drain 取出所有排队的提议
+func (ps *proposeScheduler) drain() []*queuedProposal {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	var qps []*queuedProposal
+	for p := prioritySystem; p < numPriorityClasses; p++ {
+		qps = append(qps, ps.queues[p]...)
+		ps.queues[p] = nil
+		proposalsQueued.WithLabelValues(p.String()).Set(0)
+	}
+	return qps
+}
+
+// propose 按优先级排队, 轮到后调用 raftNode.Propose
+func (s *EtcdServer) propose(ctx context.Context, p priorityClass, data []byte) error {
+	qp := &queuedProposal{ctx: ctx, data: data, queuedAt: time.Now(), errc: make(chan error, 1)}
+	if !s.proposeSched.enqueue(p, qp) {
+		proposalsShedTotal.WithLabelValues(p.String()).Inc()
+		setRetryAfter(ctx, admissionSampleInterval)
+		return ErrTooManyRequests
+	}
+	select {
+	case err := <-qp.errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.stopping:
+		return ErrStopped
+	}
+}
+
+// runProposeScheduler 依次把排队的提议交给raft
+func (s *EtcdServer) runProposeScheduler() {
+	ps := s.proposeSched
+	for {
+		select {
+		case <-ps.notify:
+		case <-s.stopping:
+			for _, qp := range ps.drain() {
+				qp.errc <- ErrStopped
+			}
+			return
+		}
+		for {
+			qp, p, ok := ps.next()
+			if !ok {
+				break
+			}
+			proposalsQueued.WithLabelValues(p.String()).Dec()
+			proposalQueueDurationSec.WithLabelValues(p.String()).Observe(time.Since(qp.queuedAt).Seconds())
+			if err := qp.ctx.Err(); err != nil {
+				qp.errc <- err
+				continue
+			}
+			qp.errc <- s.r.Propose(qp.ctx, qp.data)
+		}
+	}
+}
@@ -15,6 +15,7 @@
 package etcdserver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"expvar"
@@ -170,6 +171,7 @@ type EtcdServer struct {
 	committedIndex    uint64 // 已经提交的日志index,也就是leader确认多数成员已经同步了的日志index
 	term              uint64
 	lead              uint64
+	readOnly          uint32                   // 非0时集群处于只读维护模式; 只在apply协程中修改
 	consistIndex      cindex.ConsistentIndexer // 已经持久化到kvstore的index
 	r                 raftNode                 // 重要的数据结果,存储了raft的状态机信息.
 	readych           chan struct{}            // 启动成功并注册了自己到cluster,关闭这个通道.
@@ -180,6 +182,7 @@ type EtcdServer struct {
 	readMu            sync.RWMutex  // 下面3个结果都是为了实现linearizable 读使用的
 	readwaitc         chan struct{} // 通过向readwaitC发送一个空结构体来通知etcd服务器它正在等待读取
 	readNotifier      *notifier     // 在没有错误时通知read goroutine 可以处理请求
+	readMark          readIndexMark // 最近一次确认的读索引, 用于有界陈旧读
 
 	stop            chan struct{}           // 停止通道
 	stopping        chan struct{}           // 停止时关闭这个通道
@@ -187,6 +190,11 @@ type EtcdServer struct {
 	leaderChanged   chan struct{}           // leader变换后 通知linearizable read loop   drop掉旧的读请求
 	leaderChangedMu sync.RWMutex            //
 	errorc          chan error              // 错误通道,用以传入不可恢复的错误,关闭raft状态机.
+	compactedc      chan struct{}           // 压缩落盘后通知 monitorCompactHash 检查数据一致性
+	repairc         chan types.ID           // leader 向损坏的成员发送修复快照
+	repairSnapc     chan raftpb.Snapshot    // 损坏的成员收到的修复快照, 交给apply协程替换后端
+	proposeSched    *proposeScheduler       // 提议按优先级排队后再交给raft
+	admission       *admissionController    // 按负载压力决定是否接受新的提议
 	id              types.ID                // etcd实例id
 	attributes      membership.Attributes   // etcd实例属性
 	cluster         *membership.RaftCluster // 集群信息
@@ -556,7 +564,12 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		return nil, err
 	}
 	// watch | kv ...
-	srv.kv = mvcc.New(srv.Logger(), srv.backend, srv.lessor, mvcc.StoreConfig{CompactionBatchLimit: cfg.CompactionBatchLimit})
+	srv.kv = mvcc.New(srv.Logger(), srv.backend, srv.lessor, mvcc.StoreConfig{
+		CompactionBatchLimit:  cfg.CompactionBatchLimit,
+		WatchCacheRevisions:   cfg.WatchCacheRevisions,
+		WatchStreamMaxPending: cfg.WatchStreamMaxPending,
+		SlowWatcherPolicy:     mvcc.SlowWatcherPolicy(cfg.SlowWatcherPolicy),
+	})
 
 	kvindex := temp.CI.ConsistentIndex()
 	srv.lg.Debug("恢复consistentIndex", zap.Uint64("index", kvindex))
@@ -574,7 +587,13 @@ func NewServer(cfg config.ServerConfig) (srv *EtcdServer, err error) {
 		}
 	}
 
-	srv.authStore = auth.NewAuthStore(srv.Logger(), srv.backend, tp, int(cfg.BcryptCost)) // BcryptCost 为散列身份验证密码指定bcrypt算法的成本/强度默认10
+	certRules, err := auth.ParseCertMappingRules(cfg.ClientCertAuthRules)
+	if err != nil {
+		return nil, err
+	}
+	as := auth.NewAuthStore(srv.Logger(), srv.backend, tp, int(cfg.BcryptCost)) // BcryptCost 为散列身份验证密码指定bcrypt算法的成本/强度默认10
+	as.SetCertMappingRules(certRules)
+	srv.authStore = as
 
 	newSrv := srv // since srv == nil in defer if srv is returned as nil
 	defer func() {
@@ -733,13 +752,19 @@ func (s *EtcdServer) adjustTicks() {
 
 func (s *EtcdServer) Start() {
 	s.start()
+	s.GoAttach(s.runProposeScheduler)
+	s.GoAttach(s.monitorAdmission)
 	s.GoAttach(func() { s.adjustTicks() })
 	s.GoAttach(func() { s.publish(s.Cfg.ReqTimeout()) })
 	s.GoAttach(s.purgeFile)
 	s.GoAttach(s.monitorVersions)
 	s.GoAttach(s.linearizableReadLoop)
 	s.GoAttach(s.monitorKVHash)
+	s.GoAttach(s.monitorCompactHash)
+	s.GoAttach(s.monitorCorruptRepair)
+	s.GoAttach(s.monitorHealthAlarms)
 	s.GoAttach(s.monitorDowngrade)
+	s.GoAttach(s.purgeExpiredScopedTokens)
 }
 
 func (s *EtcdServer) start() {
@@ -767,6 +792,11 @@ func (s *EtcdServer) start() {
 	s.stopping = make(chan struct{}, 1)
 	s.ctx, s.cancel = context.WithCancel(context.Background())
 	s.readwaitc = make(chan struct{}, 1)
+	s.compactedc = make(chan struct{}, 1)
+	s.repairc = make(chan types.ID)
+	s.repairSnapc = make(chan raftpb.Snapshot, 1)
+	s.proposeSched = newProposeScheduler()
+	s.admission = &admissionController{}
 	s.readNotifier = newNotifier()
 	s.leaderChanged = make(chan struct{})
 	if s.ClusterVersion() != nil {
@@ -976,6 +1006,10 @@ func (s *EtcdServer) run() {
 				s.applyAll(&ep, &ap)
 			}
 			sched.Schedule(f)
+		case id := <-s.repairc:
+			sched.Schedule(func(context.Context) { s.sendRepairSnapshot(&ep, id) })
+		case snapshot := <-s.repairSnapc:
+			sched.Schedule(func(context.Context) { s.applyRepairSnapshot(&ep, snapshot) })
 		case leases := <-expiredLeaseC:
 			s.GoAttach(func() {
 				// 通过并行化增加过期租约删除过程的吞吐量
@@ -1093,6 +1127,53 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, apply *apply) {
 		lg.Panic("failed to open snapshot backend", zap.Error(err))
 	}
 
+	s.recoverV3Backend(newbe)
+
+	lg.Info("restoring v2 store")
+	if err := s.v2store.Recovery(apply.snapshot.Data); err != nil {
+		lg.Panic("failed to restore v2 store", zap.Error(err))
+	}
+
+	if err := assertNoV2StoreContent(lg, s.v2store, s.Cfg.V2Deprecation); err != nil {
+		lg.Panic("illegal v2store content", zap.Error(err))
+	}
+
+	lg.Info("restored v2 store")
+
+	s.cluster.SetBackend(newbe)
+
+	lg.Info("restoring cluster configuration")
+
+	s.cluster.Recover(api.UpdateCapability)
+
+	lg.Info("restored cluster configuration")
+	lg.Info("removing old peers from network")
+
+	// recover raft transport
+	s.r.transport.RemoveAllPeers()
+
+	lg.Info("removed old peers from network")
+	lg.Info("adding peers from new cluster configuration")
+
+	for _, m := range s.cluster.Members() {
+		if m.ID == s.ID() {
+			continue
+		}
+		s.r.transport.AddPeer(m.ID, m.PeerURLs)
+	}
+
+	lg.Info("added peers from new cluster configuration")
+
+	ep.appliedt = apply.snapshot.Metadata.Term
+	ep.appliedi = apply.snapshot.Metadata.Index
+	ep.snapi = ep.appliedi
+	ep.confState = apply.snapshot.Metadata.ConfState
+}
+
+// recoverV3Backend 用新的后端恢复租约、mvcc、警报和鉴权存储, 并替换当前后端
+func (s *EtcdServer) recoverV3Backend(newbe backend.Backend) {
+	lg := s.Logger()
+
 	// always recover lessor before kv. When we recover the mvcc.KV it will reattach keys to its leases.
 	// If we recover mvcc.KV first, it will attach the keys to the wrong lessor before it recovers.
 	if s.lessor != nil {
@@ -1145,46 +1226,6 @@ func (s *EtcdServer) applySnapshot(ep *etcdProgress, apply *apply) {
 
 		lg.Info("restored auth store")
 	}
-
-	lg.Info("restoring v2 store")
-	if err := s.v2store.Recovery(apply.snapshot.Data); err != nil {
-		lg.Panic("failed to restore v2 store", zap.Error(err))
-	}
-
-	if err := assertNoV2StoreContent(lg, s.v2store, s.Cfg.V2Deprecation); err != nil {
-		lg.Panic("illegal v2store content", zap.Error(err))
-	}
-
-	lg.Info("restored v2 store")
-
-	s.cluster.SetBackend(newbe)
-
-	lg.Info("restoring cluster configuration")
-
-	s.cluster.Recover(api.UpdateCapability)
-
-	lg.Info("restored cluster configuration")
-	lg.Info("removing old peers from network")
-
-	// recover raft transport
-	s.r.transport.RemoveAllPeers()
-
-	lg.Info("removed old peers from network")
-	lg.Info("adding peers from new cluster configuration")
-
-	for _, m := range s.cluster.Members() {
-		if m.ID == s.ID() {
-			continue
-		}
-		s.r.transport.AddPeer(m.ID, m.PeerURLs)
-	}
-
-	lg.Info("added peers from new cluster configuration")
-
-	ep.appliedt = apply.snapshot.Metadata.Term
-	ep.appliedi = apply.snapshot.Metadata.Index
-	ep.snapi = ep.appliedi
-	ep.confState = apply.snapshot.Metadata.ConfState
 }
 
 func (s *EtcdServer) applyEntries(ep *etcdProgress, apply *apply) {
@@ -1207,9 +1248,11 @@ func (s *EtcdServer) applyEntries(ep *etcdProgress, apply *apply) {
 		return
 	}
 	var shouldstop bool
+	start := time.Now()
 	if ep.appliedt, ep.appliedi, shouldstop = s.apply(ents, &ep.confState); shouldstop {
 		go s.stopWithDelay(10*100*time.Millisecond, fmt.Errorf(""))
 	}
+	s.admission.recordApply(len(ents), time.Since(start))
 }
 
 func (s *EtcdServer) triggerSnapshot(ep *etcdProgress) {
@@ -1920,19 +1963,13 @@ func (s *EtcdServer) AuthStore() auth.AuthStore { return s.authStore }
 
 // 启动时重置所有警报
 func (s *EtcdServer) restoreAlarms() error {
-	s.applyV3 = s.newApplierV3()
 	as, err := v3alarm.NewAlarmStore(s.lg, s)
 	if err != nil {
 		return err
 	}
 	s.alarmStore = as
-	// 警报只有这两种类型
-	if len(as.Get(pb.AlarmType_NOSPACE)) > 0 {
-		s.applyV3 = newApplierV3Capped(s.applyV3)
-	}
-	if len(as.Get(pb.AlarmType_CORRUPT)) > 0 {
-		s.applyV3 = newApplierV3Corrupt(s.applyV3)
-	}
+	s.setReadOnly(loadReadOnly(s.Backend()))
+	s.applyV3 = s.newApplierV3WithAlarms()
 	return nil
 }
 
@@ -2216,6 +2253,9 @@ func (s *EtcdServer) Process(ctx context.Context, m raftpb.Message) error {
 	if m.Type == raftpb.MsgApp {
 		s.stats.RecvAppendReq(types.ID(m.From).String(), m.Size())
 	}
+	if m.Type == raftpb.MsgSnap && bytes.Equal(m.Context, repairSnapshotContext) {
+		return s.receiveRepairSnapshot(m)
+	}
 	var _ raft.RaftNodeInterFace = raftNode{}
 	//_ = raftNode{}.Step
 	return s.r.Step(ctx, m)
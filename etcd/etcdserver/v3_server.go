@@ -39,6 +39,7 @@ const (
 	// the applied index and committed index.
 	// However, if the committed entries are very heavy to apply, the gap might grow.
 	// We should stop accepting new proposals if the gap growing to a certain point.
+	// Admission control sheds normal proposals once the gap reaches it.
 	maxGapBetweenApplyAndCommitIndex = 5000
 	readIndexRetryTime               = 500 * time.Millisecond
 )
@@ -61,6 +62,12 @@ type Authenticator interface {
 	RoleDelete(ctx context.Context, r *pb.AuthRoleDeleteRequest) (*pb.AuthRoleDeleteResponse, error)
 	UserList(ctx context.Context, r *pb.AuthUserListRequest) (*pb.AuthUserListResponse, error)
 	RoleList(ctx context.Context, r *pb.AuthRoleListRequest) (*pb.AuthRoleListResponse, error)
+	UserTokenCreate(ctx context.Context, r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error)
+	UserTokenList(ctx context.Context, r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error)
+	UserTokenRevoke(ctx context.Context, r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error)
+	AuthCheck(ctx context.Context, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error)
+	UserSetRateLimit(ctx context.Context, r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error)
+	RoleSetRateLimit(ctx context.Context, r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error)
 }
 
 func isTxnSerializable(r *pb.TxnRequest) bool {
@@ -198,8 +205,19 @@ func (s *EtcdServer) downgradeCancel(ctx context.Context) (*pb.DowngradeResponse
 
 // ----------------------------------------   OVER  ------------------------------------------------------------
 
+type authInfoCtxKey struct{}
+
+// WithAuthInfo 把已解析的认证信息(可以为nil)缓存到请求的ctx上, 之后的 AuthInfoFromCtx 直接返回,
+// 不再重复解析令牌. 只用于单个请求的ctx; 流的ctx存活时间长, 令牌过期后需要重新解析.
+func WithAuthInfo(ctx context.Context, authInfo *auth.AuthInfo) context.Context {
+	return context.WithValue(ctx, authInfoCtxKey{}, authInfo)
+}
+
 // AuthInfoFromCtx 获取认证信息
 func (s *EtcdServer) AuthInfoFromCtx(ctx context.Context) (*auth.AuthInfo, error) {
+	if authInfo, ok := ctx.Value(authInfoCtxKey{}).(*auth.AuthInfo); ok {
+		return authInfo, nil
+	}
 	authInfo, err := s.AuthStore().AuthInfoFromCtx(ctx) // 用户认证
 	if authInfo != nil || err != nil {
 		return authInfo, err
@@ -266,11 +284,10 @@ func (s *EtcdServer) raftRequestOnce(ctx context.Context, r pb.InternalRaftReque
 
 // 当客户端提交一条数据变更请求时
 func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.InternalRaftRequest) (*applyResult, error) {
-	// 判断已提交未apply的记录是否超过限制
-	ai := s.getAppliedIndex()
-	ci := s.getCommittedIndex()
-	if ci > ai+maxGapBetweenApplyAndCommitIndex {
-		return nil, ErrTooManyRequests
+	// 按负载压力与请求的优先级判断是否接受
+	priority := requestPriority(ctx, &r)
+	if err := s.admit(ctx, priority); err != nil {
+		return nil, err
 	}
 
 	r.Header = &pb.RequestHeader{
@@ -286,6 +303,8 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 		if authInfo != nil {
 			r.Header.Username = authInfo.Username
 			r.Header.AuthRevision = authInfo.Revision
+			r.Header.ScopedToken = authInfo.ScopedToken
+			r.Header.Roles = authInfo.Roles
 		}
 	}
 	// 反序列化请求数据
@@ -311,7 +330,7 @@ func (s *EtcdServer) processInternalRaftRequestOnce(ctx context.Context, r pb.In
 
 	start := time.Now()
 	_ = s.applyEntryNormal
-	err = s.r.Propose(cctx, data) // 调用raft模块的Propose处理请求,存入到了待发送队列
+	err = s.propose(cctx, priority, data) // 按优先级排队后调用raft模块的Propose处理请求,存入到了待发送队列
 	if err != nil {
 		s.w.Trigger(id, nil)
 		return nil, err
@@ -369,6 +388,9 @@ func (a *applierV3backend) Apply(r *pb.InternalRaftRequest, shouldApplyV3 member
 		ar.resp, ar.trace, ar.err = a.s.applyV3.Txn(context.TODO(), r.Txn)
 	case r.Compaction != nil:
 		ar.resp, ar.physc, ar.trace, ar.err = a.s.applyV3.Compaction(r.Compaction) // ✅ 压缩kv 历史事件
+		if ar.err == nil {
+			a.s.notifyCompacted(ar.physc)
+		}
 	case r.LeaseGrant != nil:
 		ar.resp, ar.err = a.s.applyV3.LeaseGrant(r.LeaseGrant) // ✅ 创建租约
 	case r.LeaseRevoke != nil:
@@ -412,6 +434,22 @@ func (a *applierV3backend) Apply(r *pb.InternalRaftRequest, shouldApplyV3 member
 		ar.resp, ar.err = a.s.applyV3.RoleDelete(r.AuthRoleDelete) // ✅
 	case r.AuthRoleList != nil:
 		ar.resp, ar.err = a.s.applyV3.RoleList(r.AuthRoleList) // ✅
+	case r.AuthUserTokenCreate != nil:
+		ar.resp, ar.err = a.s.applyV3.UserTokenCreate(r.AuthUserTokenCreate)
+	case r.AuthUserTokenList != nil:
+		ar.resp, ar.err = a.s.applyV3.UserTokenList(r.AuthUserTokenList)
+	case r.AuthUserTokenRevoke != nil:
+		ar.resp, ar.err = a.s.applyV3.UserTokenRevoke(r.AuthUserTokenRevoke)
+	case r.AuthTokenPurge != nil:
+		ar.resp, ar.err = a.s.applyV3.AuthTokenPurge(r.AuthTokenPurge)
+	case r.AuthCheck != nil:
+		ar.resp, ar.err = a.s.applyV3.AuthCheck(nil, r.AuthCheck)
+	case r.AuthUserSetRateLimit != nil:
+		ar.resp, ar.err = a.s.applyV3.UserSetRateLimit(r.AuthUserSetRateLimit)
+	case r.AuthRoleSetRateLimit != nil:
+		ar.resp, ar.err = a.s.applyV3.RoleSetRateLimit(r.AuthRoleSetRateLimit)
+	case r.MaintenanceMode != nil:
+		ar.resp, ar.err = a.s.applyV3.MaintenanceMode(r.MaintenanceMode)
 	default:
 		a.s.lg.Panic("没有实现应用", zap.Stringer("raft-request", r))
 	}
@@ -3,6 +3,7 @@ package etcdserver
 import (
 	"context"
 	"encoding/base64"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/ls-2018/etcd_cn/etcd/auth"
@@ -30,6 +31,12 @@ type AuthClient interface {
 	RoleDelete(ctx context.Context, in *pb.AuthRoleDeleteRequest, opts ...grpc.CallOption) (*pb.AuthRoleDeleteResponse, error)
 	RoleGrantPermission(ctx context.Context, in *pb.AuthRoleGrantPermissionRequest, opts ...grpc.CallOption) (*pb.AuthRoleGrantPermissionResponse, error)
 	RoleRevokePermission(ctx context.Context, in *pb.AuthRoleRevokePermissionRequest, opts ...grpc.CallOption) (*pb.AuthRoleRevokePermissionResponse, error)
+	UserTokenCreate(ctx context.Context, in *pb.AuthUserTokenCreateRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenCreateResponse, error)
+	UserTokenList(ctx context.Context, in *pb.AuthUserTokenListRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenListResponse, error)
+	UserTokenRevoke(ctx context.Context, in *pb.AuthUserTokenRevokeRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenRevokeResponse, error)
+	AuthCheck(ctx context.Context, in *pb.AuthCheckRequest, opts ...grpc.CallOption) (*pb.AuthCheckResponse, error)
+	UserSetRateLimit(ctx context.Context, in *pb.AuthUserSetRateLimitRequest, opts ...grpc.CallOption) (*pb.AuthUserSetRateLimitResponse, error)
+	RoleSetRateLimit(ctx context.Context, in *pb.AuthRoleSetRateLimitRequest, opts ...grpc.CallOption) (*pb.AuthRoleSetRateLimitResponse, error)
 }
 
 func (s *EtcdServer) AuthEnable(ctx context.Context, r *pb.AuthEnableRequest) (*pb.AuthEnableResponse, error) {
@@ -179,6 +186,97 @@ func (s *EtcdServer) UserRevokeRole(ctx context.Context, r *pb.AuthUserRevokeRol
 	return resp.(*pb.AuthUserRevokeRoleResponse), nil
 }
 
+func (s *EtcdServer) UserTokenCreate(ctx context.Context, r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	if r.TTL <= 0 {
+		return nil, auth.ErrInvalidTokenTTL
+	}
+	// token 明文只返回给调用方, raft日志里只记录哈希; 过期时间在这里确定, 保证各成员一致
+	token, hashed, err := auth.GenScopedToken()
+	if err != nil {
+		return nil, err
+	}
+	r.HashedToken = hashed
+	r.ExpireTime = time.Now().Add(time.Duration(r.TTL) * time.Second).Unix()
+
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserTokenCreate: r})
+	if err != nil {
+		return nil, err
+	}
+	tresp := resp.(*pb.AuthUserTokenCreateResponse)
+	tresp.Token = token
+	return tresp, nil
+}
+
+func (s *EtcdServer) UserTokenList(ctx context.Context, r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserTokenList: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserTokenListResponse), nil
+}
+
+func (s *EtcdServer) UserTokenRevoke(ctx context.Context, r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserTokenRevoke: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserTokenRevokeResponse), nil
+}
+
+// scopedTokenPurgeInterval leader 检查并清理过期 scoped token 的周期
+const scopedTokenPurgeInterval = time.Minute
+
+// purgeExpiredScopedTokens 过期的token只在查找时被拒绝, 由leader定期经raft删除, 避免authTokens bucket无限增长
+func (s *EtcdServer) purgeExpiredScopedTokens() {
+	lg := s.Logger()
+	for {
+		select {
+		case <-time.After(scopedTokenPurgeInterval):
+		case <-s.stopping:
+			return
+		}
+
+		if !s.isLeader() {
+			continue
+		}
+		now := time.Now().Unix()
+		if !s.AuthStore().HasExpiredScopedTokens(now) {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.Cfg.ReqTimeout())
+		_, err := s.raftRequestOnce(ctx, pb.InternalRaftRequest{AuthTokenPurge: &pb.InternalAuthTokenPurgeRequest{ExpireBefore: now}})
+		cancel()
+		if err != nil {
+			lg.Warn("清理过期的scoped token失败", zap.Error(err))
+		}
+	}
+}
+
+func (s *EtcdServer) AuthCheck(ctx context.Context, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthCheck: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthCheckResponse), nil
+}
+
+func (s *EtcdServer) UserSetRateLimit(ctx context.Context, r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthUserSetRateLimit: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthUserSetRateLimitResponse), nil
+}
+
+func (s *EtcdServer) RoleSetRateLimit(ctx context.Context, r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error) {
+	resp, err := s.raftRequest(ctx, pb.InternalRaftRequest{AuthRoleSetRateLimit: r})
+	if err != nil {
+		return nil, err
+	}
+	return resp.(*pb.AuthRoleSetRateLimitResponse), nil
+}
+
 // ------------------------------------------- OVER ---------------------------------------------------------vv
 
 func (s *EtcdServer) RoleGrantPermission(ctx context.Context, r *pb.AuthRoleGrantPermissionRequest) (*pb.AuthRoleGrantPermissionResponse, error) {
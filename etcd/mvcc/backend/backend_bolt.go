@@ -57,6 +57,9 @@ type Backend interface {
 	Defrag() error      // 数据文件整理,会回收已删除key和已更新的key旧版本占用的磁盘
 	ForceCommit()       // 强制当前的批处理tx提交
 	Close() error
+
+	// CommitStats 返回启动以来提交的事务数及提交的累计耗时
+	CommitStats() (commits int64, took time.Duration)
 }
 
 type Snapshot interface {
@@ -77,6 +80,7 @@ type (
 		size          int64            // 已经占用的磁盘大小
 		sizeInUse     int64            // 实际使用的大小
 		commits       int64            // 已提交事务数
+		commitNanos   int64            // 提交事务的累计耗时
 		openReadTxN   int64            // 当前开启的读事务数
 		mlock         bool             // mlock prevents backend database file to be swapped
 		boltdbMu      sync.RWMutex     // 这里的锁也是隔离下面的db对象；正常的创建bolt.DB事务只需要读锁；但是做 defrag 时候需要写锁隔离
@@ -360,6 +364,10 @@ func (b *backend) Commits() int64 {
 	return atomic.LoadInt64(&b.commits)
 }
 
+func (b *backend) CommitStats() (int64, time.Duration) {
+	return atomic.LoadInt64(&b.commits), time.Duration(atomic.LoadInt64(&b.commitNanos))
+}
+
 // Defrag 碎片整理
 func (b *backend) Defrag() error {
 	return b.defrag()
@@ -19,6 +19,7 @@ import (
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	bolt "go.etcd.io/bbolt"
 	"go.uber.org/zap"
@@ -189,7 +190,9 @@ func (t *batchTx) commit(stop bool) {
 		if t.pending == 0 && !stop {
 			return
 		}
+		start := time.Now()
 		err := t.tx.Commit() // bolt.Commit
+		atomic.AddInt64(&t.backend.commitNanos, int64(time.Since(start)))
 		atomic.AddInt64(&t.backend.commits, 1)
 
 		t.pending = 0
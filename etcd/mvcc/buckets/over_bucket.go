@@ -30,9 +30,10 @@ var (
 	Members        = backend.Bucket(bucket{id: 10, name: []byte("members"), safeRangeBucket: false})
 	MembersRemoved = backend.Bucket(bucket{id: 11, name: []byte("members_removed"), safeRangeBucket: false})
 
-	Auth      = backend.Bucket(bucket{id: 20, name: []byte("auth"), safeRangeBucket: false})
-	AuthUsers = backend.Bucket(bucket{id: 21, name: []byte("authUsers"), safeRangeBucket: false})
-	AuthRoles = backend.Bucket(bucket{id: 22, name: []byte("authRoles"), safeRangeBucket: false})
+	Auth       = backend.Bucket(bucket{id: 20, name: []byte("auth"), safeRangeBucket: false})
+	AuthUsers  = backend.Bucket(bucket{id: 21, name: []byte("authUsers"), safeRangeBucket: false})
+	AuthRoles  = backend.Bucket(bucket{id: 22, name: []byte("authRoles"), safeRangeBucket: false})
+	AuthTokens = backend.Bucket(bucket{id: 23, name: []byte("authTokens"), safeRangeBucket: false})
 
 	Test = backend.Bucket(bucket{id: 100, name: []byte("test"), safeRangeBucket: false})
 )
@@ -51,6 +52,7 @@ func (b bucket) IsSafeRangeBucket() bool { return b.safeRangeBucket }
 var (
 	MetaConsistentIndexKeyName = []byte("consistent_index")
 	MetaTermKeyName            = []byte("term")
+	MetaReadOnlyKeyName        = []byte("read_only")
 )
 
 // DefaultIgnores 定义在哈希检查中要忽略的桶和键.
@@ -0,0 +1,98 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"hash/crc32"
+
+	"github.com/ls-2018/etcd_cn/etcd/mvcc/buckets"
+	"github.com/ls-2018/etcd_cn/pkg/hashtree"
+)
+
+// hashRangesBatch 每批读取的key数; 批与批之间释放后端读事务, 不会在整个扫描期间阻塞后端提交
+const hashRangesBatch = 1000
+
+// HashRanges 计算各key范围在修订版本rev时状态(每个存在的key在rev时的版本)的哈希, rev为0表示当前修订版本.
+// 与 HashByRev 不同, 结果只取决于rev时的状态而与压缩进度无关, 可以对任意子范围计算,
+// 因此能逐层细分定位不一致的key. 每个范围最多返回 splits 个切分点.
+// 后端按 hashRangesBatch 分批读取, 期间压缩超过rev时返回 ErrCompacted.
+func (s *store) HashRanges(rev int64, ranges []hashtree.Range, splits int) (hashes []hashtree.Hash, currentRev int64, compactRev int64, err error) {
+	s.mu.RLock()
+	s.revMu.RLock()
+	compactRev, currentRev = s.compactMainRev, s.currentRev
+	s.revMu.RUnlock()
+	s.mu.RUnlock()
+
+	if rev > 0 && rev < compactRev {
+		return nil, 0, compactRev, ErrCompacted
+	} else if rev > 0 && rev > currentRev {
+		return nil, currentRev, 0, ErrFutureRev
+	}
+	if rev == 0 {
+		rev = currentRev
+	}
+
+	table := crc32.MakeTable(crc32.Castagnoli)
+	revBytes := newRevBytes()
+	hashes = make([]hashtree.Hash, len(ranges))
+	for i, r := range ranges {
+		keys, revs := s.kvindex.RangeFrom([]byte(r.Key), []byte(r.End), rev)
+		h := crc32.New(table)
+		rh := hashtree.Hash{Range: r, Count: int64(len(keys))}
+		for start := 0; start < len(revs); start += hashRangesBatch {
+			end := start + hashRangesBatch
+			if end > len(revs) {
+				end = len(revs)
+			}
+			tx := s.b.ReadTx()
+			tx.RLock()
+			for j := start; j < end; j++ {
+				kr := revs[j]
+				revToBytes(kr, revBytes)
+				h.Write(keys[j])
+				h.Write(revBytes)
+				// 索引中有而后端中没有的修订版本只计入key和修订版本, 同样会造成哈希不一致
+				if _, vs := tx.UnsafeRange(buckets.Key, revBytes, nil, 0); len(vs) == 1 {
+					h.Write(vs[0])
+				}
+				if kr.Main > rh.MaxModRevision {
+					rh.MaxModRevision = kr.Main
+				}
+			}
+			tx.RUnlock()
+			// 压缩只删除rev之前被覆盖的修订版本; 压缩未超过rev时, 批之间的提交不影响已读取的结果
+			s.revMu.RLock()
+			compacted := s.compactMainRev
+			s.revMu.RUnlock()
+			if compacted > rev {
+				return nil, 0, compacted, ErrCompacted
+			}
+		}
+		rh.Hash = h.Sum32()
+		if len(keys) > 0 {
+			rh.FirstKey, rh.LastKey = string(keys[0]), string(keys[len(keys)-1])
+		}
+		if n := len(keys) - 1; n > 0 && splits > 0 {
+			if n > splits {
+				n = splits
+			}
+			for k := 1; k <= n; k++ {
+				rh.SplitKeys = append(rh.SplitKeys, string(keys[k*len(keys)/(n+1)]))
+			}
+		}
+		hashes[i] = rh
+	}
+	return hashes, currentRev, compactRev, nil
+}
@@ -27,6 +27,7 @@ import (
 type index interface {
 	Get(key []byte, atRev int64) (rev, created revision, ver int64, err error)
 	Range(key, end []byte, atRev int64) ([][]byte, []revision)
+	RangeFrom(key, end []byte, atRev int64) ([][]byte, []revision)
 	Revisions(key, end []byte, atRev int64, limit int) ([]revision, int)
 	CountRevisions(key, end []byte, atRev int64) int
 	Put(key []byte, rev revision)
@@ -126,6 +127,18 @@ func (ti *treeIndex) Range(key, end []byte, atRev int64) (keys [][]byte, revs []
 	return keys, revs
 }
 
+// RangeFrom 与 Range 相同, 但 end 为空时表示不限上界而不是单个key
+func (ti *treeIndex) RangeFrom(key, end []byte, atRev int64) (keys [][]byte, revs []revision) {
+	ti.visit(key, end, func(ki *keyIndex) bool {
+		if rev, _, _, err := ki.get(ti.lg, atRev); err == nil {
+			revs = append(revs, rev)
+			keys = append(keys, []byte(ki.Key))
+		}
+		return true
+	})
+	return keys, revs
+}
+
 func (ti *treeIndex) Tombstone(key []byte, rev revision) error {
 	keyi := &keyIndex{Key: string(key)}
 
@@ -55,7 +55,10 @@ var (
 )
 
 type StoreConfig struct {
-	CompactionBatchLimit int
+	CompactionBatchLimit  int
+	WatchCacheRevisions   int64             // watch事件缓存的修订版本数, 0表示不缓存
+	WatchStreamMaxPending int               // 每个watch stream最多积压的事件数, 0表示不限制
+	SlowWatcherPolicy     SlowWatcherPolicy // 积压超过上限后的处理方式
 }
 
 type store struct {
@@ -2,6 +2,7 @@ package mvcc
 
 import (
 	"github.com/ls-2018/etcd_cn/etcd/mvcc/backend"
+	"github.com/ls-2018/etcd_cn/pkg/hashtree"
 	"github.com/ls-2018/etcd_cn/pkg/traceutil"
 )
 
@@ -16,6 +17,9 @@ type KV interface {
 	Commit()                                                                        // 将未完成的TXNS提交到底层后端.
 	Restore(b backend.Backend) error
 	Close() error
+
+	// HashRanges 计算各key范围在给定修订版本时状态的哈希, 用于定位成员间不一致的key
+	HashRanges(rev int64, ranges []hashtree.Range, splits int) (hashes []hashtree.Hash, revision int64, compactRev int64, err error)
 }
 
 type WatchableKV interface {
@@ -16,6 +16,7 @@ package mvcc
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ls-2018/etcd_cn/etcd/lease"
@@ -38,8 +39,9 @@ var (
 )
 
 type watchable interface {
-	watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc)
+	watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, q *streamQueue, fcs ...FilterFunc) (*watcher, cancelFunc)
 	progress(w *watcher)
+	forceProgress(w *watcher)
 	rev() int64
 }
 
@@ -50,8 +52,12 @@ type watchableStore struct {
 	victimc  chan struct{}  // 如果watcher实例关联的ch通道被阻塞了,则对应的watcherBatch实例会暂时记录到该字段中
 	unsynced watcherGroup   // 用于存储未同步完成的实例
 	synced   watcherGroup   // 用于存储同步完成的实例
+	cache    *watchCache    // 最近的事件, 供未同步的watcher共享
 	stopc    chan struct{}
 	wg       sync.WaitGroup
+
+	// 因channel已满而没有发出的进度通知, 由 syncWatchersLoop 重试
+	progressOwed map[*watcher]struct{}
 }
 
 // cancelFunc updates unsynced and synced maps when running
@@ -72,7 +78,10 @@ func newWatchableStore(lg *zap.Logger, b backend.Backend, le lease.Lessor, cfg S
 		unsynced: newWatcherGroup(),      // 用于存储未同步完成的实例
 		synced:   newWatcherGroup(),      // 用于存储已经同步完成的实例
 		stopc:    make(chan struct{}),
+
+		progressOwed: make(map[*watcher]struct{}),
 	}
+	s.cache = newWatchCache(cfg.WatchCacheRevisions, s.store.currentRev)
 	s.store.ReadView = &readView{s}   // 调用storage中全局view查询
 	s.store.WriteView = &writeView{s} // 调用storage中全局view查询
 	if s.le != nil {
@@ -99,11 +108,12 @@ func (s *watchableStore) NewWatchStream() WatchStream {
 		ch:        make(chan WatchResponse, chanBufLen),
 		cancels:   make(map[WatchID]cancelFunc),
 		watchers:  make(map[WatchID]*watcher),
+		queue:     &streamQueue{},
 	}
 }
 
 // watcher 初始化
-func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, fcs ...FilterFunc) (*watcher, cancelFunc) {
+func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch chan<- WatchResponse, q *streamQueue, fcs ...FilterFunc) (*watcher, cancelFunc) {
 	wa := &watcher{
 		key:         string(key),
 		end:         string(end),
@@ -111,6 +121,7 @@ func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch c
 		id:          id,
 		ch:          ch, // 将变更事件塞进去,可能会与其他watcher 共享
 		filterFuncs: fcs,
+		queue:       q,
 	}
 
 	s.mu.Lock()
@@ -136,6 +147,7 @@ func (s *watchableStore) watch(key, end []byte, startRev int64, id WatchID, ch c
 func (s *watchableStore) cancelWatcher(wa *watcher) {
 	for {
 		s.mu.Lock()
+		delete(s.progressOwed, wa)
 		if s.unsynced.delete(wa) {
 			break
 		} else if s.synced.delete(wa) {
@@ -145,6 +157,8 @@ func (s *watchableStore) cancelWatcher(wa *watcher) {
 		} else if wa.ch == nil { // 判断是否还能发送数据
 			// already canceled (e.g., cancel/close race)
 			break
+		} else if wa.resumeRev != 0 && !wa.victim { // 因stream积压被取消,取消通知已发出
+			break
 		}
 
 		if !wa.victim {
@@ -160,6 +174,7 @@ func (s *watchableStore) cancelWatcher(wa *watcher) {
 			}
 		}
 		if victimBatch != nil {
+			wa.releasePending(victimBatch[wa])
 			delete(victimBatch, wa)
 			break
 		}
@@ -180,6 +195,7 @@ func (s *watchableStore) Restore(b backend.Backend) error {
 	if err != nil {
 		return err
 	}
+	s.cache.reset(s.store.currentRev)
 
 	for wa := range s.synced.watchers {
 		wa.restore = true
@@ -199,6 +215,8 @@ func (s *watchableStore) syncWatchersLoop() {
 		lastUnsyncedWatchers := s.unsynced.size() // 获取当前的unsynced watcherGroup的大小
 		s.mu.RUnlock()
 
+		s.retryProgress()
+
 		unsyncedWatchers := 0
 		if lastUnsyncedWatchers > 0 {
 			// 存在需要进行同步的watcher实例,调用syncWatchers()方法对unsynced watcherGroup中的watcher进行批量同步
@@ -261,7 +279,12 @@ func (s *watchableStore) moveVictims() (moved int) {
 		// 尝试发送受损的响应【因通道阻塞导致的】
 		for w, eb := range wb {
 			rev := w.minRev - 1
-			if w.send(WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}) {
+			wr := WatchResponse{WatchID: w.id, Events: eb.evs, Revision: rev}
+			if w.resumeRev != 0 {
+				// 积压超限被取消, 只需发出取消通知
+				wr = WatchResponse{WatchID: w.id, Revision: rev, ResumeRevision: w.resumeRev}
+			}
+			if w.send(wr) {
 			} else {
 				if newVictim == nil {
 					newVictim = make(watcherBatch)
@@ -281,6 +304,11 @@ func (s *watchableStore) moveVictims() (moved int) {
 				continue
 			}
 			w.victim = false
+			w.releasePending(eb)
+			if w.resumeRev != 0 {
+				// 已取消的watcher不再回到watcherGroup
+				continue
+			}
 			if eb.moreRev != 0 {
 				w.minRev = eb.moreRev
 			}
@@ -324,15 +352,21 @@ func (s *watchableStore) syncWatchers() int {
 	compactionRev := s.store.compactMainRev
 	// 根据unsynced watcherGroup中记录的watcher个数对其进行分批返回,同时获取该批watcher实例中查找最小的minRev字段,maxWatchersPerSync默认为512
 	wg, minRev := s.unsynced.choose(maxWatchersPerSync, curRev, compactionRev)
-	minBytes, maxBytes := newRevBytes(), newRevBytes()
-	revToBytes(revision{Main: minRev}, minBytes)
-	revToBytes(revision{Main: curRev + 1}, maxBytes)
-
-	tx := s.store.b.ReadTx()
-	tx.RLock()
-	revs, vs := tx.UnsafeRange(buckets.Key, minBytes, maxBytes, 0) // 对key Bucket进行范围查找
-	evs := kvsToEvents(s.store.lg, wg, revs, vs)                   // 负责将BoltDB中查询的键值对信息转换成相应的event实例
-	tx.RUnlock()
+	var evs []mvccpb.Event
+	if s.cache.covers(minRev, curRev) {
+		// 缓存覆盖了这批watcher需要的所有修订版本, 不需要读取后端
+		evs = s.cache.eventsFrom(wg, minRev)
+	} else {
+		minBytes, maxBytes := newRevBytes(), newRevBytes()
+		revToBytes(revision{Main: minRev}, minBytes)
+		revToBytes(revision{Main: curRev + 1}, maxBytes)
+
+		tx := s.store.b.ReadTx()
+		tx.RLock()
+		revs, vs := tx.UnsafeRange(buckets.Key, minBytes, maxBytes, 0) // 对key Bucket进行范围查找
+		evs = kvsToEvents(s.store.lg, wg, revs, vs)                    // 负责将BoltDB中查询的键值对信息转换成相应的event实例
+		tx.RUnlock()
+	}
 
 	var victims watcherBatch
 	wb := newWatcherBatch(wg, evs)
@@ -356,6 +390,11 @@ func (s *watchableStore) syncWatchers() int {
 		} else {
 			// case 1 确实是发送失败
 			// case 2 通道阻塞了,暂时标记位victim
+			s.unsynced.delete(w)
+			if s.overflow(w, eb) {
+				// stream积压超限, 已按策略处理
+				continue
+			}
 			if victims == nil {
 				victims = make(watcherBatch)
 			}
@@ -402,6 +441,7 @@ func kvsToEvents(lg *zap.Logger, wg *watcherGroup, revs, vals [][]byte) (evs []m
 
 // notify 当前的修订版本,当前的变更事件   用于通知对应的watcher
 func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
+	s.cache.add(rev, evs)
 	var victim watcherBatch
 	// type watcherBatch map[*watcher]*eventBatch
 	// 找到所有的watch,synced使用了map和红黑树来快速找到监听的key
@@ -413,12 +453,16 @@ func (s *watchableStore) notify(rev int64, evs []mvccpb.Event) {
 		} else {
 			// 移动缓慢的观察者到victim
 			watcher.minRev = rev + 1
+			s.synced.delete(watcher)
+			if s.overflow(watcher, eb) {
+				// stream积压超限, 已按策略处理
+				continue
+			}
 			if victim == nil {
 				victim = make(watcherBatch)
 			}
 			watcher.victim = true
 			victim[watcher] = eb
-			s.synced.delete(watcher)
 		}
 	}
 	s.addVictim(victim) // 将因为chan满没发出的消息缓存,然后使用unsynced再将消息发送出去
@@ -429,6 +473,11 @@ func (s *watchableStore) addVictim(victim watcherBatch) {
 	if victim == nil {
 		return
 	}
+	for w, eb := range victim {
+		if w.queue != nil {
+			atomic.AddInt64(&w.queue.pending, int64(len(eb.evs)))
+		}
+	}
 	s.victims = append(s.victims, victim)
 	select {
 	case s.victimc <- struct{}{}:
@@ -451,6 +500,52 @@ func (s *watchableStore) progress(w *watcher) {
 	}
 }
 
+// forceProgress 向 w 发送进度通知, 未同步的watcher也会收到; w.ch 已满时记下, 稍后重试
+func (s *watchableStore) forceProgress(w *watcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.sendProgress(w) {
+		s.progressOwed[w] = struct{}{}
+	}
+}
+
+// retryProgress 重新发送之前因 w.ch 已满而没有发出的进度通知
+func (s *watchableStore) retryProgress() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w := range s.progressOwed {
+		if s.sendProgress(w) {
+			delete(s.progressOwed, w)
+		}
+	}
+}
+
+// sendProgress 尝试向 w 发送进度通知, 返回false表示需要稍后重试. 调用方需持有 s.mu
+func (s *watchableStore) sendProgress(w *watcher) bool {
+	if w.ch == nil || w.compacted || w.resumeRev != 0 {
+		// watcher已被取消, 不再需要通知
+		return true
+	}
+	if w.victim {
+		// 还有没发出的事件, 等它们发出后再通知
+		return false
+	}
+
+	rev := s.rev()
+	if _, ok := s.synced.watchers[w]; !ok && w.minRev-1 < rev {
+		// 未同步的watcher只保证收到了 minRev 之前的事件
+		rev = w.minRev - 1
+	}
+	select {
+	case w.ch <- WatchResponse{WatchID: w.id, Revision: rev}:
+		return true
+	default:
+		return false
+	}
+}
+
 type watcher struct {
 	key       string
 	end       string
@@ -463,12 +558,21 @@ type watcher struct {
 	// "unsynced" watcher revision must always be <= current revision,
 	// except when the watcher were to be moved from "synced" watcher group
 	restore     bool
+	resumeRev   int64                // 因stream积压被取消时设置, 客户端应从该修订版本重新watch
+	queue       *streamQueue         // 所在stream的积压统计
 	minRev      int64                // 开始监听的修订版本
 	id          WatchID              // watcher id
 	filterFuncs []FilterFunc         // 事件过滤
 	ch          chan<- WatchResponse // 将变更事件塞进去,可能会与其他watcher 共享
 }
 
+// releasePending 从所在stream的积压中扣除已发送或已丢弃的 eb, 调用方需持有 watchableStore.mu
+func (w *watcher) releasePending(eb *eventBatch) {
+	if w.queue != nil && eb != nil {
+		atomic.AddInt64(&w.queue.pending, -int64(len(eb.evs)))
+	}
+}
+
 // 向客户端发送事件
 func (w *watcher) send(wr WatchResponse) bool {
 	progressEvent := len(wr.Events) == 0
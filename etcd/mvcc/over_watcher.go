@@ -51,6 +51,10 @@ type WatchStream interface {
 	// of the watchers since the watcher is currently synced.
 	RequestProgress(id WatchID)
 
+	// RequestWatchProgress 与 RequestProgress 类似, 但即使watcher尚未同步也会发送进度通知.
+	// 通知中的修订版本之前该watcher的事件都已先于通知进入 Chan; channel已满时稍后重试.
+	RequestWatchProgress(id WatchID) error
+
 	// Cancel cancels a watcher by giving its ID. If watcher does not exist, an error will be
 	// returned.
 	Cancel(id WatchID) error
@@ -58,6 +62,8 @@ type WatchStream interface {
 	// Close closes Chan and release all related resources.
 	Close()
 	Rev() int64 // 返回当前watch指定的修订版本
+
+	Stats() WatchStreamStats // 返回当前stream的积压情况
 }
 
 type WatchResponse struct {
@@ -76,6 +82,9 @@ type WatchResponse struct {
 
 	// CompactRevision is set when the watcher is cancelled due to compaction.
 	CompactRevision int64
+
+	// ResumeRevision 在watcher因stream积压过多被取消时设置, 从该修订版本重新watch即可收到被丢弃的事件
+	ResumeRevision int64
 }
 
 // watchStream contains a collection of watchers that share
@@ -89,6 +98,7 @@ type watchStream struct {
 	closed    bool
 	cancels   map[WatchID]cancelFunc // 用于取消特定的watcher
 	watchers  map[WatchID]*watcher   // 记录watcher事件及其Id
+	queue     *streamQueue           // 积压在victims中的事件, 由当前stream的所有watcher共享
 }
 
 // Watch 在当前stream创建watcher并返回 WatchID.
@@ -116,7 +126,7 @@ func (ws *watchStream) Watch(id WatchID, key, end []byte, startRev int64, fcs ..
 		return -1, ErrWatcherDuplicateID
 	}
 
-	w, c := ws.watchable.watch(key, end, startRev, id, ws.ch, fcs...)
+	w, c := ws.watchable.watch(key, end, startRev, id, ws.ch, ws.queue, fcs...)
 	ws.cancels[id] = c  // 回调函数用于删除watcher
 	ws.watchers[id] = w // 记录watcher事件及其Id
 	return id, nil
@@ -168,6 +178,10 @@ func (ws *watchStream) Rev() int64 {
 	return ws.watchable.rev()
 }
 
+func (ws *watchStream) Stats() WatchStreamStats {
+	return ws.queue.stats()
+}
+
 func (ws *watchStream) RequestProgress(id WatchID) {
 	ws.mu.Lock()
 	w, ok := ws.watchers[id]
@@ -177,3 +191,14 @@ func (ws *watchStream) RequestProgress(id WatchID) {
 	}
 	ws.watchable.progress(w)
 }
+
+func (ws *watchStream) RequestWatchProgress(id WatchID) error {
+	ws.mu.Lock()
+	w, ok := ws.watchers[id]
+	ws.mu.Unlock()
+	if !ok {
+		return ErrWatcherNotExist
+	}
+	ws.watchable.forceProgress(w)
+	return nil
+}
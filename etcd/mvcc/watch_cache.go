@@ -0,0 +1,86 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import (
+	"sort"
+
+	"github.com/ls-2018/etcd_cn/offical/api/v3/mvccpb"
+)
+
+// watchCache 缓存最近 window 个修订版本的全部事件; 所有未同步的watcher共享这份缓存,
+// syncWatchers 在缓存能覆盖时不再从后端读取历史, 否则回退到后端.
+// 受 watchableStore.mu 保护.
+type watchCache struct {
+	window   int64          // 缓存的修订版本数, 0表示不缓存
+	evs      []mvccpb.Event // 按修订版本递增
+	firstRev int64          // 缓存包含 [firstRev, lastRev] 之间的全部事件
+	lastRev  int64
+}
+
+func newWatchCache(window int64, curRev int64) *watchCache {
+	c := &watchCache{window: window}
+	c.reset(curRev)
+	return c
+}
+
+// reset 清空缓存, 之后只缓存 curRev 之后的事件
+func (c *watchCache) reset(curRev int64) {
+	c.evs = nil
+	c.firstRev = curRev + 1
+	c.lastRev = curRev
+}
+
+// add 记录修订版本 rev 的事件, 并淘汰窗口之外的事件
+func (c *watchCache) add(rev int64, evs []mvccpb.Event) {
+	if c.window <= 0 {
+		return
+	}
+	if rev != c.lastRev+1 {
+		// 修订版本不连续, 之前缓存的事件不再完整
+		c.reset(rev - 1)
+	}
+	c.evs = append(c.evs, evs...)
+	c.lastRev = rev
+
+	minRev := rev - c.window + 1
+	if c.firstRev >= minRev {
+		return
+	}
+	i := sort.Search(len(c.evs), func(i int) bool { return c.evs[i].Kv.ModRevision >= minRev })
+	if i > len(c.evs)/2 {
+		// 释放被淘汰的事件
+		c.evs = append([]mvccpb.Event(nil), c.evs[i:]...)
+	} else {
+		c.evs = c.evs[i:]
+	}
+	c.firstRev = minRev
+}
+
+// covers 缓存是否包含 [minRev, curRev] 之间的全部事件
+func (c *watchCache) covers(minRev, curRev int64) bool {
+	return c.window > 0 && c.lastRev == curRev && c.firstRev <= minRev
+}
+
+// eventsFrom 返回修订版本不小于 minRev 且被 wg 中的watcher关注的事件
+func (c *watchCache) eventsFrom(wg *watcherGroup, minRev int64) (evs []mvccpb.Event) {
+	i := sort.Search(len(c.evs), func(i int) bool { return c.evs[i].Kv.ModRevision >= minRev })
+	for _, ev := range c.evs[i:] {
+		if wg.contains(ev.Kv.Key) {
+			evs = append(evs, ev)
+		}
+	}
+	return evs
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mvcc
+
+import "sync/atomic"
+
+// SlowWatcherPolicy 决定一个watch stream积压的事件超过上限后, 如何处理其中发送失败的watcher
+type SlowWatcherPolicy string
+
+const (
+	// SlowWatcherBlock 不再为该watcher缓存事件, 让它回到unsynced, 等stream被消费后再从缓存或后端补发;
+	// 事件不会丢失, 该watcher的进度取决于客户端的消费速度
+	SlowWatcherBlock SlowWatcherPolicy = "block"
+	// SlowWatcherCancel 丢弃该watcher积压的事件并取消它, 通过 WatchResponse.ResumeRevision
+	// 告诉客户端从哪个修订版本重新watch
+	SlowWatcherCancel SlowWatcherPolicy = "cancel"
+)
+
+// WatchStreamStats 是一个watch stream的积压情况
+type WatchStreamStats struct {
+	Pending  int64 // victims中尚未发送的事件数
+	Blocked  int64 // 因积压超限被退回unsynced的次数
+	Canceled int64 // 因积压超限被取消的watcher数
+}
+
+// streamQueue 统计一个watch stream积压在victims中的事件.
+// 只在持有 watchableStore.mu 时修改, 可以随时原子读取.
+type streamQueue struct {
+	pending  int64
+	blocked  int64
+	canceled int64
+}
+
+func (q *streamQueue) stats() WatchStreamStats {
+	return WatchStreamStats{
+		Pending:  atomic.LoadInt64(&q.pending),
+		Blocked:  atomic.LoadInt64(&q.blocked),
+		Canceled: atomic.LoadInt64(&q.canceled),
+	}
+}
+
+// overflow 在 w 的事件发送失败、w 已从watcherGroup中移除时调用. 如果把 eb 放入victims会让
+// w 所在stream的积压超过上限, 则按策略处理 w 并返回true, 调用方不再把 eb 放入victims.
+// 调用方需持有 s.mu.
+func (s *watchableStore) overflow(w *watcher, eb *eventBatch) bool {
+	limit := int64(s.store.cfg.WatchStreamMaxPending)
+	q := w.queue
+	if limit <= 0 || q == nil || q.pending+int64(len(eb.evs)) <= limit {
+		return false
+	}
+
+	// 第一个没有发送的事件
+	rev := eb.evs[0].Kv.ModRevision
+	if s.store.cfg.SlowWatcherPolicy == SlowWatcherCancel {
+		w.resumeRev = rev
+		w.victim = true
+		atomic.AddInt64(&q.canceled, 1)
+		// 取消通知同样可能发送失败, 交给victims重试; 它不携带事件, 不计入积压
+		s.addVictim(watcherBatch{w: &eventBatch{}})
+		return true
+	}
+
+	w.minRev = rev
+	s.unsynced.add(w)
+	atomic.AddInt64(&q.blocked, 1)
+	return true
+}
@@ -95,3 +95,27 @@ func (s *as2ac) UserRevokeRole(ctx context.Context, in *pb.AuthUserRevokeRoleReq
 func (s *as2ac) UserChangePassword(ctx context.Context, in *pb.AuthUserChangePasswordRequest, opts ...grpc.CallOption) (*pb.AuthUserChangePasswordResponse, error) {
 	return s.as.UserChangePassword(ctx, in)
 }
+
+func (s *as2ac) UserTokenCreate(ctx context.Context, in *pb.AuthUserTokenCreateRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenCreateResponse, error) {
+	return s.as.UserTokenCreate(ctx, in)
+}
+
+func (s *as2ac) UserTokenList(ctx context.Context, in *pb.AuthUserTokenListRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenListResponse, error) {
+	return s.as.UserTokenList(ctx, in)
+}
+
+func (s *as2ac) UserTokenRevoke(ctx context.Context, in *pb.AuthUserTokenRevokeRequest, opts ...grpc.CallOption) (*pb.AuthUserTokenRevokeResponse, error) {
+	return s.as.UserTokenRevoke(ctx, in)
+}
+
+func (s *as2ac) AuthCheck(ctx context.Context, in *pb.AuthCheckRequest, opts ...grpc.CallOption) (*pb.AuthCheckResponse, error) {
+	return s.as.AuthCheck(ctx, in)
+}
+
+func (s *as2ac) UserSetRateLimit(ctx context.Context, in *pb.AuthUserSetRateLimitRequest, opts ...grpc.CallOption) (*pb.AuthUserSetRateLimitResponse, error) {
+	return s.as.UserSetRateLimit(ctx, in)
+}
+
+func (s *as2ac) RoleSetRateLimit(ctx context.Context, in *pb.AuthRoleSetRateLimitRequest, opts ...grpc.CallOption) (*pb.AuthRoleSetRateLimitResponse, error) {
+	return s.as.RoleSetRateLimit(ctx, in)
+}
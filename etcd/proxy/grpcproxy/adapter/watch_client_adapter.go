@@ -37,6 +37,13 @@ func (s *ws2wc) Watch(ctx context.Context, opts ...grpc.CallOption) (pb.Watch_Wa
 	return &ws2wcClientStream{cs}, nil
 }
 
+func (s *ws2wc) ListWatch(ctx context.Context, in *pb.ListWatchRequest, opts ...grpc.CallOption) (pb.Watch_ListWatchClient, error) {
+	cs := newPipeStream(ctx, func(ss chanServerStream) error {
+		return s.wserv.ListWatch(in, &lw2lwcServerStream{ss})
+	})
+	return &lw2lwcClientStream{cs}, nil
+}
+
 // ws2wcClientStream implements Watch_WatchClient
 type ws2wcClientStream struct{ chanClientStream }
 
@@ -66,3 +73,21 @@ func (s *ws2wcServerStream) Recv() (*pb.WatchRequest, error) {
 	}
 	return v.(*pb.WatchRequest), nil
 }
+
+// lw2lwcClientStream implements Watch_ListWatchClient
+type lw2lwcClientStream struct{ chanClientStream }
+
+// lw2lwcServerStream implements Watch_ListWatchServer
+type lw2lwcServerStream struct{ chanServerStream }
+
+func (s *lw2lwcClientStream) Recv() (*pb.ListWatchResponse, error) {
+	var v interface{}
+	if err := s.RecvMsg(&v); err != nil {
+		return nil, err
+	}
+	return v.(*pb.ListWatchResponse), nil
+}
+
+func (s *lw2lwcServerStream) Send(lr *pb.ListWatchResponse) error {
+	return s.SendMsg(lr)
+}
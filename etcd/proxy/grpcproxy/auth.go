@@ -114,3 +114,33 @@ func (ap *AuthProxy) UserChangePassword(ctx context.Context, r *pb.AuthUserChang
 	conn := ap.client.ActiveConnection()
 	return pb.NewAuthClient(conn).UserChangePassword(ctx, r)
 }
+
+func (ap *AuthProxy) UserTokenCreate(ctx context.Context, r *pb.AuthUserTokenCreateRequest) (*pb.AuthUserTokenCreateResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).UserTokenCreate(ctx, r)
+}
+
+func (ap *AuthProxy) UserTokenList(ctx context.Context, r *pb.AuthUserTokenListRequest) (*pb.AuthUserTokenListResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).UserTokenList(ctx, r)
+}
+
+func (ap *AuthProxy) UserTokenRevoke(ctx context.Context, r *pb.AuthUserTokenRevokeRequest) (*pb.AuthUserTokenRevokeResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).UserTokenRevoke(ctx, r)
+}
+
+func (ap *AuthProxy) AuthCheck(ctx context.Context, r *pb.AuthCheckRequest) (*pb.AuthCheckResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).AuthCheck(ctx, r)
+}
+
+func (ap *AuthProxy) UserSetRateLimit(ctx context.Context, r *pb.AuthUserSetRateLimitRequest) (*pb.AuthUserSetRateLimitResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).UserSetRateLimit(ctx, r)
+}
+
+func (ap *AuthProxy) RoleSetRateLimit(ctx context.Context, r *pb.AuthRoleSetRateLimitRequest) (*pb.AuthRoleSetRateLimitResponse, error) {
+	conn := ap.client.ActiveConnection()
+	return pb.NewAuthClient(conn).RoleSetRateLimit(ctx, r)
+}
@@ -203,6 +203,21 @@ func PutRequestToOp(r *pb.PutRequest) clientv3.Op {
 	if r.PrevKv {
 		opts = append(opts, clientv3.WithPrevKV())
 	}
+	if r.IfAbsent {
+		opts = append(opts, clientv3.WithIfAbsent())
+	}
+	if r.IfModRevision != 0 {
+		opts = append(opts, clientv3.WithIfModRevision(r.IfModRevision))
+	}
+	if len(r.IfValueHash) != 0 {
+		opts = append(opts, clientv3.WithIfValueHash(r.IfValueHash))
+	}
+	if r.ValueRef != nil {
+		opts = append(opts, clientv3.WithValueFrom(int(r.ValueRef.OpIndex)))
+	}
+	if r.Increment != nil {
+		return clientv3.OpIncrement(string(r.Key), r.Increment.Delta, opts...)
+	}
 	return clientv3.OpPut(string(r.Key), string(r.Value), opts...)
 }
 
@@ -94,3 +94,8 @@ func (mp *maintenanceProxy) Downgrade(ctx context.Context, r *pb.DowngradeReques
 	conn := mp.client.ActiveConnection()
 	return pb.NewMaintenanceClient(conn).Downgrade(ctx, r)
 }
+
+func (mp *maintenanceProxy) MaintenanceMode(ctx context.Context, r *pb.MaintenanceModeRequest) (*pb.MaintenanceModeResponse, error) {
+	conn := mp.client.ActiveConnection()
+	return pb.NewMaintenanceClient(conn).MaintenanceMode(ctx, r)
+}
@@ -16,6 +16,7 @@ package grpcproxy
 
 import (
 	"context"
+	"io"
 	"sync"
 
 	clientv3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
@@ -47,6 +48,9 @@ type watchProxy struct {
 	// kv is used for permission checking
 	kv clientv3.KV
 	lg *zap.Logger
+
+	// client forwards list-watch requests, which are not shared between clients
+	client *clientv3.Client
 }
 
 func NewWatchProxy(ctx context.Context, lg *zap.Logger, c *clientv3.Client) (pb.WatchServer, <-chan struct{}) {
@@ -58,6 +62,8 @@ func NewWatchProxy(ctx context.Context, lg *zap.Logger, c *clientv3.Client) (pb.
 
 		kv: c.KV, // for permission checking
 		lg: lg,
+
+		client: c,
 	}
 	wp.ranges = newWatchRanges(wp)
 	ch := make(chan struct{})
@@ -165,6 +171,34 @@ func (wp *watchProxy) Watch(stream pb.Watch_WatchServer) (err error) {
 	}
 }
 
+// ListWatch forwards the request to the etcd server; each list-watch reads its own
+// consistent snapshot, so unlike Watch it is not coalesced with other clients.
+func (wp *watchProxy) ListWatch(req *pb.ListWatchRequest, stream pb.Watch_ListWatchServer) error {
+	conn := wp.client.ActiveConnection()
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	ctx = withClientAuthToken(ctx, stream.Context())
+
+	lc, err := pb.NewWatchClient(conn).ListWatch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for {
+		resp, err := lc.Recv()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err = stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
 // watchProxyStream forwards etcd watch events to a proxied client stream.
 type watchProxyStream struct {
 	ranges *watchRanges
@@ -247,6 +281,18 @@ func (wps *watchProxyStream) recvLoop() error {
 				continue
 			}
 
+			filters, err := v3rpc.FiltersFromRequest(cr)
+			if err != nil {
+				wps.watchCh <- &pb.WatchResponse{
+					Header:       &pb.ResponseHeader{},
+					WatchId:      -1,
+					Created:      true,
+					Canceled:     true,
+					CancelReason: rpctypes.ErrGRPCInvalidWatchFilter.Error(),
+				}
+				continue
+			}
+
 			wps.mu.Lock()
 			w := &watcher{
 				wr:  watchRange{string(cr.Key), string(cr.RangeEnd)},
@@ -256,7 +302,7 @@ func (wps *watchProxyStream) recvLoop() error {
 				nextrev:  cr.StartRevision,
 				progress: cr.ProgressNotify,
 				prevKV:   cr.PrevKv,
-				filters:  v3rpc.FiltersFromRequest(cr),
+				filters:  filters,
 			}
 			if !w.wr.valid() {
 				w.post(&pb.WatchResponse{WatchId: -1, Created: true, Canceled: true})
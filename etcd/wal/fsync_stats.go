@@ -0,0 +1,64 @@
+// Copyright 2015 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wal
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	fsyncSamples      = 512         // 最多保留的 fsync 耗时样本数
+	fsyncSampleWindow = time.Minute // 只统计这段时间内的样本
+)
+
+type fsyncSample struct {
+	at   time.Time
+	took time.Duration
+}
+
+// fsyncStats 记录最近的 fsync 耗时, 用于 SLOWDISK 警报
+var fsyncStats struct {
+	mu      sync.Mutex
+	samples [fsyncSamples]fsyncSample
+	next    int
+}
+
+func recordFsync(at time.Time, took time.Duration) {
+	fsyncStats.mu.Lock()
+	fsyncStats.samples[fsyncStats.next] = fsyncSample{at: at, took: took}
+	fsyncStats.next = (fsyncStats.next + 1) % fsyncSamples
+	fsyncStats.mu.Unlock()
+}
+
+// RecentFsyncP99 返回最近一分钟内(最多512次) WAL fsync 耗时的 p99 以及样本数
+func RecentFsyncP99() (time.Duration, int) {
+	since := time.Now().Add(-fsyncSampleWindow)
+	durations := make([]time.Duration, 0, fsyncSamples)
+	fsyncStats.mu.Lock()
+	for _, s := range fsyncStats.samples {
+		if s.at.After(since) {
+			durations = append(durations, s.took)
+		}
+	}
+	fsyncStats.mu.Unlock()
+
+	if len(durations) == 0 {
+		return 0, 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	return durations[(len(durations)*99+99)/100-1], len(durations)
+}
@@ -756,6 +756,7 @@ func (w *WAL) sync() error {
 	err := fileutil.Fdatasync(w.tail().File)
 
 	took := time.Since(start)
+	recordFsync(start, took)
 	if took > warnSyncDuration {
 		w.lg.Warn("缓慢 fdatasync", zap.Duration("took", took), zap.Duration("expected-duration", warnSyncDuration))
 	}
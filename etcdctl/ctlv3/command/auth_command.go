@@ -15,8 +15,10 @@
 package command
 
 import (
+	"context"
 	"fmt"
 
+	clientv3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
 	"github.com/ls-2018/etcd_cn/offical/api/v3/v3rpc/rpctypes"
 	"github.com/ls-2018/etcd_cn/pkg/cobrautl"
 	"github.com/spf13/cobra"
@@ -32,6 +34,9 @@ func NewAuthCommand() *cobra.Command {
 	ac.AddCommand(newAuthEnableCommand())
 	ac.AddCommand(newAuthDisableCommand())
 	ac.AddCommand(newAuthStatusCommand())
+	ac.AddCommand(newAuthCanICommand())
+	ac.AddCommand(newAuthExportCommand())
+	ac.AddCommand(newAuthApplyCommand())
 
 	return ac
 }
@@ -121,3 +126,36 @@ func authDisableCommandFunc(cmd *cobra.Command, args []string) {
 
 	fmt.Println("身份验证禁用")
 }
+
+func newAuthCanICommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "can-i [options] <user name> <permission type> <key> [endkey]",
+		Short: "检查用户是否拥有某个范围的权限, 并说明是哪些角色/权限允许或拒绝了请求",
+		Run:   authCanICommandFunc,
+	}
+
+	cmd.Flags().BoolVar(&rolePermPrefix, "prefix", false, "检查前缀权限")
+	cmd.Flags().BoolVar(&rolePermFromKey, "from-key", false, "使用byte compare检查大于或等于给定键的权限")
+
+	return cmd
+}
+
+// authCanICommandFunc executes the "auth can-i" command.
+func authCanICommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 3 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth can-i命令需要用户名、权限类型和关键字[endkey]作为参数"))
+	}
+
+	permType, err := clientv3.StrToPermissionType(args[1])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	key, rangeEnd := permRange(args[2:])
+
+	resp, err := mustClientFromCmd(cmd).Auth.AuthCheck(context.TODO(), args[0], key, rangeEnd, permType)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.AuthCheck(args[0], *resp)
+}
@@ -0,0 +1,477 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	clientv3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+	"github.com/ls-2018/etcd_cn/pkg/cobrautl"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	authApplyFile   string
+	authApplyDryRun bool
+)
+
+// authConfig 是用户/角色/权限模型的声明式描述, 可以保存在git中
+type authConfig struct {
+	Users []authUserConfig `json:"users,omitempty"`
+	Roles []authRoleConfig `json:"roles,omitempty"`
+}
+
+type authUserConfig struct {
+	Name string `json:"name"`
+	// Password 只在创建用户时使用, 不会修改已有用户的密码; export 不会导出密码
+	Password   string               `json:"password,omitempty"`
+	NoPassword bool                 `json:"no-password,omitempty"`
+	Roles      []string             `json:"roles,omitempty"`
+	RateLimit  *authRateLimitConfig `json:"rate-limit,omitempty"`
+}
+
+type authRoleConfig struct {
+	Name        string               `json:"name"`
+	Permissions []authPermConfig     `json:"permissions,omitempty"`
+	RateLimit   *authRateLimitConfig `json:"rate-limit,omitempty"`
+}
+
+// authRateLimitConfig 与 user/role set-rate-limit 的参数一致
+type authRateLimitConfig struct {
+	RequestsPerSec uint64 `json:"requests-per-sec,omitempty"`
+	BytesPerSec    uint64 `json:"bytes-per-sec,omitempty"`
+}
+
+// authPermConfig 与 role grant-permission 的参数一致; prefix 和 from-key 互斥, 都不指定时使用 range-end
+type authPermConfig struct {
+	Type     string `json:"type"`
+	Key      string `json:"key"`
+	RangeEnd string `json:"range-end,omitempty"`
+	Prefix   bool   `json:"prefix,omitempty"`
+	FromKey  bool   `json:"from-key,omitempty"`
+}
+
+func newAuthExportCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "export",
+		Short: "以YAML格式导出所有用户、角色和权限",
+		Run:   authExportCommandFunc,
+	}
+}
+
+func newAuthApplyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply -f <file>",
+		Short: "将集群的用户、角色和权限调整为与YAML文件一致(创建、更新、删除)",
+		Run:   authApplyCommandFunc,
+	}
+
+	cmd.Flags().StringVarP(&authApplyFile, "file", "f", "", "期望的认证配置文件, 格式与 auth export 的输出相同")
+	cmd.Flags().BoolVar(&authApplyDryRun, "dry-run", false, "只打印差异, 不修改集群")
+
+	return cmd
+}
+
+// authExportCommandFunc executes the "auth export" command.
+func authExportCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth export命令不接受任何参数"))
+	}
+
+	cfg, err := getAuthConfig(mustClientFromCmd(cmd))
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	b, err := yaml.Marshal(cfg)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	fmt.Print(string(b))
+}
+
+// authApplyCommandFunc executes the "auth apply" command.
+func authApplyCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 0 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth apply命令不接受任何参数"))
+	}
+	if authApplyFile == "" {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("auth apply命令需要 -f 指定配置文件"))
+	}
+
+	b, err := ioutil.ReadFile(authApplyFile)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	var desired authConfig
+	if err = yaml.UnmarshalStrict(b, &desired); err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	cli := mustClientFromCmd(cmd)
+	current, err := getAuthConfig(cli)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	steps, err := diffAuthConfig(current, &desired)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("认证配置没有变化")
+		return
+	}
+	for _, s := range steps {
+		fmt.Println(s.desc)
+		if authApplyDryRun {
+			continue
+		}
+		if err = s.do(cli); err != nil {
+			cobrautl.ExitWithError(cobrautl.ExitError, fmt.Errorf("%s: %v", s.desc, err))
+		}
+	}
+	if authApplyDryRun {
+		fmt.Printf("dry-run: %d 项变更未执行\n", len(steps))
+	}
+}
+
+// getAuthConfig 通过 UserList/UserGet/RoleList/RoleGet 读取集群当前的认证配置
+func getAuthConfig(cli *clientv3.Client) (*authConfig, error) {
+	cfg := &authConfig{}
+
+	users, err := cli.Auth.UserList(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range users.Users {
+		resp, err := cli.Auth.UserGet(context.TODO(), name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Users = append(cfg.Users, userToConfig(name, resp))
+	}
+
+	roles, err := cli.Auth.RoleList(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range roles.Roles {
+		resp, err := cli.Auth.RoleGet(context.TODO(), name)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Roles = append(cfg.Roles, roleToConfig(name, resp))
+	}
+
+	sort.Slice(cfg.Users, func(i, j int) bool { return cfg.Users[i].Name < cfg.Users[j].Name })
+	sort.Slice(cfg.Roles, func(i, j int) bool { return cfg.Roles[i].Name < cfg.Roles[j].Name })
+	return cfg, nil
+}
+
+// userToConfig 导出不包含密码; 没有密码的用户导出 no-password, 使导出的文件可以直接用于创建用户
+func userToConfig(name string, resp *clientv3.AuthUserGetResponse) authUserConfig {
+	roles := append([]string(nil), resp.Roles...)
+	sort.Strings(roles)
+	return authUserConfig{
+		Name:       name,
+		NoPassword: resp.Options != nil && resp.Options.NoPassword,
+		Roles:      roles,
+		RateLimit:  rateLimitToConfig(resp.RateLimit),
+	}
+}
+
+func roleToConfig(name string, resp *clientv3.AuthRoleGetResponse) authRoleConfig {
+	rc := authRoleConfig{Name: name, RateLimit: rateLimitToConfig(resp.RateLimit)}
+	for _, perm := range resp.Perm {
+		rc.Permissions = append(rc.Permissions, permToConfig(perm))
+	}
+	return rc
+}
+
+// permToConfig 与 permRange 相反, 尽量还原为 --prefix/--from-key 的形式
+func permToConfig(perm *authpb.Permission) authPermConfig {
+	pc := authPermConfig{Type: strings.ToLower(perm.PermType.String()), Key: perm.Key}
+	switch {
+	case perm.Key == "\x00" && perm.RangeEnd == "\x00":
+		pc.Key, pc.Prefix = "", true
+	case perm.RangeEnd == "\x00":
+		pc.FromKey = true
+	case len(perm.RangeEnd) != 0 && perm.RangeEnd == clientv3.GetPrefixRangeEnd(perm.Key):
+		pc.Prefix = true
+	default:
+		pc.RangeEnd = perm.RangeEnd
+	}
+	return pc
+}
+
+// configToPerm 与 permRange 的规则一致
+func configToPerm(role string, pc authPermConfig) (*authpb.Permission, error) {
+	permType, err := clientv3.StrToPermissionType(pc.Type)
+	if err != nil {
+		return nil, fmt.Errorf("角色 %s: %v", role, err)
+	}
+	if pc.Prefix && pc.FromKey {
+		return nil, fmt.Errorf("角色 %s: prefix 和 from-key 是互相排斥的", role)
+	}
+	if (pc.Prefix || pc.FromKey) && pc.RangeEnd != "" {
+		return nil, fmt.Errorf("角色 %s: 指定 prefix 或 from-key 时不能指定 range-end", role)
+	}
+
+	perm := &authpb.Permission{PermType: authpb.Permission_Type(permType), Key: pc.Key, RangeEnd: pc.RangeEnd}
+	switch {
+	case len(pc.Key) == 0:
+		perm.Key = "\x00"
+		if pc.Prefix || pc.FromKey {
+			perm.RangeEnd = "\x00"
+		}
+	case pc.Prefix:
+		perm.RangeEnd = clientv3.GetPrefixRangeEnd(pc.Key)
+	case pc.FromKey:
+		perm.RangeEnd = "\x00"
+	}
+	return perm, nil
+}
+
+func permDesc(perm *authpb.Permission) string {
+	return fmt.Sprintf("%s [%q, %q)", perm.PermType, perm.Key, perm.RangeEnd)
+}
+
+func rateLimitToConfig(l *authpb.RateLimit) *authRateLimitConfig {
+	if l == nil {
+		return nil
+	}
+	return &authRateLimitConfig{RequestsPerSec: l.RequestsPerSec, BytesPerSec: l.BytesPerSec}
+}
+
+func rateLimitFromConfig(l *authRateLimitConfig) *clientv3.RateLimit {
+	if l == nil {
+		return nil
+	}
+	return &clientv3.RateLimit{RequestsPerSec: l.RequestsPerSec, BytesPerSec: l.BytesPerSec}
+}
+
+// rateLimitEqual 全为0的限制等同于不限制
+func rateLimitEqual(a, b *authRateLimitConfig) bool {
+	var ar, ab, br, bb uint64
+	if a != nil {
+		ar, ab = a.RequestsPerSec, a.BytesPerSec
+	}
+	if b != nil {
+		br, bb = b.RequestsPerSec, b.BytesPerSec
+	}
+	return ar == br && ab == bb
+}
+
+func rateLimitDesc(l *authRateLimitConfig) string {
+	if l == nil || (l.RequestsPerSec == 0 && l.BytesPerSec == 0) {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d requests/s, %d bytes/s", l.RequestsPerSec, l.BytesPerSec)
+}
+
+type authApplyStep struct {
+	desc string
+	do   func(cli *clientv3.Client) error
+}
+
+// diffAuthConfig 计算从 current 调整到 desired 所需的操作, 顺序为:
+// 新建角色 -> 调整角色权限 -> 新建用户 -> 调整用户角色 -> 删除多余的用户 -> 删除多余的角色.
+// root 用户和 root 角色不会被删除, 以免把自己锁在集群之外.
+func diffAuthConfig(current, desired *authConfig) ([]authApplyStep, error) {
+	var steps []authApplyStep
+
+	curRoles := make(map[string]authRoleConfig)
+	for _, r := range current.Roles {
+		curRoles[r.Name] = r
+	}
+	wantRoles := make(map[string]bool)
+	for _, r := range desired.Roles {
+		if wantRoles[r.Name] {
+			return nil, fmt.Errorf("角色 %s 重复定义", r.Name)
+		}
+		wantRoles[r.Name] = true
+
+		role := r.Name
+		cur, exist := curRoles[role]
+		if !exist {
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("+ role %s", role),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.RoleAdd(context.TODO(), role)
+					return err
+				},
+			})
+		}
+
+		// 权限以 key+range_end 标识, 类型不同时重新授予即可覆盖
+		curPerms := make(map[string]*authpb.Permission)
+		for _, pc := range cur.Permissions {
+			perm, err := configToPerm(role, pc)
+			if err != nil {
+				return nil, err
+			}
+			curPerms[perm.Key+"\x00"+perm.RangeEnd] = perm
+		}
+		wantPerms := make(map[string]bool)
+		for _, pc := range r.Permissions {
+			perm, err := configToPerm(role, pc)
+			if err != nil {
+				return nil, err
+			}
+			id := perm.Key + "\x00" + perm.RangeEnd
+			wantPerms[id] = true
+			if old, ok := curPerms[id]; ok && old.PermType == perm.PermType {
+				continue
+			}
+			desc := fmt.Sprintf("+ role %s permission %s", role, permDesc(perm))
+			if old, ok := curPerms[id]; ok {
+				desc = fmt.Sprintf("~ role %s permission %s -> %s", role, permDesc(old), perm.PermType)
+			}
+			steps = append(steps, authApplyStep{
+				desc: desc,
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.RoleGrantPermission(context.TODO(), role, perm.Key, perm.RangeEnd, clientv3.PermissionType(perm.PermType))
+					return err
+				},
+			})
+		}
+		for _, pc := range cur.Permissions {
+			perm, _ := configToPerm(role, pc)
+			if wantPerms[perm.Key+"\x00"+perm.RangeEnd] {
+				continue
+			}
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("- role %s permission %s", role, permDesc(perm)),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.RoleRevokePermission(context.TODO(), role, perm.Key, perm.RangeEnd)
+					return err
+				},
+			})
+		}
+		if !rateLimitEqual(cur.RateLimit, r.RateLimit) {
+			limit := r.RateLimit
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("~ role %s rate-limit %s -> %s", role, rateLimitDesc(cur.RateLimit), rateLimitDesc(limit)),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.RoleSetRateLimit(context.TODO(), role, rateLimitFromConfig(limit))
+					return err
+				},
+			})
+		}
+	}
+
+	curUsers := make(map[string]authUserConfig)
+	for _, u := range current.Users {
+		curUsers[u.Name] = u
+	}
+	wantUsers := make(map[string]bool)
+	for _, u := range desired.Users {
+		if wantUsers[u.Name] {
+			return nil, fmt.Errorf("用户 %s 重复定义", u.Name)
+		}
+		wantUsers[u.Name] = true
+
+		user := u
+		cur, exist := curUsers[user.Name]
+		if !exist {
+			if user.Password == "" && !user.NoPassword {
+				return nil, fmt.Errorf("用户 %s 不存在, 创建时需要指定 password 或 no-password", user.Name)
+			}
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("+ user %s", user.Name),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.UserAddWithOptions(context.TODO(), user.Name, user.Password, &clientv3.UserAddOptions{NoPassword: user.NoPassword})
+					return err
+				},
+			})
+		}
+
+		curUserRoles := make(map[string]bool)
+		for _, role := range cur.Roles {
+			curUserRoles[role] = true
+		}
+		wantUserRoles := make(map[string]bool)
+		for _, role := range user.Roles {
+			role := role
+			wantUserRoles[role] = true
+			if curUserRoles[role] {
+				continue
+			}
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("+ user %s role %s", user.Name, role),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.UserGrantRole(context.TODO(), user.Name, role)
+					return err
+				},
+			})
+		}
+		for _, role := range cur.Roles {
+			role := role
+			if wantUserRoles[role] {
+				continue
+			}
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("- user %s role %s", user.Name, role),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.UserRevokeRole(context.TODO(), user.Name, role)
+					return err
+				},
+			})
+		}
+		if !rateLimitEqual(cur.RateLimit, user.RateLimit) {
+			steps = append(steps, authApplyStep{
+				desc: fmt.Sprintf("~ user %s rate-limit %s -> %s", user.Name, rateLimitDesc(cur.RateLimit), rateLimitDesc(user.RateLimit)),
+				do: func(cli *clientv3.Client) error {
+					_, err := cli.Auth.UserSetRateLimit(context.TODO(), user.Name, rateLimitFromConfig(user.RateLimit))
+					return err
+				},
+			})
+		}
+	}
+
+	for _, u := range current.Users {
+		name := u.Name
+		if wantUsers[name] || name == "root" {
+			continue
+		}
+		steps = append(steps, authApplyStep{
+			desc: fmt.Sprintf("- user %s", name),
+			do: func(cli *clientv3.Client) error {
+				_, err := cli.Auth.UserDelete(context.TODO(), name)
+				return err
+			},
+		})
+	}
+	for _, r := range current.Roles {
+		name := r.Name
+		if wantRoles[name] || name == "root" {
+			continue
+		}
+		steps = append(steps, authApplyStep{
+			desc: fmt.Sprintf("- role %s", name),
+			do: func(cli *clientv3.Client) error {
+				_, err := cli.Auth.RoleDelete(context.TODO(), name)
+				return err
+			},
+		})
+	}
+	return steps, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"testing"
+
+	clientv3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
+	"sigs.k8s.io/yaml"
+)
+
+// TestAuthConfigExportApplyRoundTrip 导出的文件应能原样应用: 对原集群没有差异, 在空集群上能重建无密码用户
+func TestAuthConfigExportApplyRoundTrip(t *testing.T) {
+	exported := &authConfig{
+		Users: []authUserConfig{
+			userToConfig("ci", &clientv3.AuthUserGetResponse{
+				Roles:   []string{"reader"},
+				Options: &authpb.UserAddOptions{NoPassword: true},
+			}),
+		},
+		Roles: []authRoleConfig{
+			roleToConfig("reader", &clientv3.AuthRoleGetResponse{
+				Perm: []*authpb.Permission{
+					{PermType: authpb.READ, Key: "app/", RangeEnd: clientv3.GetPrefixRangeEnd("app/")},
+					{PermType: authpb.READWRITE, Key: "cfg"},
+				},
+				RateLimit: &authpb.RateLimit{RequestsPerSec: 10},
+			}),
+		},
+	}
+	if !exported.Users[0].NoPassword {
+		t.Fatalf("expected no-password to be exported for user ci")
+	}
+
+	b, err := yaml.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var desired authConfig
+	if err = yaml.UnmarshalStrict(b, &desired); err != nil {
+		t.Fatal(err)
+	}
+
+	steps, err := diffAuthConfig(exported, &desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		for _, s := range steps {
+			t.Errorf("unexpected step %q", s.desc)
+		}
+	}
+
+	steps, err = diffAuthConfig(&authConfig{}, &desired)
+	if err != nil {
+		t.Fatalf("applying exported config to an empty cluster failed: %v", err)
+	}
+	var created bool
+	for _, s := range steps {
+		if s.desc == "+ user ci" {
+			created = true
+		}
+	}
+	if !created {
+		t.Errorf("expected user ci to be created, got %d steps", len(steps))
+	}
+}
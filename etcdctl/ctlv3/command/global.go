@@ -56,6 +56,7 @@ type GlobalFlags struct {
 
 	User     string
 	Password string
+	Token    string
 
 	Debug bool
 }
@@ -73,6 +74,7 @@ type secureCfg struct {
 type authCfg struct {
 	username string
 	password string
+	token    string
 }
 
 type discoveryCfg struct {
@@ -243,6 +245,7 @@ func newClientCfg(endpoints []string, dialTimeout, keepAliveTime, keepAliveTimeo
 	if acfg != nil {
 		cfg.Username = acfg.username
 		cfg.Password = acfg.password
+		cfg.Token = acfg.token
 	}
 
 	return cfg, nil
@@ -353,7 +356,15 @@ func authCfgFromCmd(cmd *cobra.Command) *authCfg {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
 	}
 
+	tokenFlag, err := cmd.Flags().GetString("token")
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+
 	if userFlag == "" {
+		if tokenFlag != "" {
+			return &authCfg{token: tokenFlag}
+		}
 		return nil
 	}
 
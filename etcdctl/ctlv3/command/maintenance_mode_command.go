@@ -0,0 +1,45 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+	"github.com/ls-2018/etcd_cn/pkg/cobrautl"
+	"github.com/spf13/cobra"
+)
+
+// NewMaintenanceModeCommand returns the cobra command for "maintenance-mode".
+func NewMaintenanceModeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance-mode <get|enable|disable>",
+		Short: "查询或切换集群的只读维护模式",
+		Long: `只读维护模式开启后, 集群拒绝写入key与创建租约, 删除、压缩与撤销租约照常执行.
+该状态经raft复制到所有成员, 重启后仍然有效, 需要显式关闭.`,
+		Run: maintenanceModeCommandFunc,
+	}
+	return cmd
+}
+
+// maintenanceModeCommandFunc executes the "maintenance-mode" command.
+func maintenanceModeCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("maintenance-mode command needs 1 argument"))
+	}
+	action, ok := pb.MaintenanceModeRequest_Action_value[strings.ToUpper(args[0])]
+	if !ok {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("unknown maintenance-mode action %q, expected get, enable or disable", args[0]))
+	}
+
+	ctx, cancel := commandCtx(cmd)
+	resp, err := mustClientFromCmd(cmd).MaintenanceMode(ctx, pb.MaintenanceModeRequest_Action(action))
+	cancel()
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	if resp.ReadOnly {
+		fmt.Println("只读维护模式已开启")
+	} else {
+		fmt.Println("只读维护模式已关闭")
+	}
+}
@@ -43,6 +43,7 @@ var (
 	mmuser         string
 	mmpassword     string
 	mmnodestprefix bool
+	mmpriority     string
 )
 
 // NewMakeMirrorCommand returns the cobra command for "makeMirror".
@@ -62,6 +63,7 @@ func NewMakeMirrorCommand() *cobra.Command {
 	c.Flags().BoolVar(&mminsecureTr, "dest-insecure-transport", true, "为客户端连接禁用传输安全性")
 	c.Flags().StringVar(&mmuser, "dest-user", "", "目标集群的 username[:password]")
 	c.Flags().StringVar(&mmpassword, "dest-password", "", "目标集群的密码")
+	c.Flags().StringVar(&mmpriority, "priority", "", "请求的优先级类别, 如 bulk; 为空时由服务端按请求类型确定")
 
 	return c
 }
@@ -122,7 +124,11 @@ func makeMirrorCommandFunc(cmd *cobra.Command, args []string) {
 	dc := cc.mustClient() // 目标集群
 	c := mustClientFromCmd(cmd)
 
-	err := makeMirror(context.TODO(), c, dc)
+	ctx := context.TODO()
+	if mmpriority != "" {
+		ctx = clientv3.WithPriority(ctx, mmpriority)
+	}
+	err := makeMirror(ctx, c, dc)
 	cobrautl.ExitWithError(cobrautl.ExitError, err)
 }
 
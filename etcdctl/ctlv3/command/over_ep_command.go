@@ -144,6 +144,12 @@ func epHealthCommandFunc(cmd *cobra.Command, args []string) {
 							eh.Error = eh.Error + "NOSPACE "
 						case etcdserverpb.AlarmType_CORRUPT:
 							eh.Error = eh.Error + "CORRUPT "
+						case etcdserverpb.AlarmType_SLOWDISK:
+							eh.Error = eh.Error + "SLOWDISK "
+						case etcdserverpb.AlarmType_CLOCKSKEW:
+							eh.Error = eh.Error + "CLOCKSKEW "
+						case etcdserverpb.AlarmType_APPLYLAG:
+							eh.Error = eh.Error + "APPLYLAG "
 						default:
 							eh.Error = eh.Error + "UNKNOWN "
 						}
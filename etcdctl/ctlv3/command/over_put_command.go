@@ -30,6 +30,10 @@ var (
 	putPrevKV      bool
 	putIgnoreVal   bool
 	putIgnoreLease bool
+
+	putIfAbsent      bool
+	putIfModRevision int64
+	putIfValueHash   string
 )
 
 // NewPutCommand returns the cobra command for "put".
@@ -44,6 +48,9 @@ func NewPutCommand() *cobra.Command {
 	cmd.Flags().BoolVar(&putPrevKV, "prev-kv", false, "返回键值对之前的版本")
 	cmd.Flags().BoolVar(&putIgnoreVal, "ignore-value", false, "更新当前的值")
 	cmd.Flags().BoolVar(&putIgnoreLease, "ignore-lease", false, "更新租约")
+	cmd.Flags().BoolVar(&putIfAbsent, "if-absent", false, "仅当key不存在时写入")
+	cmd.Flags().Int64Var(&putIfModRevision, "if-mod-revision", 0, "仅当key的mod_revision等于该值时写入")
+	cmd.Flags().StringVar(&putIfValueHash, "if-value-hash", "", "仅当key当前值的SHA-256(十六进制)等于该值时写入")
 	return cmd
 }
 
@@ -96,6 +103,15 @@ func getPutOp(args []string) (string, string, []clientv3.OpOption) {
 	if putIgnoreLease {
 		opts = append(opts, clientv3.WithIgnoreLease())
 	}
+	if putIfAbsent {
+		opts = append(opts, clientv3.WithIfAbsent())
+	}
+	if putIfModRevision != 0 {
+		opts = append(opts, clientv3.WithIfModRevision(putIfModRevision))
+	}
+	if putIfValueHash != "" {
+		opts = append(opts, clientv3.WithIfValueHash(putIfValueHash))
+	}
 
 	return key, value, opts
 }
@@ -27,6 +27,9 @@ import (
 var (
 	rolePermPrefix  bool
 	rolePermFromKey bool
+
+	rateLimitRequests uint64
+	rateLimitBytes    uint64
 )
 
 // NewRoleCommand returns the cobra command for "role".
@@ -42,6 +45,7 @@ func NewRoleCommand() *cobra.Command {
 	ac.AddCommand(newRoleListCommand())
 	ac.AddCommand(newRoleGrantPermissionCommand())
 	ac.AddCommand(newRoleRevokePermissionCommand())
+	ac.AddCommand(newRoleSetRateLimitCommand())
 
 	return ac
 }
@@ -104,6 +108,21 @@ func newRoleRevokePermissionCommand() *cobra.Command {
 	return cmd
 }
 
+func newRoleSetRateLimitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-rate-limit <role name> [options]",
+		Short: "设置角色的速率限制, 由拥有该角色的所有用户共享; 都为0时取消限制",
+		Run:   roleSetRateLimitCommandFunc,
+	}
+	addRateLimitFlags(cmd)
+	return cmd
+}
+
+func addRateLimitFlags(cmd *cobra.Command) {
+	cmd.Flags().Uint64Var(&rateLimitRequests, "requests-per-sec", 0, "每秒允许的请求数, 0表示不限制")
+	cmd.Flags().Uint64Var(&rateLimitBytes, "bytes-per-sec", 0, "每秒允许的请求字节数, 0表示不限制")
+}
+
 func roleAddCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role add命令需要角色名作为参数"))
@@ -190,6 +209,19 @@ func roleRevokePermissionCommandFunc(cmd *cobra.Command, args []string) {
 	display.RoleRevokePermission(args[0], args[1], rangeEnd, *resp)
 }
 
+func roleSetRateLimitCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("role set-rate-limit命令需要角色名作为参数"))
+	}
+
+	limit := &clientv3.RateLimit{RequestsPerSec: rateLimitRequests, BytesPerSec: rateLimitBytes}
+	resp, err := mustClientFromCmd(cmd).Auth.RoleSetRateLimit(context.TODO(), args[0], limit)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.RoleSetRateLimit(args[0], *resp)
+}
+
 func permRange(args []string) (string, string) {
 	key := args[0]
 	var rangeEnd string
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	clientv3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
 
 	"github.com/bgentry/speakeasy"
 	"github.com/ls-2018/etcd_cn/pkg/cobrautl"
@@ -42,6 +43,8 @@ func NewUserCommand() *cobra.Command {
 	ac.AddCommand(newUserChangePasswordCommand())
 	ac.AddCommand(newUserGrantRoleCommand())
 	ac.AddCommand(newUserRevokeRoleCommand())
+	ac.AddCommand(newUserTokenCommand())
+	ac.AddCommand(newUserSetRateLimitCommand())
 
 	return ac
 }
@@ -50,6 +53,7 @@ var (
 	passwordInteractive bool
 	passwordFromFlag    string
 	noPassword          bool
+	userTokenTTL        int64
 )
 
 func newUserAddCommand() *cobra.Command {
@@ -122,6 +126,45 @@ func newUserRevokeRoleCommand() *cobra.Command {
 	}
 }
 
+func newUserTokenCommand() *cobra.Command {
+	tc := &cobra.Command{
+		Use:   "token <subcommand>",
+		Short: "用户API token相关命令",
+	}
+
+	create := &cobra.Command{
+		Use:   "create [options] <user name> <token name> <permission type> <key> [endkey]",
+		Short: "创建一个有过期时间、权限是用户权限子集的API token",
+		Run:   userTokenCreateCommandFunc,
+	}
+	create.Flags().Int64Var(&userTokenTTL, "ttl", 3600, "token的有效期(秒)")
+	create.Flags().BoolVar(&rolePermPrefix, "prefix", false, "授予前缀权限")
+	create.Flags().BoolVar(&rolePermFromKey, "from-key", false, "使用byte compare授予大于或等于给定键的权限")
+
+	tc.AddCommand(create)
+	tc.AddCommand(&cobra.Command{
+		Use:   "list <user name>",
+		Short: "显示用户的所有API token",
+		Run:   userTokenListCommandFunc,
+	})
+	tc.AddCommand(&cobra.Command{
+		Use:   "revoke <user name> <token name>",
+		Short: "撤销用户的API token",
+		Run:   userTokenRevokeCommandFunc,
+	})
+	return tc
+}
+
+func newUserSetRateLimitCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-rate-limit <user name> [options]",
+		Short: "设置用户的速率限制; 都为0时取消限制",
+		Run:   userSetRateLimitCommandFunc,
+	}
+	addRateLimitFlags(cmd)
+	return cmd
+}
+
 func userAddCommandFunc(cmd *cobra.Command, args []string) {
 	if len(args) != 1 {
 		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("用户add命令需要用户名作为参数"))
@@ -273,6 +316,55 @@ func userRevokeRoleCommandFunc(cmd *cobra.Command, args []string) {
 	display.UserRevokeRole(args[0], args[1], *resp)
 }
 
+// userTokenCreateCommandFunc executes the "user token create" command.
+func userTokenCreateCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) < 4 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("user token create命令需要用户名、token名、权限类型和关键字[endkey]作为参数"))
+	}
+
+	permType, err := clientv3.StrToPermissionType(args[2])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, err)
+	}
+	key, rangeEnd := permRange(args[3:])
+	perm := &clientv3.Permission{Key: key, RangeEnd: rangeEnd, PermType: authpb.Permission_Type(permType)}
+
+	resp, err := mustClientFromCmd(cmd).Auth.UserTokenCreate(context.TODO(), args[0], args[1], userTokenTTL, perm)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.UserTokenCreate(args[0], args[1], *resp)
+}
+
+// userTokenListCommandFunc executes the "user token list" command.
+func userTokenListCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("user token list命令需要用户名作为参数"))
+	}
+
+	resp, err := mustClientFromCmd(cmd).Auth.UserTokenList(context.TODO(), args[0])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.UserTokenList(args[0], *resp)
+}
+
+// userTokenRevokeCommandFunc executes the "user token revoke" command.
+func userTokenRevokeCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 2 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("user token revoke命令需要用户名和token名作为参数"))
+	}
+
+	resp, err := mustClientFromCmd(cmd).Auth.UserTokenRevoke(context.TODO(), args[0], args[1])
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+
+	display.UserTokenRevoke(args[0], args[1], *resp)
+}
+
 func readPasswordInteractive(name string) string {
 	prompt1 := fmt.Sprintf("%s密码: ", name)
 	password1, err1 := speakeasy.Ask(prompt1)
@@ -296,3 +388,17 @@ func readPasswordInteractive(name string) string {
 
 	return password1
 }
+
+// userSetRateLimitCommandFunc executes the "user set-rate-limit" command.
+func userSetRateLimitCommandFunc(cmd *cobra.Command, args []string) {
+	if len(args) != 1 {
+		cobrautl.ExitWithError(cobrautl.ExitBadArgs, fmt.Errorf("user set-rate-limit命令需要用户名作为参数"))
+	}
+
+	limit := &clientv3.RateLimit{RequestsPerSec: rateLimitRequests, BytesPerSec: rateLimitBytes}
+	resp, err := mustClientFromCmd(cmd).Auth.UserSetRateLimit(context.TODO(), args[0], limit)
+	if err != nil {
+		cobrautl.ExitWithError(cobrautl.ExitError, err)
+	}
+	display.UserSetRateLimit(args[0], *resp)
+}
@@ -60,7 +60,13 @@ type printer interface {
 	UserGrantRole(user string, role string, r v3.AuthUserGrantRoleResponse)
 	UserRevokeRole(user string, role string, r v3.AuthUserRevokeRoleResponse)
 	UserDelete(user string, r v3.AuthUserDeleteResponse)
+	UserTokenCreate(user string, name string, r v3.AuthUserTokenCreateResponse)
+	UserTokenList(user string, r v3.AuthUserTokenListResponse)
+	UserTokenRevoke(user string, name string, r v3.AuthUserTokenRevokeResponse)
 	AuthStatus(r v3.AuthStatusResponse)
+	AuthCheck(user string, r v3.AuthCheckResponse)
+	UserSetRateLimit(user string, r v3.AuthUserSetRateLimitResponse)
+	RoleSetRateLimit(role string, r v3.AuthRoleSetRateLimitResponse)
 }
 
 func NewPrinter(printerType string, isHex bool) printer {
@@ -144,10 +150,34 @@ func (p *printerRPC) UserDelete(_ string, r v3.AuthUserDeleteResponse) {
 	p.p((*pb.AuthUserDeleteResponse)(&r))
 }
 
+func (p *printerRPC) UserTokenCreate(_ string, _ string, r v3.AuthUserTokenCreateResponse) {
+	p.p((*pb.AuthUserTokenCreateResponse)(&r))
+}
+
+func (p *printerRPC) UserTokenList(_ string, r v3.AuthUserTokenListResponse) {
+	p.p((*pb.AuthUserTokenListResponse)(&r))
+}
+
+func (p *printerRPC) UserTokenRevoke(_ string, _ string, r v3.AuthUserTokenRevokeResponse) {
+	p.p((*pb.AuthUserTokenRevokeResponse)(&r))
+}
+
 func (p *printerRPC) AuthStatus(r v3.AuthStatusResponse) {
 	p.p((*pb.AuthStatusResponse)(&r))
 }
 
+func (p *printerRPC) AuthCheck(_ string, r v3.AuthCheckResponse) {
+	p.p((*pb.AuthCheckResponse)(&r))
+}
+
+func (p *printerRPC) UserSetRateLimit(_ string, r v3.AuthUserSetRateLimitResponse) {
+	p.p((*pb.AuthUserSetRateLimitResponse)(&r))
+}
+
+func (p *printerRPC) RoleSetRateLimit(_ string, r v3.AuthRoleSetRateLimitResponse) {
+	p.p((*pb.AuthRoleSetRateLimitResponse)(&r))
+}
+
 type printerUnsupported struct{ printerRPC }
 
 func newPrinterUnsupported(n string) printer {
@@ -18,9 +18,11 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ls-2018/etcd_cn/client_sdk/pkg/types"
 	v3 "github.com/ls-2018/etcd_cn/client_sdk/v3"
+	"github.com/ls-2018/etcd_cn/offical/api/v3/authpb"
 )
 
 type simplePrinter struct {
@@ -216,6 +218,7 @@ func (s *simplePrinter) RoleGet(role string, r v3.AuthRoleGetResponse) {
 			}
 		}
 	}
+	printRateLimit(r.RateLimit)
 }
 
 func (s *simplePrinter) RoleList(r v3.AuthRoleListResponse) {
@@ -255,6 +258,7 @@ func (s *simplePrinter) UserGet(name string, r v3.AuthUserGetResponse) {
 		fmt.Printf(" %s", role)
 	}
 	fmt.Printf("\n")
+	printRateLimit(r.RateLimit)
 }
 
 func (s *simplePrinter) UserChangePassword(v3.AuthUserChangePasswordResponse) {
@@ -279,7 +283,60 @@ func (s *simplePrinter) UserList(r v3.AuthUserListResponse) {
 	}
 }
 
+func (s *simplePrinter) UserTokenCreate(user string, name string, r v3.AuthUserTokenCreateResponse) {
+	fmt.Printf("用户 %s 的token %s 已创建, 过期时间 %s\n", user, name, time.Unix(r.ExpireTime, 0).Format(time.RFC3339))
+	fmt.Println(r.Token)
+}
+
+func (s *simplePrinter) UserTokenList(user string, r v3.AuthUserTokenListResponse) {
+	for _, t := range r.Tokens {
+		fmt.Printf("%s\t%s", t.Name, time.Unix(t.ExpireTime, 0).Format(time.RFC3339))
+		for _, perm := range t.KeyPermission {
+			fmt.Printf("\t%s[%s, %s)", perm.PermType, perm.Key, perm.RangeEnd)
+		}
+		fmt.Printf("\n")
+	}
+}
+
+func (s *simplePrinter) UserTokenRevoke(user string, name string, r v3.AuthUserTokenRevokeResponse) {
+	fmt.Printf("用户 %s 的token %s 已撤销\n", user, name)
+}
+
 func (s *simplePrinter) AuthStatus(r v3.AuthStatusResponse) {
 	fmt.Println("身份认证是否开启:", r.Enabled)
 	fmt.Println("验证版本:", r.AuthRevision)
 }
+
+func (s *simplePrinter) AuthCheck(user string, r v3.AuthCheckResponse) {
+	if r.Allowed {
+		fmt.Println("yes")
+	} else {
+		fmt.Println("no")
+	}
+	fmt.Println(r.Reason)
+	for _, perm := range r.Perms {
+		switch {
+		case len(perm.RangeEnd) == 0:
+			fmt.Printf("\t%s %s\n", perm.PermType, perm.Key)
+		case perm.RangeEnd == "\x00":
+			fmt.Printf("\t%s [%s, <open ended>\n", perm.PermType, perm.Key)
+		default:
+			fmt.Printf("\t%s [%s, %s)\n", perm.PermType, perm.Key, perm.RangeEnd)
+		}
+	}
+}
+
+func (s *simplePrinter) UserSetRateLimit(user string, r v3.AuthUserSetRateLimitResponse) {
+	fmt.Printf("用户 %s 的速率限制已更新\n", user)
+}
+
+func (s *simplePrinter) RoleSetRateLimit(role string, r v3.AuthRoleSetRateLimitResponse) {
+	fmt.Printf("角色 %s 的速率限制已更新\n", role)
+}
+
+func printRateLimit(l *authpb.RateLimit) {
+	if l == nil {
+		return
+	}
+	fmt.Printf("RateLimit: %d requests/s, %d bytes/s\n", l.RequestsPerSec, l.BytesPerSec)
+}
@@ -64,6 +64,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&globalFlags.TLS.TrustedCAFile, "cacert", "", "使用此CA包验证启用tls的安全服务器的证书")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.User, "user", "", "username[:password]  (如果没有提供密码,则提示)")
 	rootCmd.PersistentFlags().StringVar(&globalFlags.Password, "password", "", "身份验证的密码(如果使用了这个选项,——user选项不应该包含密码)")
+	rootCmd.PersistentFlags().StringVar(&globalFlags.Token, "token", "", "使用 user token create 创建的API token进行身份验证")
 	rootCmd.PersistentFlags().StringVarP(&globalFlags.TLS.ServerName, "discovery-srv", "d", "", "查询描述集群端点的SRV记录的域名")
 	rootCmd.PersistentFlags().StringVarP(&globalFlags.DNSClusterServiceName, "discovery-srv-name", "", "", "使用DNS发现时需要查询的服务名称")
 
@@ -89,6 +90,7 @@ func init() {
 		command.NewUserCommand(),
 		command.NewRoleCommand(),
 		command.NewCheckCommand(),
+		command.NewMaintenanceModeCommand(),
 	)
 }
 
@@ -70,6 +70,7 @@ type User struct {
 	Password             string          `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	Roles                []string        `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
 	Options              *UserAddOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	RateLimit            *RateLimit      `protobuf:"bytes,5,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
 	XXX_sizecache        int32           `json:"-"`
@@ -103,6 +104,7 @@ func (*Permission) Descriptor() ([]byte, []int) {
 type Role struct {
 	Name                 string        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	KeyPermission        []*Permission `protobuf:"bytes,2,rep,name=keyPermission,proto3" json:"keyPermission,omitempty"`
+	RateLimit            *RateLimit    `protobuf:"bytes,3,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
 	XXX_unrecognized     []byte        `json:"-"`
 	XXX_sizecache        int32         `json:"-"`
@@ -115,12 +117,42 @@ func (*Role) Descriptor() ([]byte, []int) {
 	return fileDescriptor_8bbd6f3875b0e874, []int{3}
 }
 
+// ScopedToken 是一个有过期时间、权限范围受限的API令牌,存储在authTokens桶中
+type ScopedToken struct {
+	Name                 string        `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	User                 string        `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	ExpireTime           int64         `protobuf:"varint,3,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"` // unix 秒
+	KeyPermission        []*Permission `protobuf:"bytes,4,rep,name=keyPermission,proto3" json:"keyPermission,omitempty"`              // 必须是用户权限的子集
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ScopedToken) Reset()         { *m = ScopedToken{} }
+func (m *ScopedToken) String() string { return proto.CompactTextString(m) }
+func (*ScopedToken) ProtoMessage()    {}
+
+// RateLimit 是用户或角色的令牌桶限速配置, 0 表示不限制
+type RateLimit struct {
+	RequestsPerSec       uint64   `protobuf:"varint,1,opt,name=requests_per_sec,json=requestsPerSec,proto3" json:"requests_per_sec,omitempty"`
+	BytesPerSec          uint64   `protobuf:"varint,2,opt,name=bytes_per_sec,json=bytesPerSec,proto3" json:"bytes_per_sec,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RateLimit) Reset()         { *m = RateLimit{} }
+func (m *RateLimit) String() string { return proto.CompactTextString(m) }
+func (*RateLimit) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterEnum("authpb.Permission_Type", PermissionTypeName, PermissionTypeValue)
 	proto.RegisterType((*UserAddOptions)(nil), "authpb.UserAddOptions")
 	proto.RegisterType((*User)(nil), "authpb.User")
 	proto.RegisterType((*Permission)(nil), "authpb.Permission")
 	proto.RegisterType((*Role)(nil), "authpb.Role")
+	proto.RegisterType((*ScopedToken)(nil), "authpb.ScopedToken")
+	proto.RegisterType((*RateLimit)(nil), "authpb.RateLimit")
 }
 
 func init() { proto.RegisterFile("auth.proto", fileDescriptor_8bbd6f3875b0e874) }
@@ -167,6 +199,14 @@ func (m *Role) Marshal() (dAtA []byte, err error) {
 	return json.Marshal(m)
 }
 
+func (m *ScopedToken) Marshal() (dAtA []byte, err error) {
+	return json.Marshal(m)
+}
+
+func (m *RateLimit) Marshal() (dAtA []byte, err error) {
+	return json.Marshal(m)
+}
+
 func (m *UserAddOptions) Size() (n int) {
 	marshal, _ := json.Marshal(m)
 	return len(marshal)
@@ -187,6 +227,16 @@ func (m *Role) Size() (n int) {
 	return len(marshal)
 }
 
+func (m *ScopedToken) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+
+func (m *RateLimit) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+
 func (m *UserAddOptions) Unmarshal(dAtA []byte) error {
 	return json.Unmarshal(dAtA, m)
 }
@@ -203,6 +253,14 @@ func (m *Role) Unmarshal(dAtA []byte) error {
 	return json.Unmarshal(dAtA, m)
 }
 
+func (m *ScopedToken) Unmarshal(dAtA []byte) error {
+	return json.Unmarshal(dAtA, m)
+}
+
+func (m *RateLimit) Unmarshal(dAtA []byte) error {
+	return json.Unmarshal(dAtA, m)
+}
+
 var (
 	ErrInvalidLengthAuth        = fmt.Errorf("proto: negative length found during unmarshaling")
 	ErrIntOverflowAuth          = fmt.Errorf("proto: integer overflow")
@@ -30,12 +30,26 @@ var (
 	ErrGRPCCompacted     = status.New(codes.OutOfRange, "etcdserver: mvcc: 所需的修订版 已被压缩").Err()
 	ErrGRPCFutureRev     = status.New(codes.OutOfRange, "etcdserver: mvcc: 所需的修订版是一个未来版本").Err()
 	ErrGRPCNoSpace       = status.New(codes.ResourceExhausted, "etcdserver: mvcc: database space exceeded").Err()
+	ErrGRPCReadOnly      = status.New(codes.FailedPrecondition, "etcdserver: cluster is in read-only maintenance mode").Err()
+
+	ErrGRPCKeyExists           = status.New(codes.FailedPrecondition, "etcdserver: key already exists").Err()
+	ErrGRPCKeyWrittenInTxn     = status.New(codes.InvalidArgument, "etcdserver: key is written earlier in the same txn branch").Err()
+	ErrGRPCModRevisionMismatch = status.New(codes.FailedPrecondition, "etcdserver: key mod revision does not match").Err()
+	ErrGRPCValueHashMismatch   = status.New(codes.FailedPrecondition, "etcdserver: key value hash does not match").Err()
+	ErrGRPCInvalidPutCondition = status.New(codes.InvalidArgument, "etcdserver: if_absent conflicts with options requiring an existing key").Err()
+	ErrGRPCInvalidValueRef     = status.New(codes.InvalidArgument, "etcdserver: value_ref must reference an earlier range op returning values in the same txn branch").Err()
+	ErrGRPCValueNotNumber      = status.New(codes.FailedPrecondition, "etcdserver: value is not a decimal integer").Err()
+	ErrGRPCCounterOverflow     = status.New(codes.OutOfRange, "etcdserver: counter overflow").Err()
 
 	ErrGRPCLeaseNotFound    = status.New(codes.NotFound, "etcdserver: 请求的租约不存在").Err()
 	ErrGRPCLeaseExist       = status.New(codes.FailedPrecondition, "etcdserver: lease already exists").Err()
 	ErrGRPCLeaseTTLTooLarge = status.New(codes.OutOfRange, "etcdserver: too large lease TTL").Err()
 
-	ErrGRPCWatchCanceled = status.New(codes.Canceled, "etcdserver: watch 取消了").Err()
+	ErrGRPCWatchCanceled          = status.New(codes.Canceled, "etcdserver: watch 取消了").Err()
+	ErrGRPCWatchPermissionRevoked = status.New(codes.PermissionDenied, "etcdserver: 权限已变更, 不再允许watch该范围").Err()
+	ErrGRPCInvalidWatchFilter     = status.New(codes.InvalidArgument, "etcdserver: watch 的过滤条件无效").Err()
+	ErrGRPCWatchSlowConsumer      = status.New(codes.ResourceExhausted, "etcdserver: watch 积压的事件过多, 已被取消").Err()
+	ErrGRPCWatchSessionMismatch   = status.New(codes.FailedPrecondition, "etcdserver: 恢复watch会话的选项与创建时不一致").Err()
 
 	ErrGRPCMemberExist            = status.New(codes.FailedPrecondition, "etcdserver: member ID already exist").Err()
 	ErrGRPCPeerURLExist           = status.New(codes.FailedPrecondition, "etcdserver: Peer URLs already exists").Err()
@@ -67,6 +81,14 @@ var (
 	ErrGRPCInvalidAuthMgmt      = status.New(codes.InvalidArgument, "etcdserver: invalid auth management").Err()
 	ErrGRPCAuthOldRevision      = status.New(codes.InvalidArgument, "etcdserver: revision of auth store is old").Err()
 
+	ErrGRPCTokenNameEmpty         = status.New(codes.InvalidArgument, "etcdserver: token名称不能为空").Err()
+	ErrGRPCTokenAlreadyExist      = status.New(codes.FailedPrecondition, "etcdserver: token已存在").Err()
+	ErrGRPCTokenNotFound          = status.New(codes.FailedPrecondition, "etcdserver: token不存在").Err()
+	ErrGRPCTokenExpired           = status.New(codes.Unauthenticated, "etcdserver: token已过期").Err()
+	ErrGRPCInvalidTokenTTL        = status.New(codes.InvalidArgument, "etcdserver: token的TTL必须大于0").Err()
+	ErrGRPCInvalidTokenPermission = status.New(codes.InvalidArgument, "etcdserver: token的权限必须是用户权限的子集").Err()
+	ErrGRPCRateLimited            = status.New(codes.ResourceExhausted, "etcdserver: 超过了用户或角色的请求速率限制").Err()
+
 	ErrGRPCNoLeader                   = status.New(codes.Unavailable, "etcdserver: 没有leader").Err()
 	ErrGRPCNotLeader                  = status.New(codes.FailedPrecondition, "etcdserver: 不是leader").Err()
 	ErrGRPCLeaderChanged              = status.New(codes.Unavailable, "etcdserver: leader改变了").Err()
@@ -100,11 +122,26 @@ var (
 		ErrorDesc(ErrGRPCCompacted):    ErrGRPCCompacted,
 		ErrorDesc(ErrGRPCFutureRev):    ErrGRPCFutureRev,
 		ErrorDesc(ErrGRPCNoSpace):      ErrGRPCNoSpace,
+		ErrorDesc(ErrGRPCReadOnly):     ErrGRPCReadOnly,
+
+		ErrorDesc(ErrGRPCKeyExists):           ErrGRPCKeyExists,
+		ErrorDesc(ErrGRPCKeyWrittenInTxn):     ErrGRPCKeyWrittenInTxn,
+		ErrorDesc(ErrGRPCModRevisionMismatch): ErrGRPCModRevisionMismatch,
+		ErrorDesc(ErrGRPCValueHashMismatch):   ErrGRPCValueHashMismatch,
+		ErrorDesc(ErrGRPCInvalidPutCondition): ErrGRPCInvalidPutCondition,
+		ErrorDesc(ErrGRPCInvalidValueRef):     ErrGRPCInvalidValueRef,
+		ErrorDesc(ErrGRPCValueNotNumber):      ErrGRPCValueNotNumber,
+		ErrorDesc(ErrGRPCCounterOverflow):     ErrGRPCCounterOverflow,
 
 		ErrorDesc(ErrGRPCLeaseNotFound):    ErrGRPCLeaseNotFound,
 		ErrorDesc(ErrGRPCLeaseExist):       ErrGRPCLeaseExist,
 		ErrorDesc(ErrGRPCLeaseTTLTooLarge): ErrGRPCLeaseTTLTooLarge,
 
+		ErrorDesc(ErrGRPCWatchPermissionRevoked): ErrGRPCWatchPermissionRevoked,
+		ErrorDesc(ErrGRPCInvalidWatchFilter):     ErrGRPCInvalidWatchFilter,
+		ErrorDesc(ErrGRPCWatchSlowConsumer):      ErrGRPCWatchSlowConsumer,
+		ErrorDesc(ErrGRPCWatchSessionMismatch):   ErrGRPCWatchSessionMismatch,
+
 		ErrorDesc(ErrGRPCMemberExist):            ErrGRPCMemberExist,
 		ErrorDesc(ErrGRPCPeerURLExist):           ErrGRPCPeerURLExist,
 		ErrorDesc(ErrGRPCMemberNotEnoughStarted): ErrGRPCMemberNotEnoughStarted,
@@ -134,6 +171,14 @@ var (
 		ErrorDesc(ErrGRPCInvalidAuthMgmt):      ErrGRPCInvalidAuthMgmt,
 		ErrorDesc(ErrGRPCAuthOldRevision):      ErrGRPCAuthOldRevision,
 
+		ErrorDesc(ErrGRPCTokenNameEmpty):         ErrGRPCTokenNameEmpty,
+		ErrorDesc(ErrGRPCTokenAlreadyExist):      ErrGRPCTokenAlreadyExist,
+		ErrorDesc(ErrGRPCTokenNotFound):          ErrGRPCTokenNotFound,
+		ErrorDesc(ErrGRPCTokenExpired):           ErrGRPCTokenExpired,
+		ErrorDesc(ErrGRPCInvalidTokenTTL):        ErrGRPCInvalidTokenTTL,
+		ErrorDesc(ErrGRPCInvalidTokenPermission): ErrGRPCInvalidTokenPermission,
+		ErrorDesc(ErrGRPCRateLimited):            ErrGRPCRateLimited,
+
 		ErrorDesc(ErrGRPCNoLeader):                   ErrGRPCNoLeader,
 		ErrorDesc(ErrGRPCNotLeader):                  ErrGRPCNotLeader,
 		ErrorDesc(ErrGRPCLeaderChanged):              ErrGRPCLeaderChanged,
@@ -160,9 +205,24 @@ var (
 	ErrEmptyKey  = Error(ErrGRPCEmptyKey)
 	ErrCompacted = Error(ErrGRPCCompacted)
 	ErrFutureRev = Error(ErrGRPCFutureRev)
+	ErrReadOnly  = Error(ErrGRPCReadOnly)
+
+	ErrKeyExists           = Error(ErrGRPCKeyExists)
+	ErrKeyWrittenInTxn     = Error(ErrGRPCKeyWrittenInTxn)
+	ErrModRevisionMismatch = Error(ErrGRPCModRevisionMismatch)
+	ErrValueHashMismatch   = Error(ErrGRPCValueHashMismatch)
+	ErrInvalidPutCondition = Error(ErrGRPCInvalidPutCondition)
+	ErrInvalidValueRef     = Error(ErrGRPCInvalidValueRef)
+	ErrValueNotNumber      = Error(ErrGRPCValueNotNumber)
+	ErrCounterOverflow     = Error(ErrGRPCCounterOverflow)
 
 	ErrLeaseNotFound = Error(ErrGRPCLeaseNotFound)
 
+	ErrWatchPermissionRevoked = Error(ErrGRPCWatchPermissionRevoked)
+	ErrInvalidWatchFilter     = Error(ErrGRPCInvalidWatchFilter)
+	ErrWatchSlowConsumer      = Error(ErrGRPCWatchSlowConsumer)
+	ErrWatchSessionMismatch   = Error(ErrGRPCWatchSessionMismatch)
+
 	ErrMemberNotEnoughStarted = Error(ErrGRPCMemberNotEnoughStarted)
 
 	ErrTooManyRequests = Error(ErrGRPCRequestTooManyRequests)
@@ -173,6 +233,8 @@ var (
 	ErrAuthNotEnabled   = Error(ErrGRPCAuthNotEnabled)
 	ErrInvalidAuthToken = Error(ErrGRPCInvalidAuthToken)
 	ErrAuthOldRevision  = Error(ErrGRPCAuthOldRevision)
+	ErrTokenExpired     = Error(ErrGRPCTokenExpired)
+	ErrRateLimited      = Error(ErrGRPCRateLimited)
 
 	ErrNoLeader = Error(ErrGRPCNoLeader)
 )
@@ -19,4 +19,14 @@ var (
 	MetadataHasLeader        = "true"
 
 	MetadataClientAPIVersionKey = "client-api-version"
+
+	// MetadataPriorityKey 请求的优先级类别, 客户端只能借此降低请求的优先级
+	MetadataPriorityKey    = "priority"
+	MetadataPrioritySystem = "system"
+	MetadataPriorityLease  = "lease"
+	MetadataPriorityNormal = "normal"
+	MetadataPriorityBulk   = "bulk"
+
+	// MetadataRetryAfterKey 服务端因负载过高拒绝请求时, 在 trailer 中建议的重试等待时间(毫秒)
+	MetadataRetryAfterKey = "retry-after-ms"
 )
@@ -15,6 +15,12 @@ type xx struct {
 	PrevKv      bool
 	IgnoreValue bool
 	IgnoreLease bool
+
+	IfAbsent      bool
+	IfModRevision int64
+	IfValueHash   string
+	ValueRef      *ValueRef
+	Increment     *Increment
 }
 type ASD struct {
 	Put                      *xx
@@ -41,14 +47,22 @@ type ASD struct {
 	AuthUserRevokeRole       *AuthUserRevokeRoleRequest                `protobuf:"bytes,1105,opt,name=auth_user_revoke_role,json=authUserRevokeRole,proto3" json:"auth_user_revoke_role,omitempty"`
 	AuthUserList             *AuthUserListRequest                      `protobuf:"bytes,1106,opt,name=auth_user_list,json=authUserList,proto3" json:"auth_user_list,omitempty"`
 	AuthRoleList             *AuthRoleListRequest                      `protobuf:"bytes,1107,opt,name=auth_role_list,json=authRoleList,proto3" json:"auth_role_list,omitempty"`
+	AuthUserTokenCreate      *AuthUserTokenCreateRequest               `protobuf:"bytes,1108,opt,name=auth_user_token_create,json=authUserTokenCreate,proto3" json:"auth_user_token_create,omitempty"`
+	AuthUserTokenList        *AuthUserTokenListRequest                 `protobuf:"bytes,1109,opt,name=auth_user_token_list,json=authUserTokenList,proto3" json:"auth_user_token_list,omitempty"`
+	AuthUserTokenRevoke      *AuthUserTokenRevokeRequest               `protobuf:"bytes,1110,opt,name=auth_user_token_revoke,json=authUserTokenRevoke,proto3" json:"auth_user_token_revoke,omitempty"`
+	AuthCheck                *AuthCheckRequest                         `protobuf:"bytes,1111,opt,name=auth_check,json=authCheck,proto3" json:"auth_check,omitempty"`
+	AuthUserSetRateLimit     *AuthUserSetRateLimitRequest              `protobuf:"bytes,1112,opt,name=auth_user_set_rate_limit,json=authUserSetRateLimit,proto3" json:"auth_user_set_rate_limit,omitempty"`
+	AuthTokenPurge           *InternalAuthTokenPurgeRequest            `protobuf:"bytes,1113,opt,name=auth_token_purge,json=authTokenPurge,proto3" json:"auth_token_purge,omitempty"`
 	AuthRoleAdd              *AuthRoleAddRequest                       `protobuf:"bytes,1200,opt,name=auth_role_add,json=authRoleAdd,proto3" json:"auth_role_add,omitempty"`
 	AuthRoleDelete           *AuthRoleDeleteRequest                    `protobuf:"bytes,1201,opt,name=auth_role_delete,json=authRoleDelete,proto3" json:"auth_role_delete,omitempty"`
 	AuthRoleGet              *AuthRoleGetRequest                       `protobuf:"bytes,1202,opt,name=auth_role_get,json=authRoleGet,proto3" json:"auth_role_get,omitempty"`
 	AuthRoleGrantPermission  *AuthRoleGrantPermissionRequest           `protobuf:"bytes,1203,opt,name=auth_role_grant_permission,json=authRoleGrantPermission,proto3" json:"auth_role_grant_permission,omitempty"`
 	AuthRoleRevokePermission *AuthRoleRevokePermissionRequest          `protobuf:"bytes,1204,opt,name=auth_role_revoke_permission,json=authRoleRevokePermission,proto3" json:"auth_role_revoke_permission,omitempty"`
+	AuthRoleSetRateLimit     *AuthRoleSetRateLimitRequest              `protobuf:"bytes,1205,opt,name=auth_role_set_rate_limit,json=authRoleSetRateLimit,proto3" json:"auth_role_set_rate_limit,omitempty"`
 	ClusterVersionSet        *membershippb.ClusterVersionSetRequest    `protobuf:"bytes,1300,opt,name=cluster_version_set,json=clusterVersionSet,proto3" json:"cluster_version_set,omitempty"`
 	ClusterMemberAttrSet     *membershippb.ClusterMemberAttrSetRequest `protobuf:"bytes,1301,opt,name=cluster_member_attr_set,json=clusterMemberAttrSet,proto3" json:"cluster_member_attr_set,omitempty"`
 	DowngradeInfoSet         *membershippb.DowngradeInfoSetRequest     `protobuf:"bytes,1302,opt,name=downgrade_info_set,json=downgradeInfoSet,proto3" json:"downgrade_info_set,omitempty"`
+	MaintenanceMode          *MaintenanceModeRequest                   `protobuf:"bytes,1400,opt,name=maintenance_mode,json=maintenanceMode,proto3" json:"maintenance_mode,omitempty"`
 	XXX_NoUnkeyedLiteral     struct{}                                  `json:"-"`
 	XXX_unrecognized         []byte                                    `json:"-"`
 	XXX_sizecache            int32                                     `json:"-"`
@@ -82,12 +96,20 @@ func (m *InternalRaftRequest) Marshal() (dAtA []byte, err error) {
 		LeaseGrant:               m.LeaseGrant,
 		Compaction:               m.Compaction,
 		AuthRoleList:             m.AuthRoleList,
+		AuthUserTokenCreate:      m.AuthUserTokenCreate,
+		AuthUserTokenList:        m.AuthUserTokenList,
+		AuthUserTokenRevoke:      m.AuthUserTokenRevoke,
+		AuthCheck:                m.AuthCheck,
+		AuthUserSetRateLimit:     m.AuthUserSetRateLimit,
+		AuthTokenPurge:           m.AuthTokenPurge,
+		AuthRoleSetRateLimit:     m.AuthRoleSetRateLimit,
 		AuthRoleAdd:              m.AuthRoleAdd,
 		AuthUserGrantRole:        m.AuthUserGrantRole,
 		AuthUserAdd:              m.AuthUserAdd,
 		ClusterVersionSet:        m.ClusterVersionSet,
 		ClusterMemberAttrSet:     m.ClusterMemberAttrSet,
 		DowngradeInfoSet:         m.DowngradeInfoSet,
+		MaintenanceMode:          m.MaintenanceMode,
 	}
 
 	if m.Put != nil {
@@ -98,6 +120,12 @@ func (m *InternalRaftRequest) Marshal() (dAtA []byte, err error) {
 			PrevKv:      m.Put.PrevKv,
 			IgnoreValue: m.Put.IgnoreValue,
 			IgnoreLease: m.Put.IgnoreLease,
+
+			IfAbsent:      m.Put.IfAbsent,
+			IfModRevision: m.Put.IfModRevision,
+			IfValueHash:   m.Put.IfValueHash,
+			ValueRef:      m.Put.ValueRef,
+			Increment:     m.Put.Increment,
 		}
 	}
 
@@ -123,6 +151,12 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 			PrevKv:      a.Put.PrevKv,
 			IgnoreValue: a.Put.IgnoreValue,
 			IgnoreLease: a.Put.IgnoreLease,
+
+			IfAbsent:      a.Put.IfAbsent,
+			IfModRevision: a.Put.IfModRevision,
+			IfValueHash:   a.Put.IfValueHash,
+			ValueRef:      a.Put.ValueRef,
+			Increment:     a.Put.Increment,
 		}
 	}
 	m.Header = a.Header
@@ -149,12 +183,20 @@ func (m *InternalRaftRequest) Unmarshal(dAtA []byte) error {
 	m.LeaseGrant = a.LeaseGrant
 	m.Compaction = a.Compaction
 	m.AuthRoleList = a.AuthRoleList
+	m.AuthUserTokenCreate = a.AuthUserTokenCreate
+	m.AuthUserTokenList = a.AuthUserTokenList
+	m.AuthUserTokenRevoke = a.AuthUserTokenRevoke
+	m.AuthCheck = a.AuthCheck
+	m.AuthUserSetRateLimit = a.AuthUserSetRateLimit
+	m.AuthTokenPurge = a.AuthTokenPurge
+	m.AuthRoleSetRateLimit = a.AuthRoleSetRateLimit
 	m.AuthRoleAdd = a.AuthRoleAdd
 	m.AuthUserGrantRole = a.AuthUserGrantRole
 	m.AuthUserAdd = a.AuthUserAdd
 	m.ClusterVersionSet = a.ClusterVersionSet
 	m.ClusterMemberAttrSet = a.ClusterMemberAttrSet
 	m.DowngradeInfoSet = a.DowngradeInfoSet
+	m.MaintenanceMode = a.MaintenanceMode
 	return err
 }
 
@@ -29,7 +29,11 @@ type RequestHeader struct {
 	// username is a username that is associated with an auth token of gRPC connection
 	Username string `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
 	// auth_revision is a revision number of auth.authStore. It is not related to mvcc
-	AuthRevision         uint64   `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
+	AuthRevision uint64 `protobuf:"varint,3,opt,name=auth_revision,json=authRevision,proto3" json:"auth_revision,omitempty"`
+	// scoped_token is the hash of the API token the request was made with, empty for regular auth tokens
+	ScopedToken string `protobuf:"bytes,4,opt,name=scoped_token,json=scopedToken,proto3" json:"scoped_token,omitempty"`
+	// roles are granted directly by a client certificate mapping rule
+	Roles                []string `protobuf:"bytes,5,rep,name=roles,proto3" json:"roles,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -69,14 +73,22 @@ type InternalRaftRequest struct {
 	AuthUserRevokeRole       *AuthUserRevokeRoleRequest                `protobuf:"bytes,1105,opt,name=auth_user_revoke_role,json=authUserRevokeRole,proto3" json:"auth_user_revoke_role,omitempty"`
 	AuthUserList             *AuthUserListRequest                      `protobuf:"bytes,1106,opt,name=auth_user_list,json=authUserList,proto3" json:"auth_user_list,omitempty"`
 	AuthRoleList             *AuthRoleListRequest                      `protobuf:"bytes,1107,opt,name=auth_role_list,json=authRoleList,proto3" json:"auth_role_list,omitempty"`
+	AuthUserTokenCreate      *AuthUserTokenCreateRequest               `protobuf:"bytes,1108,opt,name=auth_user_token_create,json=authUserTokenCreate,proto3" json:"auth_user_token_create,omitempty"`
+	AuthUserTokenList        *AuthUserTokenListRequest                 `protobuf:"bytes,1109,opt,name=auth_user_token_list,json=authUserTokenList,proto3" json:"auth_user_token_list,omitempty"`
+	AuthUserTokenRevoke      *AuthUserTokenRevokeRequest               `protobuf:"bytes,1110,opt,name=auth_user_token_revoke,json=authUserTokenRevoke,proto3" json:"auth_user_token_revoke,omitempty"`
+	AuthCheck                *AuthCheckRequest                         `protobuf:"bytes,1111,opt,name=auth_check,json=authCheck,proto3" json:"auth_check,omitempty"`
+	AuthUserSetRateLimit     *AuthUserSetRateLimitRequest              `protobuf:"bytes,1112,opt,name=auth_user_set_rate_limit,json=authUserSetRateLimit,proto3" json:"auth_user_set_rate_limit,omitempty"`
+	AuthTokenPurge           *InternalAuthTokenPurgeRequest            `protobuf:"bytes,1113,opt,name=auth_token_purge,json=authTokenPurge,proto3" json:"auth_token_purge,omitempty"`
 	AuthRoleAdd              *AuthRoleAddRequest                       `protobuf:"bytes,1200,opt,name=auth_role_add,json=authRoleAdd,proto3" json:"auth_role_add,omitempty"`
 	AuthRoleDelete           *AuthRoleDeleteRequest                    `protobuf:"bytes,1201,opt,name=auth_role_delete,json=authRoleDelete,proto3" json:"auth_role_delete,omitempty"`
 	AuthRoleGet              *AuthRoleGetRequest                       `protobuf:"bytes,1202,opt,name=auth_role_get,json=authRoleGet,proto3" json:"auth_role_get,omitempty"`
 	AuthRoleGrantPermission  *AuthRoleGrantPermissionRequest           `protobuf:"bytes,1203,opt,name=auth_role_grant_permission,json=authRoleGrantPermission,proto3" json:"auth_role_grant_permission,omitempty"`
 	AuthRoleRevokePermission *AuthRoleRevokePermissionRequest          `protobuf:"bytes,1204,opt,name=auth_role_revoke_permission,json=authRoleRevokePermission,proto3" json:"auth_role_revoke_permission,omitempty"`
+	AuthRoleSetRateLimit     *AuthRoleSetRateLimitRequest              `protobuf:"bytes,1205,opt,name=auth_role_set_rate_limit,json=authRoleSetRateLimit,proto3" json:"auth_role_set_rate_limit,omitempty"`
 	ClusterVersionSet        *membershippb.ClusterVersionSetRequest    `protobuf:"bytes,1300,opt,name=cluster_version_set,json=clusterVersionSet,proto3" json:"cluster_version_set,omitempty"`
 	ClusterMemberAttrSet     *membershippb.ClusterMemberAttrSetRequest `protobuf:"bytes,1301,opt,name=cluster_member_attr_set,json=clusterMemberAttrSet,proto3" json:"cluster_member_attr_set,omitempty"`
 	DowngradeInfoSet         *membershippb.DowngradeInfoSetRequest     `protobuf:"bytes,1302,opt,name=downgrade_info_set,json=downgradeInfoSet,proto3" json:"downgrade_info_set,omitempty"`
+	MaintenanceMode          *MaintenanceModeRequest                   `protobuf:"bytes,1400,opt,name=maintenance_mode,json=maintenanceMode,proto3" json:"maintenance_mode,omitempty"`
 	XXX_NoUnkeyedLiteral     struct{}                                  `json:"-"`
 	XXX_unrecognized         []byte                                    `json:"-"`
 	XXX_sizecache            int32                                     `json:"-"`
@@ -122,11 +134,25 @@ func (*InternalAuthenticateRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_b4c9a9be0cfca103, []int{3}
 }
 
+// InternalAuthTokenPurgeRequest 由leader定期发起, 删除过期时间不晚于 ExpireBefore 的 scoped token;
+// 截止时间由leader决定, apply 阶段不依赖本地时钟
+type InternalAuthTokenPurgeRequest struct {
+	ExpireBefore         int64    `protobuf:"varint,1,opt,name=expire_before,json=expireBefore,proto3" json:"expire_before,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *InternalAuthTokenPurgeRequest) Reset()         { *m = InternalAuthTokenPurgeRequest{} }
+func (m *InternalAuthTokenPurgeRequest) String() string { return proto.CompactTextString(m) }
+func (*InternalAuthTokenPurgeRequest) ProtoMessage()    {}
+
 func init() {
 	proto.RegisterType((*RequestHeader)(nil), "etcdserverpb.RequestHeader")
 	proto.RegisterType((*InternalRaftRequest)(nil), "etcdserverpb.InternalRaftRequest")
 	proto.RegisterType((*EmptyResponse)(nil), "etcdserverpb.EmptyResponse")
 	proto.RegisterType((*InternalAuthenticateRequest)(nil), "etcdserverpb.InternalAuthenticateRequest")
+	proto.RegisterType((*InternalAuthTokenPurgeRequest)(nil), "etcdserverpb.InternalAuthTokenPurgeRequest")
 }
 
 func init() { proto.RegisterFile("raft_internal.proto", fileDescriptor_b4c9a9be0cfca103) }
@@ -229,3 +255,10 @@ func (m *InternalAuthenticateRequest) Size() (n int) {
 func (m *RequestHeader) Unmarshal(dAtA []byte) error               { return json.Unmarshal(dAtA, m) }
 func (m *EmptyResponse) Unmarshal(dAtA []byte) error               { return json.Unmarshal(dAtA, m) }
 func (m *InternalAuthenticateRequest) Unmarshal(dAtA []byte) error { return json.Unmarshal(dAtA, m) }
+
+func (m *InternalAuthTokenPurgeRequest) Marshal() (dAtA []byte, err error) { return json.Marshal(m) }
+func (m *InternalAuthTokenPurgeRequest) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *InternalAuthTokenPurgeRequest) Unmarshal(dAtA []byte) error { return json.Unmarshal(dAtA, m) }
@@ -162,6 +162,10 @@ type loggablePutRequest struct {
 	PrevKv      bool   `protobuf:"varint,4,opt,name=prev_kv,proto3"`
 	IgnoreValue bool   `protobuf:"varint,5,opt,name=ignore_value,proto3"`
 	IgnoreLease bool   `protobuf:"varint,6,opt,name=ignore_lease,proto3"`
+
+	IfAbsent      bool   `protobuf:"varint,7,opt,name=if_absent,proto3"`
+	IfModRevision int64  `protobuf:"varint,8,opt,name=if_mod_revision,proto3"`
+	IfValueHash   string `protobuf:"bytes,9,opt,name=if_value_hash,proto3"`
 }
 
 func NewLoggablePutRequest(request *PutRequest) *loggablePutRequest {
@@ -172,6 +176,9 @@ func NewLoggablePutRequest(request *PutRequest) *loggablePutRequest {
 		request.PrevKv,
 		request.IgnoreValue,
 		request.IgnoreLease,
+		request.IfAbsent,
+		request.IfModRevision,
+		request.IfValueHash,
 	}
 }
 
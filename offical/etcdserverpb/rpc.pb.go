@@ -34,21 +34,30 @@ const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 type AlarmType int32
 
 const (
-	AlarmType_NONE    AlarmType = 0
-	AlarmType_NOSPACE AlarmType = 1
-	AlarmType_CORRUPT AlarmType = 2
+	AlarmType_NONE      AlarmType = 0
+	AlarmType_NOSPACE   AlarmType = 1
+	AlarmType_CORRUPT   AlarmType = 2
+	AlarmType_SLOWDISK  AlarmType = 3
+	AlarmType_CLOCKSKEW AlarmType = 4
+	AlarmType_APPLYLAG  AlarmType = 5
 )
 
 var AlarmType_name = map[int32]string{
 	0: "NONE",
 	1: "NOSPACE",
 	2: "CORRUPT",
+	3: "SLOWDISK",
+	4: "CLOCKSKEW",
+	5: "APPLYLAG",
 }
 
 var AlarmType_value = map[string]int32{
-	"NONE":    0,
-	"NOSPACE": 1,
-	"CORRUPT": 2,
+	"NONE":      0,
+	"NOSPACE":   1,
+	"CORRUPT":   2,
+	"SLOWDISK":  3,
+	"CLOCKSKEW": 4,
+	"APPLYLAG":  5,
 }
 
 func (x AlarmType) String() string {
@@ -186,6 +195,37 @@ func (Compare_CompareTarget) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_77a6da22d6a3feb1, []int{9, 1}
 }
 
+type Compare_CompareAggregate int32
+
+const (
+	// NONE 逐个比较范围内每个key的 target
+	Compare_NONE Compare_CompareAggregate = 0
+	// COUNT 比较范围内的key数, 与 count 比较
+	Compare_COUNT Compare_CompareAggregate = 1
+	// MAX 比较范围内所有key的 target 的最大值
+	Compare_MAX Compare_CompareAggregate = 2
+	// MIN 比较范围内所有key的 target 的最小值
+	Compare_MIN Compare_CompareAggregate = 3
+)
+
+var Compare_CompareAggregate_name = map[int32]string{
+	0: "NONE",
+	1: "COUNT",
+	2: "MAX",
+	3: "MIN",
+}
+
+var Compare_CompareAggregate_value = map[string]int32{
+	"NONE":  0,
+	"COUNT": 1,
+	"MAX":   2,
+	"MIN":   3,
+}
+
+func (x Compare_CompareAggregate) String() string {
+	return proto.EnumName(Compare_CompareAggregate_name, int32(x))
+}
+
 type WatchCreateRequest_FilterType int32
 
 const (
@@ -269,6 +309,30 @@ func (DowngradeRequest_DowngradeAction) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_77a6da22d6a3feb1, []int{57, 0}
 }
 
+type MaintenanceModeRequest_Action int32
+
+const (
+	MaintenanceModeRequest_GET     MaintenanceModeRequest_Action = 0
+	MaintenanceModeRequest_ENABLE  MaintenanceModeRequest_Action = 1
+	MaintenanceModeRequest_DISABLE MaintenanceModeRequest_Action = 2
+)
+
+var MaintenanceModeRequest_Action_name = map[int32]string{
+	0: "GET",
+	1: "ENABLE",
+	2: "DISABLE",
+}
+
+var MaintenanceModeRequest_Action_value = map[string]int32{
+	"GET":     0,
+	"ENABLE":  1,
+	"DISABLE": 2,
+}
+
+func (x MaintenanceModeRequest_Action) String() string {
+	return proto.EnumName(MaintenanceModeRequest_Action_name, int32(x))
+}
+
 type ResponseHeader struct {
 	// cluster_id is the ID of the cluster which sent the response.
 	ClusterId uint64 `protobuf:"varint,1,opt,name=cluster_id,json=clusterId,proto3" json:"cluster_id,omitempty"`
@@ -350,6 +414,10 @@ type RangeRequest struct {
 	// max_create_revision is the upper bound for returned key create revisions; all keys with
 	// greater create revisions will be filtered away.
 	MaxCreateRevision int64 `protobuf:"varint,13,opt,name=max_create_revision,json=maxCreateRevision,proto3" json:"max_create_revision,omitempty"`
+	// max_staleness_ms 有界陈旧读: 返回的数据最多比请求开始时落后的毫秒数, 0表示不限制
+	MaxStalenessMs int64 `protobuf:"varint,14,opt,name=max_staleness_ms,json=maxStalenessMs,proto3" json:"max_staleness_ms,omitempty"`
+	// max_staleness_revisions 有界陈旧读: 返回的数据最多落后的修订版本数, 0表示不限制
+	MaxStalenessRevisions int64 `protobuf:"varint,15,opt,name=max_staleness_revisions,json=maxStalenessRevisions,proto3" json:"max_staleness_revisions,omitempty"`
 }
 
 func (m *RangeRequest) Reset()         { *m = RangeRequest{} }
@@ -450,6 +518,20 @@ func (m *RangeRequest) GetMaxCreateRevision() int64 {
 	return 0
 }
 
+func (m *RangeRequest) GetMaxStalenessMs() int64 {
+	if m != nil {
+		return m.MaxStalenessMs
+	}
+	return 0
+}
+
+func (m *RangeRequest) GetMaxStalenessRevisions() int64 {
+	if m != nil {
+		return m.MaxStalenessRevisions
+	}
+	return 0
+}
+
 type RangeResponse struct {
 	Header *ResponseHeader    `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Kvs    []*mvccpb.KeyValue `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`      // 表示符合range 请求的key-value 对列表.如果Count_Only 设置为true ,则kvs 就为空.
@@ -507,6 +589,21 @@ type PutRequest struct {
 	// If ignore_lease is set, etcd updates the key using its current lease.
 	// Returns an error if the key does not exist.
 	IgnoreLease bool `protobuf:"varint,6,opt,name=ignore_lease,json=ignoreLease,proto3" json:"ignore_lease,omitempty"`
+	// If if_absent is set, the put succeeds only if the key does not exist.
+	IfAbsent bool `protobuf:"varint,7,opt,name=if_absent,json=ifAbsent,proto3" json:"if_absent,omitempty"`
+	// If if_mod_revision is non-zero, the put succeeds only if the key exists and
+	// its mod_revision equals if_mod_revision.
+	IfModRevision int64 `protobuf:"varint,8,opt,name=if_mod_revision,json=ifModRevision,proto3" json:"if_mod_revision,omitempty"`
+	// If if_value_hash is set, the put succeeds only if the key exists and the
+	// lowercase hex SHA-256 digest of its current value equals if_value_hash.
+	IfValueHash string `protobuf:"bytes,9,opt,name=if_value_hash,json=ifValueHash,proto3" json:"if_value_hash,omitempty"`
+	// value_ref takes the value from the result of an earlier range op in the same
+	// txn branch instead of value. Only valid inside a txn. If the referenced range
+	// returned no key, the put is skipped.
+	ValueRef *ValueRef `protobuf:"bytes,10,opt,name=value_ref,json=valueRef,proto3" json:"value_ref,omitempty"`
+	// If increment is set, the value is the current value of the key, a decimal int64
+	// (0 if the key does not exist), plus increment.delta. value must be empty.
+	Increment *Increment `protobuf:"bytes,11,opt,name=increment,proto3" json:"increment,omitempty"`
 }
 
 func (m *PutRequest) Reset()         { *m = PutRequest{} }
@@ -558,13 +655,91 @@ func (m *PutRequest) GetIgnoreLease() bool {
 	return false
 }
 
+func (m *PutRequest) GetIfAbsent() bool {
+	if m != nil {
+		return m.IfAbsent
+	}
+	return false
+}
+
+func (m *PutRequest) GetIfModRevision() int64 {
+	if m != nil {
+		return m.IfModRevision
+	}
+	return 0
+}
+
+func (m *PutRequest) GetIfValueHash() string {
+	if m != nil {
+		return m.IfValueHash
+	}
+	return ""
+}
+
+func (m *PutRequest) GetValueRef() *ValueRef {
+	if m != nil {
+		return m.ValueRef
+	}
+	return nil
+}
+
+func (m *PutRequest) GetIncrement() *Increment {
+	if m != nil {
+		return m.Increment
+	}
+	return nil
+}
+
+// Increment 计数器的原子加法
+type Increment struct {
+	// delta is added to the current value of the key; it may be negative.
+	Delta                int64    `protobuf:"varint,1,opt,name=delta,proto3" json:"delta,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Increment) Reset()         { *m = Increment{} }
+func (m *Increment) String() string { return proto.CompactTextString(m) }
+func (*Increment) ProtoMessage()    {}
+
+func (m *Increment) GetDelta() int64 {
+	if m != nil {
+		return m.Delta
+	}
+	return 0
+}
+
+// ValueRef 引用同一事务分支中之前某个range操作的结果
+type ValueRef struct {
+	// op_index is the index of the referenced range op in the same txn branch; it must
+	// precede the op holding the reference. The value of the first key returned is used.
+	OpIndex              int64    `protobuf:"varint,1,opt,name=op_index,json=opIndex,proto3" json:"op_index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ValueRef) Reset()         { *m = ValueRef{} }
+func (m *ValueRef) String() string { return proto.CompactTextString(m) }
+func (*ValueRef) ProtoMessage()    {}
+
+func (m *ValueRef) GetOpIndex() int64 {
+	if m != nil {
+		return m.OpIndex
+	}
+	return 0
+}
+
 type PutResponse struct {
 	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	// if prev_kv is set in the request, the previous key-value pair will be returned.
-	PrevKv               *mvccpb.KeyValue `protobuf:"bytes,2,opt,name=prev_kv,json=prevKv,proto3" json:"prev_kv,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+	PrevKv *mvccpb.KeyValue `protobuf:"bytes,2,opt,name=prev_kv,json=prevKv,proto3" json:"prev_kv,omitempty"`
+	// counter is the value of the key after the put if increment is set in the request.
+	Counter              int64    `protobuf:"varint,3,opt,name=counter,proto3" json:"counter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *PutResponse) Reset()         { *m = PutResponse{} }
@@ -588,6 +763,13 @@ func (m *PutResponse) GetPrevKv() *mvccpb.KeyValue {
 	return nil
 }
 
+func (m *PutResponse) GetCounter() int64 {
+	if m != nil {
+		return m.Counter
+	}
+	return 0
+}
+
 type DeleteRangeRequest struct {
 	// [key,RangeEnd]
 	Key      string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -851,6 +1033,13 @@ type Compare struct {
 	// range_end compares the given target to all keys in the range [key, range_end).
 	// See RangeRequest for more details on key ranges.
 	RangeEnd string `protobuf:"bytes,64,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+
+	// aggregate 对范围内所有key聚合后只比较一次
+	Aggregate Compare_CompareAggregate `protobuf:"varint,65,opt,name=aggregate,proto3,enum=etcdserverpb.Compare_CompareAggregate" json:"aggregate,omitempty"`
+	// count aggregate 为 COUNT 时用于比较的key数
+	Count int64 `protobuf:"varint,66,opt,name=count,proto3" json:"count,omitempty"`
+	// numeric 为 true 时按十进制 int64 比较 VALUE, 不是数字的值比较失败
+	Numeric bool `protobuf:"varint,67,opt,name=numeric,proto3" json:"numeric,omitempty"`
 }
 
 func (m *Compare) Reset()         { *m = Compare{} }
@@ -908,6 +1097,27 @@ func (m *Compare) GetKey() string {
 	return ""
 }
 
+func (m *Compare) GetAggregate() Compare_CompareAggregate {
+	if m != nil {
+		return m.Aggregate
+	}
+	return Compare_NONE
+}
+
+func (m *Compare) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Compare) GetNumeric() bool {
+	if m != nil {
+		return m.Numeric
+	}
+	return false
+}
+
 func (m *Compare) GetVersion() int64 {
 	if m.Compare_Version != nil {
 		return m.Compare_Version.Version
@@ -1121,6 +1331,10 @@ func (*HashRequest) Descriptor() ([]byte, []int) {
 type HashKVRequest struct {
 	// revision是哈希操作的键值存储修订版.
 	Revision int64 `protobuf:"varint,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	// 设置后只计算这些key范围在 revision 时状态的哈希, 结果在 range_hashes 中
+	Ranges []*KeyRange `protobuf:"bytes,2,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	// 每个范围最多返回的切分点数量
+	Splits int64 `protobuf:"varint,3,opt,name=splits,proto3" json:"splits,omitempty"`
 }
 
 func (m *HashKVRequest) Reset()         { *m = HashKVRequest{} }
@@ -1137,12 +1351,135 @@ func (m *HashKVRequest) GetRevision() int64 {
 	return 0
 }
 
+func (m *HashKVRequest) GetRanges() []*KeyRange {
+	if m != nil {
+		return m.Ranges
+	}
+	return nil
+}
+
+func (m *HashKVRequest) GetSplits() int64 {
+	if m != nil {
+		return m.Splits
+	}
+	return 0
+}
+
+// KeyRange 是key范围 [key, range_end)
+type KeyRange struct {
+	// key 为空表示从第一个key开始
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// range_end 为 "\0" 表示不限上界
+	RangeEnd             string   `protobuf:"bytes,2,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyRange) Reset()         { *m = KeyRange{} }
+func (m *KeyRange) String() string { return proto.CompactTextString(m) }
+func (*KeyRange) ProtoMessage()    {}
+
+func (m *KeyRange) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyRange) GetRangeEnd() string {
+	if m != nil {
+		return m.RangeEnd
+	}
+	return ""
+}
+
+// KeyRangeHash 是某个key范围的状态哈希
+type KeyRangeHash struct {
+	Key      string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	RangeEnd string `protobuf:"bytes,2,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+	Hash     uint32 `protobuf:"varint,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	// 范围内的key数
+	Count int64 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	// 范围内key的最大修改修订版本
+	MaxModRevision int64  `protobuf:"varint,5,opt,name=max_mod_revision,json=maxModRevision,proto3" json:"max_mod_revision,omitempty"`
+	FirstKey       string `protobuf:"bytes,6,opt,name=first_key,json=firstKey,proto3" json:"first_key,omitempty"`
+	LastKey        string `protobuf:"bytes,7,opt,name=last_key,json=lastKey,proto3" json:"last_key,omitempty"`
+	// 将范围内的key大致等分的切分点
+	SplitKeys            []string `protobuf:"bytes,8,rep,name=split_keys,json=splitKeys,proto3" json:"split_keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyRangeHash) Reset()         { *m = KeyRangeHash{} }
+func (m *KeyRangeHash) String() string { return proto.CompactTextString(m) }
+func (*KeyRangeHash) ProtoMessage()    {}
+
+func (m *KeyRangeHash) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *KeyRangeHash) GetRangeEnd() string {
+	if m != nil {
+		return m.RangeEnd
+	}
+	return ""
+}
+
+func (m *KeyRangeHash) GetHash() uint32 {
+	if m != nil {
+		return m.Hash
+	}
+	return 0
+}
+
+func (m *KeyRangeHash) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *KeyRangeHash) GetMaxModRevision() int64 {
+	if m != nil {
+		return m.MaxModRevision
+	}
+	return 0
+}
+
+func (m *KeyRangeHash) GetFirstKey() string {
+	if m != nil {
+		return m.FirstKey
+	}
+	return ""
+}
+
+func (m *KeyRangeHash) GetLastKey() string {
+	if m != nil {
+		return m.LastKey
+	}
+	return ""
+}
+
+func (m *KeyRangeHash) GetSplitKeys() []string {
+	if m != nil {
+		return m.SplitKeys
+	}
+	return nil
+}
+
 type HashKVResponse struct {
 	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	// hash is the hash value computed from the responding member's MVCC keys up to a given revision.
 	Hash uint32 `protobuf:"varint,2,opt,name=hash,proto3" json:"hash,omitempty"`
 	// compact_revision is the compacted revision of key-value store when hash begins.
 	CompactRevision int64 `protobuf:"varint,3,opt,name=compact_revision,json=compactRevision,proto3" json:"compact_revision,omitempty"`
+	// 请求设置了 ranges 时各范围的哈希, 顺序与请求相同
+	RangeHashes []*KeyRangeHash `protobuf:"bytes,4,rep,name=range_hashes,json=rangeHashes,proto3" json:"range_hashes,omitempty"`
 }
 
 func (m *HashKVResponse) Reset()         { *m = HashKVResponse{} }
@@ -1173,6 +1510,13 @@ func (m *HashKVResponse) GetCompactRevision() int64 {
 	return 0
 }
 
+func (m *HashKVResponse) GetRangeHashes() []*KeyRangeHash {
+	if m != nil {
+		return m.RangeHashes
+	}
+	return nil
+}
+
 type HashResponse struct {
 	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	// hash is the hash value computed from the responding member's KV's backend.
@@ -1338,6 +1682,21 @@ type WatchCreateRequest struct {
 	WatchId int64 `protobuf:"varint,7,opt,name=watch_id,json=watchId,proto3" json:"watch_id,omitempty"`
 	// 拆分大的变更 成多个watch响应.
 	Fragment bool `protobuf:"varint,8,opt,name=fragment,proto3" json:"fragment,omitempty"`
+	// 只发送值以value_prefix开头的PUT事件
+	ValuePrefix string `protobuf:"bytes,9,opt,name=value_prefix,json=valuePrefix,proto3" json:"value_prefix,omitempty"`
+	// 只发送值匹配RE2表达式value_regex的PUT事件
+	ValueRegex string `protobuf:"bytes,10,opt,name=value_regex,json=valueRegex,proto3" json:"value_regex,omitempty"`
+	// 非0时只发送绑定了该租约的key的PUT事件
+	Lease int64 `protobuf:"varint,11,opt,name=lease,proto3" json:"lease,omitempty"`
+	// 只发送key以其中之一结尾的事件; 值和租约的过滤不会丢弃DELETE事件, 因为它们不携带这些信息
+	KeySuffixes []string `protobuf:"bytes,12,rep,name=key_suffixes,json=keySuffixes,proto3" json:"key_suffixes,omitempty"`
+	// 连接断开后由服务端保留watcher并缓存其事件, 创建响应中返回会话ID
+	Resumable bool `protobuf:"varint,13,opt,name=resumable,proto3" json:"resumable,omitempty"`
+	// 恢复连接断开后服务端保留的watcher, 从会话缓存中重放start_revision之后的事件;
+	// 会话不存在或无法覆盖start_revision时按普通请求创建watcher
+	ResumeSessionId string `protobuf:"bytes,14,opt,name=resume_session_id,json=resumeSessionId,proto3" json:"resume_session_id,omitempty"`
+	// 大于0时, 服务端在该窗口(毫秒)内按key合并事件, 每个key只发送最终状态
+	CoalesceWindowMs int64 `protobuf:"varint,15,opt,name=coalesce_window_ms,json=coalesceWindowMs,proto3" json:"coalesce_window_ms,omitempty"`
 }
 
 func (m *WatchCreateRequest) Reset()         { *m = WatchCreateRequest{} }
@@ -1403,6 +1762,55 @@ func (m *WatchCreateRequest) GetFragment() bool {
 	return false
 }
 
+func (m *WatchCreateRequest) GetValuePrefix() []byte {
+	if m != nil {
+		return []byte(m.ValuePrefix)
+	}
+	return nil
+}
+
+func (m *WatchCreateRequest) GetValueRegex() string {
+	if m != nil {
+		return m.ValueRegex
+	}
+	return ""
+}
+
+func (m *WatchCreateRequest) GetLease() int64 {
+	if m != nil {
+		return m.Lease
+	}
+	return 0
+}
+
+func (m *WatchCreateRequest) GetKeySuffixes() []string {
+	if m != nil {
+		return m.KeySuffixes
+	}
+	return nil
+}
+
+func (m *WatchCreateRequest) GetResumable() bool {
+	if m != nil {
+		return m.Resumable
+	}
+	return false
+}
+
+func (m *WatchCreateRequest) GetResumeSessionId() string {
+	if m != nil {
+		return m.ResumeSessionId
+	}
+	return ""
+}
+
+func (m *WatchCreateRequest) GetCoalesceWindowMs() int64 {
+	if m != nil {
+		return m.CoalesceWindowMs
+	}
+	return 0
+}
+
 type WatchCancelRequest struct {
 	// watch_id is the watcher id to cancel so that no more events are transmitted.
 	WatchId int64 `protobuf:"varint,1,opt,name=watch_id,json=watchId,proto3" json:"watch_id,omitempty"`
@@ -1423,7 +1831,12 @@ func (m *WatchCancelRequest) GetWatchId() int64 {
 }
 
 // WatchProgressRequest 获取watch的状态
-type WatchProgressRequest struct{}
+type WatchProgressRequest struct {
+	// 只请求 WatchId 对应watcher的进度; 即使该watcher尚未同步也会响应,
+	// 响应头中的修订版本之前该watcher的事件都已发出
+	Targeted bool  `protobuf:"varint,1,opt,name=targeted,proto3" json:"targeted,omitempty"`
+	WatchId  int64 `protobuf:"varint,2,opt,name=watch_id,json=watchId,proto3" json:"watch_id,omitempty"`
+}
 
 func (m *WatchProgressRequest) Reset()         { *m = WatchProgressRequest{} }
 func (m *WatchProgressRequest) String() string { return proto.CompactTextString(m) }
@@ -1432,6 +1845,20 @@ func (*WatchProgressRequest) Descriptor() ([]byte, []int) {
 	return fileDescriptor_77a6da22d6a3feb1, []int{23}
 }
 
+func (m *WatchProgressRequest) GetTargeted() bool {
+	if m != nil {
+		return m.Targeted
+	}
+	return false
+}
+
+func (m *WatchProgressRequest) GetWatchId() int64 {
+	if m != nil {
+		return m.WatchId
+	}
+	return 0
+}
+
 type WatchResponse struct {
 	CompactRevision int64           // 压缩操作对应的 revison
 	Header          *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
@@ -1440,7 +1867,11 @@ type WatchResponse struct {
 	Canceled        bool            `protobuf:"varint,4,opt,name=canceled,proto3" json:"canceled,omitempty"`
 	CancelReason    string          `protobuf:"bytes,6,opt,name=cancel_reason,json=cancelReason,proto3" json:"cancel_reason,omitempty"`
 	// framgment is true if large watch response was split over multiple responses.
-	Fragment             bool            `protobuf:"varint,7,opt,name=fragment,proto3" json:"fragment,omitempty"`
+	Fragment bool `protobuf:"varint,7,opt,name=fragment,proto3" json:"fragment,omitempty"`
+	// 可恢复的watcher的创建响应中携带会话ID
+	SessionId string `protobuf:"bytes,8,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// 因积压过多被服务端取消时设置, 客户端从该修订版本重新watch即可收到被丢弃的事件
+	ResumeRevision       int64           `protobuf:"varint,9,opt,name=resume_revision,json=resumeRevision,proto3" json:"resume_revision,omitempty"`
 	Events               []*mvccpb.Event `protobuf:"bytes,11,rep,name=events,proto3" json:"events,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
@@ -1503,6 +1934,20 @@ func (m *WatchResponse) GetFragment() bool {
 	return false
 }
 
+func (m *WatchResponse) GetSessionId() string {
+	if m != nil {
+		return m.SessionId
+	}
+	return ""
+}
+
+func (m *WatchResponse) GetResumeRevision() int64 {
+	if m != nil {
+		return m.ResumeRevision
+	}
+	return 0
+}
+
 func (m *WatchResponse) GetEvents() []*mvccpb.Event {
 	if m != nil {
 		return m.Events
@@ -1510,12 +1955,146 @@ func (m *WatchResponse) GetEvents() []*mvccpb.Event {
 	return nil
 }
 
-type Member struct {
-	// ID is the member ID for this member.
-	ID uint64 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
-	// name is the human-readable name of the member. If the member is not started, the name will be an empty string.
-	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	// peerURLs is the list of URLs the member exposes to the cluster for communication.
+type ListWatchRequest struct {
+	// key is the first key of the range to list and watch.
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// range_end is the end of the range [key, range_end), empty means the single key.
+	RangeEnd string `protobuf:"bytes,2,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+	// 列出初始状态时每个响应最多包含的key数, 0表示使用服务端的默认值
+	PageSize int64 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// 列出该修订版本的状态并从下一个修订版本开始watch, 0表示当前修订版本
+	Revision int64 `protobuf:"varint,4,opt,name=revision,proto3" json:"revision,omitempty"`
+	// watch阶段的事件是否携带变更前的kv
+	PrevKv bool `protobuf:"varint,5,opt,name=prev_kv,json=prevKv,proto3" json:"prev_kv,omitempty"`
+	// watch阶段没有事件时, 定期发送进度通知
+	ProgressNotify       bool     `protobuf:"varint,6,opt,name=progress_notify,json=progressNotify,proto3" json:"progress_notify,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListWatchRequest) Reset()         { *m = ListWatchRequest{} }
+func (m *ListWatchRequest) String() string { return proto.CompactTextString(m) }
+func (*ListWatchRequest) ProtoMessage()    {}
+
+func (m *ListWatchRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ListWatchRequest) GetRangeEnd() string {
+	if m != nil {
+		return m.RangeEnd
+	}
+	return ""
+}
+
+func (m *ListWatchRequest) GetPageSize() int64 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListWatchRequest) GetRevision() int64 {
+	if m != nil {
+		return m.Revision
+	}
+	return 0
+}
+
+func (m *ListWatchRequest) GetPrevKv() bool {
+	if m != nil {
+		return m.PrevKv
+	}
+	return false
+}
+
+func (m *ListWatchRequest) GetProgressNotify() bool {
+	if m != nil {
+		return m.ProgressNotify
+	}
+	return false
+}
+
+type ListWatchResponse struct {
+	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	// 初始状态的一页, 按key排序; 所有页都是 header.revision 时的状态
+	Kvs []*mvccpb.KeyValue `protobuf:"bytes,2,rep,name=kvs,proto3" json:"kvs,omitempty"`
+	// 初始状态已全部发出; 该响应不携带kv, 之后的响应只包含修订版本大于 header.revision 的事件
+	ListDone bool `protobuf:"varint,3,opt,name=list_done,json=listDone,proto3" json:"list_done,omitempty"`
+	// watch阶段的事件
+	Events []*mvccpb.Event `protobuf:"bytes,4,rep,name=events,proto3" json:"events,omitempty"`
+	// 需要的修订版本已被压缩, 流随后关闭, 客户端需要重新开始
+	CompactRevision      int64    `protobuf:"varint,5,opt,name=compact_revision,json=compactRevision,proto3" json:"compact_revision,omitempty"`
+	Canceled             bool     `protobuf:"varint,6,opt,name=canceled,proto3" json:"canceled,omitempty"`
+	CancelReason         string   `protobuf:"bytes,7,opt,name=cancel_reason,json=cancelReason,proto3" json:"cancel_reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListWatchResponse) Reset()         { *m = ListWatchResponse{} }
+func (m *ListWatchResponse) String() string { return proto.CompactTextString(m) }
+func (*ListWatchResponse) ProtoMessage()    {}
+
+func (m *ListWatchResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *ListWatchResponse) GetKvs() []*mvccpb.KeyValue {
+	if m != nil {
+		return m.Kvs
+	}
+	return nil
+}
+
+func (m *ListWatchResponse) GetListDone() bool {
+	if m != nil {
+		return m.ListDone
+	}
+	return false
+}
+
+func (m *ListWatchResponse) GetEvents() []*mvccpb.Event {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *ListWatchResponse) GetCompactRevision() int64 {
+	if m != nil {
+		return m.CompactRevision
+	}
+	return 0
+}
+
+func (m *ListWatchResponse) GetCanceled() bool {
+	if m != nil {
+		return m.Canceled
+	}
+	return false
+}
+
+func (m *ListWatchResponse) GetCancelReason() string {
+	if m != nil {
+		return m.CancelReason
+	}
+	return ""
+}
+
+type Member struct {
+	// ID is the member ID for this member.
+	ID uint64 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// name is the human-readable name of the member. If the member is not started, the name will be an empty string.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// peerURLs is the list of URLs the member exposes to the cluster for communication.
 	PeerURLs []string `protobuf:"bytes,3,rep,name=peerURLs,proto3" json:"peerURLs,omitempty"`
 	// clientURLs is the list of URLs the member exposes to clients for communication. If the member is not started, clientURLs will be empty.
 	ClientURLs []string `protobuf:"bytes,4,rep,name=clientURLs,proto3" json:"clientURLs,omitempty"`
@@ -2061,6 +2640,52 @@ func (m *DowngradeResponse) GetVersion() string {
 	return ""
 }
 
+type MaintenanceModeRequest struct {
+	// action 为 GET 时只查询当前状态, 不经过raft
+	Action               MaintenanceModeRequest_Action `protobuf:"varint,1,opt,name=action,proto3,enum=etcdserverpb.MaintenanceModeRequest_Action" json:"action,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *MaintenanceModeRequest) Reset()         { *m = MaintenanceModeRequest{} }
+func (m *MaintenanceModeRequest) String() string { return proto.CompactTextString(m) }
+func (*MaintenanceModeRequest) ProtoMessage()    {}
+
+func (m *MaintenanceModeRequest) GetAction() MaintenanceModeRequest_Action {
+	if m != nil {
+		return m.Action
+	}
+	return MaintenanceModeRequest_GET
+}
+
+type MaintenanceModeResponse struct {
+	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	// read_only 集群是否处于只读维护模式
+	ReadOnly             bool     `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MaintenanceModeResponse) Reset()         { *m = MaintenanceModeResponse{} }
+func (m *MaintenanceModeResponse) String() string { return proto.CompactTextString(m) }
+func (*MaintenanceModeResponse) ProtoMessage()    {}
+
+func (m *MaintenanceModeResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *MaintenanceModeResponse) GetReadOnly() bool {
+	if m != nil {
+		return m.ReadOnly
+	}
+	return false
+}
+
 type StatusRequest struct{}
 
 func (m *StatusRequest) Reset()         { *m = StatusRequest{} }
@@ -2658,11 +3283,13 @@ func (m *AuthUserAddResponse) GetHeader() *ResponseHeader {
 }
 
 type AuthUserGetResponse struct {
-	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
-	Roles                []string        `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Header               *ResponseHeader        `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Roles                []string               `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	RateLimit            *authpb.RateLimit      `protobuf:"bytes,3,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	Options              *authpb.UserAddOptions `protobuf:"bytes,4,opt,name=options,proto3" json:"options,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
 }
 
 func (m *AuthUserGetResponse) Reset()         { *m = AuthUserGetResponse{} }
@@ -2686,6 +3313,20 @@ func (m *AuthUserGetResponse) GetRoles() []string {
 	return nil
 }
 
+func (m *AuthUserGetResponse) GetRateLimit() *authpb.RateLimit {
+	if m != nil {
+		return m.RateLimit
+	}
+	return nil
+}
+
+func (m *AuthUserGetResponse) GetOptions() *authpb.UserAddOptions {
+	if m != nil {
+		return m.Options
+	}
+	return nil
+}
+
 type AuthUserDeleteResponse struct {
 	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
@@ -2794,6 +3435,7 @@ func (m *AuthRoleAddResponse) GetHeader() *ResponseHeader {
 type AuthRoleGetResponse struct {
 	Header               *ResponseHeader      `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	Perm                 []*authpb.Permission `protobuf:"bytes,2,rep,name=perm,proto3" json:"perm,omitempty"`
+	RateLimit            *authpb.RateLimit    `protobuf:"bytes,3,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
 	XXX_sizecache        int32                `json:"-"`
@@ -2813,71 +3455,452 @@ func (m *AuthRoleGetResponse) GetHeader() *ResponseHeader {
 	return nil
 }
 
-func (m *AuthRoleGetResponse) GetPerm() []*authpb.Permission {
+func (m *AuthRoleGetResponse) GetPerm() []*authpb.Permission {
+	if m != nil {
+		return m.Perm
+	}
+	return nil
+}
+
+func (m *AuthRoleGetResponse) GetRateLimit() *authpb.RateLimit {
+	if m != nil {
+		return m.RateLimit
+	}
+	return nil
+}
+
+type AuthRoleListResponse struct {
+	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Roles                []string        `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AuthRoleListResponse) Reset()         { *m = AuthRoleListResponse{} }
+func (m *AuthRoleListResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthRoleListResponse) ProtoMessage()    {}
+func (*AuthRoleListResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{90}
+}
+
+func (m *AuthRoleListResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *AuthRoleListResponse) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+type AuthUserListResponse struct {
+	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Users                []string        `protobuf:"bytes,2,rep,name=users,proto3" json:"users,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AuthUserListResponse) Reset()         { *m = AuthUserListResponse{} }
+func (m *AuthUserListResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthUserListResponse) ProtoMessage()    {}
+func (*AuthUserListResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_77a6da22d6a3feb1, []int{91}
+}
+
+func (m *AuthUserListResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *AuthUserListResponse) GetUsers() []string {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+type AuthUserTokenCreateRequest struct {
+	// user is the name of the user who owns the token.
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// name identifies the token among the tokens of the user.
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// TTL is the time-to-live of the token in seconds.
+	TTL int64 `protobuf:"varint,3,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	// perm is the set of permissions of the token, it must be a subset of the user's permissions.
+	Perm []*authpb.Permission `protobuf:"bytes,4,rep,name=perm,proto3" json:"perm,omitempty"`
+	// expire_time is filled by etcdserver from TTL, so that every member applies the same deadline.
+	ExpireTime int64 `protobuf:"varint,5,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// hashed_token is filled by etcdserver, the raw token never enters the raft log.
+	HashedToken          string   `protobuf:"bytes,6,opt,name=hashed_token,json=hashedToken,proto3" json:"hashed_token,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthUserTokenCreateRequest) Reset()         { *m = AuthUserTokenCreateRequest{} }
+func (m *AuthUserTokenCreateRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenCreateRequest) ProtoMessage()    {}
+
+func (m *AuthUserTokenCreateRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *AuthUserTokenCreateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AuthUserTokenCreateRequest) GetTTL() int64 {
+	if m != nil {
+		return m.TTL
+	}
+	return 0
+}
+
+func (m *AuthUserTokenCreateRequest) GetPerm() []*authpb.Permission {
+	if m != nil {
+		return m.Perm
+	}
+	return nil
+}
+
+func (m *AuthUserTokenCreateRequest) GetExpireTime() int64 {
+	if m != nil {
+		return m.ExpireTime
+	}
+	return 0
+}
+
+func (m *AuthUserTokenCreateRequest) GetHashedToken() string {
+	if m != nil {
+		return m.HashedToken
+	}
+	return ""
+}
+
+type AuthUserTokenCreateResponse struct {
+	Header *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	// token is the secret which can be used in succeeding RPCs, it is only returned once.
+	Token                string   `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	ExpireTime           int64    `protobuf:"varint,3,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthUserTokenCreateResponse) Reset()         { *m = AuthUserTokenCreateResponse{} }
+func (m *AuthUserTokenCreateResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenCreateResponse) ProtoMessage()    {}
+
+func (m *AuthUserTokenCreateResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *AuthUserTokenCreateResponse) GetToken() string {
+	if m != nil {
+		return m.Token
+	}
+	return ""
+}
+
+func (m *AuthUserTokenCreateResponse) GetExpireTime() int64 {
+	if m != nil {
+		return m.ExpireTime
+	}
+	return 0
+}
+
+type AuthUserTokenListRequest struct {
+	User                 string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthUserTokenListRequest) Reset()         { *m = AuthUserTokenListRequest{} }
+func (m *AuthUserTokenListRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenListRequest) ProtoMessage()    {}
+
+func (m *AuthUserTokenListRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+type AuthUserTokenListResponse struct {
+	Header               *ResponseHeader       `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Tokens               []*authpb.ScopedToken `protobuf:"bytes,2,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *AuthUserTokenListResponse) Reset()         { *m = AuthUserTokenListResponse{} }
+func (m *AuthUserTokenListResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenListResponse) ProtoMessage()    {}
+
+func (m *AuthUserTokenListResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *AuthUserTokenListResponse) GetTokens() []*authpb.ScopedToken {
+	if m != nil {
+		return m.Tokens
+	}
+	return nil
+}
+
+type AuthUserTokenRevokeRequest struct {
+	User                 string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthUserTokenRevokeRequest) Reset()         { *m = AuthUserTokenRevokeRequest{} }
+func (m *AuthUserTokenRevokeRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenRevokeRequest) ProtoMessage()    {}
+
+func (m *AuthUserTokenRevokeRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *AuthUserTokenRevokeRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type AuthUserTokenRevokeResponse struct {
+	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AuthUserTokenRevokeResponse) Reset()         { *m = AuthUserTokenRevokeResponse{} }
+func (m *AuthUserTokenRevokeResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthUserTokenRevokeResponse) ProtoMessage()    {}
+
+func (m *AuthUserTokenRevokeResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+type AuthCheckRequest struct {
+	// user is the name of the user whose permission is evaluated.
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Key  string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// range_end is the upper bound of the checked range, empty means the single key.
+	RangeEnd             string                 `protobuf:"bytes,3,opt,name=range_end,json=rangeEnd,proto3" json:"range_end,omitempty"`
+	PermType             authpb.Permission_Type `protobuf:"varint,4,opt,name=perm_type,json=permType,proto3,enum=authpb.Permission_Type" json:"perm_type,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *AuthCheckRequest) Reset()         { *m = AuthCheckRequest{} }
+func (m *AuthCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthCheckRequest) ProtoMessage()    {}
+
+func (m *AuthCheckRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *AuthCheckRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *AuthCheckRequest) GetRangeEnd() string {
+	if m != nil {
+		return m.RangeEnd
+	}
+	return ""
+}
+
+func (m *AuthCheckRequest) GetPermType() authpb.Permission_Type {
+	if m != nil {
+		return m.PermType
+	}
+	return authpb.READ
+}
+
+type AuthCheckResponse struct {
+	Header  *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
+	Allowed bool            `protobuf:"varint,2,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	// roles are the roles of the user which grant (part of) the checked range.
+	Roles []string `protobuf:"bytes,3,rep,name=roles,proto3" json:"roles,omitempty"`
+	// perms are the permissions of those roles which overlap the checked range.
+	Perms []*authpb.Permission `protobuf:"bytes,4,rep,name=perms,proto3" json:"perms,omitempty"`
+	// reason explains why the request is allowed or denied.
+	Reason               string   `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthCheckResponse) Reset()         { *m = AuthCheckResponse{} }
+func (m *AuthCheckResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthCheckResponse) ProtoMessage()    {}
+
+func (m *AuthCheckResponse) GetHeader() *ResponseHeader {
+	if m != nil {
+		return m.Header
+	}
+	return nil
+}
+
+func (m *AuthCheckResponse) GetAllowed() bool {
+	if m != nil {
+		return m.Allowed
+	}
+	return false
+}
+
+func (m *AuthCheckResponse) GetRoles() []string {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *AuthCheckResponse) GetPerms() []*authpb.Permission {
+	if m != nil {
+		return m.Perms
+	}
+	return nil
+}
+
+func (m *AuthCheckResponse) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type AuthUserSetRateLimitRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// rate_limit replaces the current limit, an empty limit removes it.
+	RateLimit            *authpb.RateLimit `protobuf:"bytes,2,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *AuthUserSetRateLimitRequest) Reset()         { *m = AuthUserSetRateLimitRequest{} }
+func (m *AuthUserSetRateLimitRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthUserSetRateLimitRequest) ProtoMessage()    {}
+
+func (m *AuthUserSetRateLimitRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AuthUserSetRateLimitRequest) GetRateLimit() *authpb.RateLimit {
 	if m != nil {
-		return m.Perm
+		return m.RateLimit
 	}
 	return nil
 }
 
-type AuthRoleListResponse struct {
+type AuthUserSetRateLimitResponse struct {
 	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
-	Roles                []string        `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
 	XXX_sizecache        int32           `json:"-"`
 }
 
-func (m *AuthRoleListResponse) Reset()         { *m = AuthRoleListResponse{} }
-func (m *AuthRoleListResponse) String() string { return proto.CompactTextString(m) }
-func (*AuthRoleListResponse) ProtoMessage()    {}
-func (*AuthRoleListResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{90}
-}
+func (m *AuthUserSetRateLimitResponse) Reset()         { *m = AuthUserSetRateLimitResponse{} }
+func (m *AuthUserSetRateLimitResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthUserSetRateLimitResponse) ProtoMessage()    {}
 
-func (m *AuthRoleListResponse) GetHeader() *ResponseHeader {
+func (m *AuthUserSetRateLimitResponse) GetHeader() *ResponseHeader {
 	if m != nil {
 		return m.Header
 	}
 	return nil
 }
 
-func (m *AuthRoleListResponse) GetRoles() []string {
+type AuthRoleSetRateLimitRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// rate_limit replaces the current limit, an empty limit removes it.
+	RateLimit            *authpb.RateLimit `protobuf:"bytes,2,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *AuthRoleSetRateLimitRequest) Reset()         { *m = AuthRoleSetRateLimitRequest{} }
+func (m *AuthRoleSetRateLimitRequest) String() string { return proto.CompactTextString(m) }
+func (*AuthRoleSetRateLimitRequest) ProtoMessage()    {}
+
+func (m *AuthRoleSetRateLimitRequest) GetName() string {
 	if m != nil {
-		return m.Roles
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AuthRoleSetRateLimitRequest) GetRateLimit() *authpb.RateLimit {
+	if m != nil {
+		return m.RateLimit
 	}
 	return nil
 }
 
-type AuthUserListResponse struct {
+type AuthRoleSetRateLimitResponse struct {
 	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
-	Users                []string        `protobuf:"bytes,2,rep,name=users,proto3" json:"users,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
 	XXX_sizecache        int32           `json:"-"`
 }
 
-func (m *AuthUserListResponse) Reset()         { *m = AuthUserListResponse{} }
-func (m *AuthUserListResponse) String() string { return proto.CompactTextString(m) }
-func (*AuthUserListResponse) ProtoMessage()    {}
-func (*AuthUserListResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_77a6da22d6a3feb1, []int{91}
-}
+func (m *AuthRoleSetRateLimitResponse) Reset()         { *m = AuthRoleSetRateLimitResponse{} }
+func (m *AuthRoleSetRateLimitResponse) String() string { return proto.CompactTextString(m) }
+func (*AuthRoleSetRateLimitResponse) ProtoMessage()    {}
 
-func (m *AuthUserListResponse) GetHeader() *ResponseHeader {
+func (m *AuthRoleSetRateLimitResponse) GetHeader() *ResponseHeader {
 	if m != nil {
 		return m.Header
 	}
 	return nil
 }
 
-func (m *AuthUserListResponse) GetUsers() []string {
-	if m != nil {
-		return m.Users
-	}
-	return nil
-}
-
 type AuthRoleDeleteResponse struct {
 	Header               *ResponseHeader `protobuf:"bytes,1,opt,name=header,proto3" json:"header,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
@@ -2947,9 +3970,11 @@ func init() {
 	proto.RegisterEnum("etcdserverpb.RangeRequest_SortTarget", RangeRequest_SortTarget_name, RangeRequest_SortTarget_value)
 	proto.RegisterEnum("etcdserverpb.Compare_CompareResult", Compare_CompareResult_name, Compare_CompareResult_value)
 	proto.RegisterEnum("etcdserverpb.Compare_CompareTarget", Compare_CompareTarget_name, Compare_CompareTarget_value)
+	proto.RegisterEnum("etcdserverpb.Compare_CompareAggregate", Compare_CompareAggregate_name, Compare_CompareAggregate_value)
 	proto.RegisterEnum("etcdserverpb.WatchCreateRequest_FilterType", WatchCreateRequest_FilterType_name, WatchCreateRequest_FilterType_value)
 	proto.RegisterEnum("etcdserverpb.AlarmRequest_AlarmAction", AlarmRequest_AlarmAction_name, AlarmRequest_AlarmAction_value)
 	proto.RegisterEnum("etcdserverpb.DowngradeRequest_DowngradeAction", DowngradeRequest_DowngradeAction_name, DowngradeRequest_DowngradeAction_value)
+	proto.RegisterEnum("etcdserverpb.MaintenanceModeRequest_Action", MaintenanceModeRequest_Action_name, MaintenanceModeRequest_Action_value)
 	proto.RegisterType((*ResponseHeader)(nil), "etcdserverpb.ResponseHeader")
 	proto.RegisterType((*RangeRequest)(nil), "etcdserverpb.RangeRequest")
 	proto.RegisterType((*RangeResponse)(nil), "etcdserverpb.RangeResponse")
@@ -3045,6 +4070,26 @@ func init() {
 	proto.RegisterType((*AuthRoleDeleteResponse)(nil), "etcdserverpb.AuthRoleDeleteResponse")
 	proto.RegisterType((*AuthRoleGrantPermissionResponse)(nil), "etcdserverpb.AuthRoleGrantPermissionResponse")
 	proto.RegisterType((*AuthRoleRevokePermissionResponse)(nil), "etcdserverpb.AuthRoleRevokePermissionResponse")
+	proto.RegisterType((*AuthUserTokenCreateRequest)(nil), "etcdserverpb.AuthUserTokenCreateRequest")
+	proto.RegisterType((*AuthUserTokenCreateResponse)(nil), "etcdserverpb.AuthUserTokenCreateResponse")
+	proto.RegisterType((*AuthUserTokenListRequest)(nil), "etcdserverpb.AuthUserTokenListRequest")
+	proto.RegisterType((*AuthUserTokenListResponse)(nil), "etcdserverpb.AuthUserTokenListResponse")
+	proto.RegisterType((*AuthUserTokenRevokeRequest)(nil), "etcdserverpb.AuthUserTokenRevokeRequest")
+	proto.RegisterType((*AuthUserTokenRevokeResponse)(nil), "etcdserverpb.AuthUserTokenRevokeResponse")
+	proto.RegisterType((*AuthCheckRequest)(nil), "etcdserverpb.AuthCheckRequest")
+	proto.RegisterType((*AuthCheckResponse)(nil), "etcdserverpb.AuthCheckResponse")
+	proto.RegisterType((*AuthUserSetRateLimitRequest)(nil), "etcdserverpb.AuthUserSetRateLimitRequest")
+	proto.RegisterType((*AuthUserSetRateLimitResponse)(nil), "etcdserverpb.AuthUserSetRateLimitResponse")
+	proto.RegisterType((*AuthRoleSetRateLimitRequest)(nil), "etcdserverpb.AuthRoleSetRateLimitRequest")
+	proto.RegisterType((*AuthRoleSetRateLimitResponse)(nil), "etcdserverpb.AuthRoleSetRateLimitResponse")
+	proto.RegisterType((*ListWatchRequest)(nil), "etcdserverpb.ListWatchRequest")
+	proto.RegisterType((*ListWatchResponse)(nil), "etcdserverpb.ListWatchResponse")
+	proto.RegisterType((*ValueRef)(nil), "etcdserverpb.ValueRef")
+	proto.RegisterType((*Increment)(nil), "etcdserverpb.Increment")
+	proto.RegisterType((*KeyRange)(nil), "etcdserverpb.KeyRange")
+	proto.RegisterType((*KeyRangeHash)(nil), "etcdserverpb.KeyRangeHash")
+	proto.RegisterType((*MaintenanceModeRequest)(nil), "etcdserverpb.MaintenanceModeRequest")
+	proto.RegisterType((*MaintenanceModeResponse)(nil), "etcdserverpb.MaintenanceModeResponse")
 }
 
 func init() { proto.RegisterFile("rpc.proto", fileDescriptor_77a6da22d6a3feb1) }
@@ -3548,6 +4593,9 @@ type WatchClient interface {
 	// for several watches at once. The entire event history can be watched starting from the
 	// last compaction revision.
 	Watch(ctx context.Context, opts ...grpc.CallOption) (Watch_WatchClient, error)
+	// ListWatch streams the state of a key range at a consistent revision page by page,
+	// then a marker response, then the events after that revision.
+	ListWatch(ctx context.Context, in *ListWatchRequest, opts ...grpc.CallOption) (Watch_ListWatchClient, error)
 }
 
 type watchClient struct {
@@ -3589,10 +4637,44 @@ func (x *watchWatchClient) Recv() (*WatchResponse, error) {
 	return m, nil
 }
 
+func (c *watchClient) ListWatch(ctx context.Context, in *ListWatchRequest, opts ...grpc.CallOption) (Watch_ListWatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Watch_serviceDesc.Streams[1], "/etcdserverpb.Watch/ListWatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &watchListWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Watch_ListWatchClient interface {
+	Recv() (*ListWatchResponse, error)
+	grpc.ClientStream
+}
+
+type watchListWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *watchListWatchClient) Recv() (*ListWatchResponse, error) {
+	m := new(ListWatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 type WatchServer interface {
 	// Watch 观察正在发生或已经发生的事件.输入和输出都是流;输入流用于创建和取消监视和输出
 	// 流发送事件.一个watch RPC可以在多个key range上watch ,一次为几个watch stream event .整个事件历史可以从最后的压缩修订开始观看.
 	Watch(Watch_WatchServer) error
+	// ListWatch 按页发送某个修订版本的范围状态, 然后发送分界响应, 之后是该修订版本之后的事件
+	ListWatch(*ListWatchRequest, Watch_ListWatchServer) error
 }
 
 // UnimplementedWatchServer can be embedded to have forward compatible implementations.
@@ -3602,6 +4684,10 @@ func (*UnimplementedWatchServer) Watch(srv Watch_WatchServer) error {
 	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
 }
 
+func (*UnimplementedWatchServer) ListWatch(req *ListWatchRequest, srv Watch_ListWatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListWatch not implemented")
+}
+
 func RegisterWatchServer(s *grpc.Server, srv WatchServer) {
 	s.RegisterService(&_Watch_serviceDesc, srv)
 }
@@ -3632,6 +4718,27 @@ func (x *watchWatchServer) Recv() (*WatchRequest, error) {
 	return m, nil
 }
 
+func _Watch_ListWatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListWatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WatchServer).ListWatch(m, &watchListWatchServer{stream})
+}
+
+type Watch_ListWatchServer interface {
+	Send(*ListWatchResponse) error
+	grpc.ServerStream
+}
+
+type watchListWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *watchListWatchServer) Send(m *ListWatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Watch_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "etcdserverpb.Watch",
 	HandlerType: (*WatchServer)(nil),
@@ -3643,6 +4750,11 @@ var _Watch_serviceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "ListWatch",
+			Handler:       _Watch_ListWatch_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "rpc.proto",
 }
@@ -3855,6 +4967,7 @@ type MaintenanceClient interface {
 	Snapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (Maintenance_SnapshotClient, error)
 	MoveLeader(ctx context.Context, in *MoveLeaderRequest, opts ...grpc.CallOption) (*MoveLeaderResponse, error)
 	Downgrade(ctx context.Context, in *DowngradeRequest, opts ...grpc.CallOption) (*DowngradeResponse, error)
+	MaintenanceMode(ctx context.Context, in *MaintenanceModeRequest, opts ...grpc.CallOption) (*MaintenanceModeResponse, error)
 }
 
 type maintenanceClient struct {
@@ -3961,6 +5074,15 @@ func (c *maintenanceClient) Downgrade(ctx context.Context, in *DowngradeRequest,
 	return out, nil
 }
 
+func (c *maintenanceClient) MaintenanceMode(ctx context.Context, in *MaintenanceModeRequest, opts ...grpc.CallOption) (*MaintenanceModeResponse, error) {
+	out := new(MaintenanceModeResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Maintenance/MaintenanceMode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 type MaintenanceServer interface {
 	Alarm(context.Context, *AlarmRequest) (*AlarmResponse, error)
 	Status(context.Context, *StatusRequest) (*StatusResponse, error)
@@ -3970,6 +5092,7 @@ type MaintenanceServer interface {
 	Snapshot(*SnapshotRequest, Maintenance_SnapshotServer) error
 	MoveLeader(context.Context, *MoveLeaderRequest) (*MoveLeaderResponse, error)
 	Downgrade(context.Context, *DowngradeRequest) (*DowngradeResponse, error)
+	MaintenanceMode(context.Context, *MaintenanceModeRequest) (*MaintenanceModeResponse, error) // 查询或切换只读维护模式
 }
 
 func RegisterMaintenanceServer(s *grpc.Server, srv MaintenanceServer) {
@@ -4123,6 +5246,24 @@ func _Maintenance_Downgrade_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Maintenance_MaintenanceMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MaintenanceModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintenanceServer).MaintenanceMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Maintenance/MaintenanceMode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintenanceServer).MaintenanceMode(ctx, req.(*MaintenanceModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Maintenance_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "etcdserverpb.Maintenance",
 	HandlerType: (*MaintenanceServer)(nil),
@@ -4155,6 +5296,10 @@ var _Maintenance_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Downgrade",
 			Handler:    _Maintenance_Downgrade_Handler,
 		},
+		{
+			MethodName: "MaintenanceMode",
+			Handler:    _Maintenance_MaintenanceMode_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -4187,6 +5332,15 @@ type AuthClient interface {
 	RoleDelete(ctx context.Context, in *AuthRoleDeleteRequest, opts ...grpc.CallOption) (*AuthRoleDeleteResponse, error)
 	RoleGrantPermission(ctx context.Context, in *AuthRoleGrantPermissionRequest, opts ...grpc.CallOption) (*AuthRoleGrantPermissionResponse, error)
 	RoleRevokePermission(ctx context.Context, in *AuthRoleRevokePermissionRequest, opts ...grpc.CallOption) (*AuthRoleRevokePermissionResponse, error)
+	UserTokenCreate(ctx context.Context, in *AuthUserTokenCreateRequest, opts ...grpc.CallOption) (*AuthUserTokenCreateResponse, error)
+	UserTokenList(ctx context.Context, in *AuthUserTokenListRequest, opts ...grpc.CallOption) (*AuthUserTokenListResponse, error)
+	UserTokenRevoke(ctx context.Context, in *AuthUserTokenRevokeRequest, opts ...grpc.CallOption) (*AuthUserTokenRevokeResponse, error)
+	// AuthCheck evaluates whether a user is permitted to access a key range and explains the decision.
+	AuthCheck(ctx context.Context, in *AuthCheckRequest, opts ...grpc.CallOption) (*AuthCheckResponse, error)
+	// UserSetRateLimit sets the request rate limit of a specified user.
+	UserSetRateLimit(ctx context.Context, in *AuthUserSetRateLimitRequest, opts ...grpc.CallOption) (*AuthUserSetRateLimitResponse, error)
+	// RoleSetRateLimit sets the request rate limit shared by all users of a specified role.
+	RoleSetRateLimit(ctx context.Context, in *AuthRoleSetRateLimitRequest, opts ...grpc.CallOption) (*AuthRoleSetRateLimitResponse, error)
 }
 
 type authClient struct {
@@ -4350,6 +5504,60 @@ func (c *authClient) RoleRevokePermission(ctx context.Context, in *AuthRoleRevok
 	return out, nil
 }
 
+func (c *authClient) UserTokenCreate(ctx context.Context, in *AuthUserTokenCreateRequest, opts ...grpc.CallOption) (*AuthUserTokenCreateResponse, error) {
+	out := new(AuthUserTokenCreateResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/UserTokenCreate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UserTokenList(ctx context.Context, in *AuthUserTokenListRequest, opts ...grpc.CallOption) (*AuthUserTokenListResponse, error) {
+	out := new(AuthUserTokenListResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/UserTokenList", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UserTokenRevoke(ctx context.Context, in *AuthUserTokenRevokeRequest, opts ...grpc.CallOption) (*AuthUserTokenRevokeResponse, error) {
+	out := new(AuthUserTokenRevokeResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/UserTokenRevoke", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) AuthCheck(ctx context.Context, in *AuthCheckRequest, opts ...grpc.CallOption) (*AuthCheckResponse, error) {
+	out := new(AuthCheckResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/AuthCheck", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) UserSetRateLimit(ctx context.Context, in *AuthUserSetRateLimitRequest, opts ...grpc.CallOption) (*AuthUserSetRateLimitResponse, error) {
+	out := new(AuthUserSetRateLimitResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/UserSetRateLimit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authClient) RoleSetRateLimit(ctx context.Context, in *AuthRoleSetRateLimitRequest, opts ...grpc.CallOption) (*AuthRoleSetRateLimitResponse, error) {
+	out := new(AuthRoleSetRateLimitResponse)
+	err := c.cc.Invoke(ctx, "/etcdserverpb.Auth/RoleSetRateLimit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 type AuthServer interface {
 	AuthEnable(context.Context, *AuthEnableRequest) (*AuthEnableResponse, error)
 	AuthDisable(context.Context, *AuthDisableRequest) (*AuthDisableResponse, error)
@@ -4368,6 +5576,15 @@ type AuthServer interface {
 	RoleDelete(context.Context, *AuthRoleDeleteRequest) (*AuthRoleDeleteResponse, error)
 	RoleGrantPermission(context.Context, *AuthRoleGrantPermissionRequest) (*AuthRoleGrantPermissionResponse, error)
 	RoleRevokePermission(context.Context, *AuthRoleRevokePermissionRequest) (*AuthRoleRevokePermissionResponse, error)
+	UserTokenCreate(context.Context, *AuthUserTokenCreateRequest) (*AuthUserTokenCreateResponse, error)
+	UserTokenList(context.Context, *AuthUserTokenListRequest) (*AuthUserTokenListResponse, error)
+	UserTokenRevoke(context.Context, *AuthUserTokenRevokeRequest) (*AuthUserTokenRevokeResponse, error)
+	// AuthCheck evaluates whether a user is permitted to access a key range and explains the decision.
+	AuthCheck(context.Context, *AuthCheckRequest) (*AuthCheckResponse, error)
+	// UserSetRateLimit sets the request rate limit of a specified user.
+	UserSetRateLimit(context.Context, *AuthUserSetRateLimitRequest) (*AuthUserSetRateLimitResponse, error)
+	// RoleSetRateLimit sets the request rate limit shared by all users of a specified role.
+	RoleSetRateLimit(context.Context, *AuthRoleSetRateLimitRequest) (*AuthRoleSetRateLimitResponse, error)
 }
 
 func RegisterAuthServer(s *grpc.Server, srv AuthServer) {
@@ -4680,6 +5897,114 @@ func _Auth_RoleRevokePermission_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Auth_UserTokenCreate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthUserTokenCreateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).UserTokenCreate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/UserTokenCreate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UserTokenCreate(ctx, req.(*AuthUserTokenCreateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_UserTokenList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthUserTokenListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).UserTokenList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/UserTokenList",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UserTokenList(ctx, req.(*AuthUserTokenListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_UserTokenRevoke_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthUserTokenRevokeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).UserTokenRevoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/UserTokenRevoke",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UserTokenRevoke(ctx, req.(*AuthUserTokenRevokeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_AuthCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).AuthCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/AuthCheck",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).AuthCheck(ctx, req.(*AuthCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_UserSetRateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthUserSetRateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).UserSetRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/UserSetRateLimit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).UserSetRateLimit(ctx, req.(*AuthUserSetRateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Auth_RoleSetRateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthRoleSetRateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServer).RoleSetRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/etcdserverpb.Auth/RoleSetRateLimit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServer).RoleSetRateLimit(ctx, req.(*AuthRoleSetRateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Auth_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "etcdserverpb.Auth",
 	HandlerType: (*AuthServer)(nil),
@@ -4752,6 +6077,30 @@ var _Auth_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RoleRevokePermission",
 			Handler:    _Auth_RoleRevokePermission_Handler,
 		},
+		{
+			MethodName: "UserTokenCreate",
+			Handler:    _Auth_UserTokenCreate_Handler,
+		},
+		{
+			MethodName: "UserTokenList",
+			Handler:    _Auth_UserTokenList_Handler,
+		},
+		{
+			MethodName: "UserTokenRevoke",
+			Handler:    _Auth_UserTokenRevoke_Handler,
+		},
+		{
+			MethodName: "AuthCheck",
+			Handler:    _Auth_AuthCheck_Handler,
+		},
+		{
+			MethodName: "UserSetRateLimit",
+			Handler:    _Auth_UserSetRateLimit_Handler,
+		},
+		{
+			MethodName: "RoleSetRateLimit",
+			Handler:    _Auth_RoleSetRateLimit_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "rpc.proto",
@@ -4863,6 +6212,26 @@ func (m *AuthUserListResponse) Marshal() (dAtA []byte, err error)             {
 func (m *AuthRoleDeleteResponse) Marshal() (dAtA []byte, err error)           { return json.Marshal(m) }
 func (m *AuthRoleGrantPermissionResponse) Marshal() (dAtA []byte, err error)  { return json.Marshal(m) }
 func (m *AuthRoleRevokePermissionResponse) Marshal() (dAtA []byte, err error) { return json.Marshal(m) }
+func (m *AuthUserTokenCreateRequest) Marshal() (dAtA []byte, err error)       { return json.Marshal(m) }
+func (m *AuthUserTokenCreateResponse) Marshal() (dAtA []byte, err error)      { return json.Marshal(m) }
+func (m *AuthUserTokenListRequest) Marshal() (dAtA []byte, err error)         { return json.Marshal(m) }
+func (m *AuthUserTokenListResponse) Marshal() (dAtA []byte, err error)        { return json.Marshal(m) }
+func (m *AuthUserTokenRevokeRequest) Marshal() (dAtA []byte, err error)       { return json.Marshal(m) }
+func (m *AuthUserTokenRevokeResponse) Marshal() (dAtA []byte, err error)      { return json.Marshal(m) }
+func (m *AuthCheckRequest) Marshal() (dAtA []byte, err error)                 { return json.Marshal(m) }
+func (m *AuthCheckResponse) Marshal() (dAtA []byte, err error)                { return json.Marshal(m) }
+func (m *AuthUserSetRateLimitRequest) Marshal() (dAtA []byte, err error)      { return json.Marshal(m) }
+func (m *AuthUserSetRateLimitResponse) Marshal() (dAtA []byte, err error)     { return json.Marshal(m) }
+func (m *AuthRoleSetRateLimitRequest) Marshal() (dAtA []byte, err error)      { return json.Marshal(m) }
+func (m *AuthRoleSetRateLimitResponse) Marshal() (dAtA []byte, err error)     { return json.Marshal(m) }
+func (m *ListWatchRequest) Marshal() (dAtA []byte, err error)                 { return json.Marshal(m) }
+func (m *ListWatchResponse) Marshal() (dAtA []byte, err error)                { return json.Marshal(m) }
+func (m *ValueRef) Marshal() (dAtA []byte, err error)                         { return json.Marshal(m) }
+func (m *Increment) Marshal() (dAtA []byte, err error)                        { return json.Marshal(m) }
+func (m *KeyRange) Marshal() (dAtA []byte, err error)                         { return json.Marshal(m) }
+func (m *KeyRangeHash) Marshal() (dAtA []byte, err error)                     { return json.Marshal(m) }
+func (m *MaintenanceModeRequest) Marshal() (dAtA []byte, err error)           { return json.Marshal(m) }
+func (m *MaintenanceModeResponse) Marshal() (dAtA []byte, err error)          { return json.Marshal(m) }
 
 func (m *ResponseHeader) Size() (n int)         { marshal, _ := json.Marshal(m); return len(marshal) }
 func (m *RangeRequest) Size() (n int)           { marshal, _ := json.Marshal(m); return len(marshal) }
@@ -5023,6 +6392,53 @@ func (m *AuthRoleRevokePermissionResponse) Size() (n int) {
 	marshal, _ := json.Marshal(m)
 	return len(marshal)
 }
+func (m *AuthUserTokenCreateRequest) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthUserTokenCreateResponse) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthUserTokenListRequest) Size() (n int) { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *AuthUserTokenListResponse) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthUserTokenRevokeRequest) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthUserTokenRevokeResponse) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthCheckRequest) Size() (n int)  { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *AuthCheckResponse) Size() (n int) { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *AuthUserSetRateLimitRequest) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthUserSetRateLimitResponse) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthRoleSetRateLimitRequest) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *AuthRoleSetRateLimitResponse) Size() (n int) {
+	marshal, _ := json.Marshal(m)
+	return len(marshal)
+}
+func (m *ListWatchRequest) Size() (n int)        { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *ListWatchResponse) Size() (n int)       { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *ValueRef) Size() (n int)                { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *Increment) Size() (n int)               { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *KeyRange) Size() (n int)                { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *KeyRangeHash) Size() (n int)            { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *MaintenanceModeRequest) Size() (n int)  { marshal, _ := json.Marshal(m); return len(marshal) }
+func (m *MaintenanceModeResponse) Size() (n int) { marshal, _ := json.Marshal(m); return len(marshal) }
 
 func sovRpc(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
@@ -5135,6 +6551,26 @@ func (m *AuthRoleGrantPermissionResponse) Unmarshal(dAtA []byte) error {
 func (m *AuthRoleRevokePermissionResponse) Unmarshal(dAtA []byte) error {
 	return json.Unmarshal(dAtA, m)
 }
+func (m *AuthUserTokenCreateRequest) Unmarshal(dAtA []byte) error   { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserTokenCreateResponse) Unmarshal(dAtA []byte) error  { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserTokenListRequest) Unmarshal(dAtA []byte) error     { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserTokenListResponse) Unmarshal(dAtA []byte) error    { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserTokenRevokeRequest) Unmarshal(dAtA []byte) error   { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserTokenRevokeResponse) Unmarshal(dAtA []byte) error  { return json.Unmarshal(dAtA, m) }
+func (m *AuthCheckRequest) Unmarshal(dAtA []byte) error             { return json.Unmarshal(dAtA, m) }
+func (m *AuthCheckResponse) Unmarshal(dAtA []byte) error            { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserSetRateLimitRequest) Unmarshal(dAtA []byte) error  { return json.Unmarshal(dAtA, m) }
+func (m *AuthUserSetRateLimitResponse) Unmarshal(dAtA []byte) error { return json.Unmarshal(dAtA, m) }
+func (m *AuthRoleSetRateLimitRequest) Unmarshal(dAtA []byte) error  { return json.Unmarshal(dAtA, m) }
+func (m *AuthRoleSetRateLimitResponse) Unmarshal(dAtA []byte) error { return json.Unmarshal(dAtA, m) }
+func (m *ListWatchRequest) Unmarshal(dAtA []byte) error             { return json.Unmarshal(dAtA, m) }
+func (m *ListWatchResponse) Unmarshal(dAtA []byte) error            { return json.Unmarshal(dAtA, m) }
+func (m *ValueRef) Unmarshal(dAtA []byte) error                     { return json.Unmarshal(dAtA, m) }
+func (m *Increment) Unmarshal(dAtA []byte) error                    { return json.Unmarshal(dAtA, m) }
+func (m *KeyRange) Unmarshal(dAtA []byte) error                     { return json.Unmarshal(dAtA, m) }
+func (m *KeyRangeHash) Unmarshal(dAtA []byte) error                 { return json.Unmarshal(dAtA, m) }
+func (m *MaintenanceModeRequest) Unmarshal(dAtA []byte) error       { return json.Unmarshal(dAtA, m) }
+func (m *MaintenanceModeResponse) Unmarshal(dAtA []byte) error      { return json.Unmarshal(dAtA, m) }
 
 type alarmMember struct {
 	MemberID uint64 `protobuf:"varint,1,opt,name=memberID,proto3" json:"memberID,omitempty"`
@@ -5150,6 +6586,12 @@ func (m *AlarmMember) Marshal() (dAtA []byte, err error) {
 		a.Alarm = "NOSPACE"
 	case 2:
 		a.Alarm = "CORRUPT"
+	case 3:
+		a.Alarm = "SLOWDISK"
+	case 4:
+		a.Alarm = "CLOCKSKEW"
+	case 5:
+		a.Alarm = "APPLYLAG"
 	}
 
 	return json.Marshal(&a)
@@ -5167,6 +6609,12 @@ func (m *AlarmMember) Unmarshal(dAtA []byte) error {
 			m.Alarm = 1
 		case "CORRUPT":
 			m.Alarm = 2
+		case "SLOWDISK":
+			m.Alarm = 3
+		case "CLOCKSKEW":
+			m.Alarm = 4
+		case "APPLYLAG":
+			m.Alarm = 5
 		}
 	}
 	return err
@@ -0,0 +1,118 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hashtree 比较两个成员在同一修订版本上各key范围的哈希, 对不一致的范围按切分点逐层细分,
+// 相当于按需展开的 Merkle 树, 最终定位到数据不一致的key范围.
+package hashtree
+
+import "fmt"
+
+// Range 是key范围 [Key, End); Key 为空表示从第一个key开始, End 为空表示不限上界
+type Range struct {
+	Key string
+	End string
+}
+
+// Hash 是某个key范围的状态哈希
+type Hash struct {
+	Range
+	Hash           uint32
+	Count          int64    // 范围内的key数
+	MaxModRevision int64    // 范围内key的最大修改修订版本
+	FirstKey       string   // 范围内的第一个key
+	LastKey        string   // 范围内的最后一个key
+	SplitKeys      []string // 将范围内的key大致等分的切分点, 均大于 FirstKey
+}
+
+// HashFunc 在同一修订版本上按顺序计算各范围的哈希, 每个范围最多返回 splits 个切分点
+type HashFunc func(ranges []Range, splits int) ([]Hash, error)
+
+// Mismatch 是无法再细分的不一致范围
+type Mismatch struct {
+	Range
+	Local  Hash
+	Remote Hash
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("[%q, %q) 本地(key数=%d, key=[%q, %q], 最大修改修订版本=%d, 哈希=%d) 远端(key数=%d, key=[%q, %q], 最大修改修订版本=%d, 哈希=%d)",
+		m.Key, m.End,
+		m.Local.Count, m.Local.FirstKey, m.Local.LastKey, m.Local.MaxModRevision, m.Local.Hash,
+		m.Remote.Count, m.Remote.FirstKey, m.Remote.LastKey, m.Remote.MaxModRevision, m.Remote.Hash)
+}
+
+// Config 控制逐层细分的范围
+type Config struct {
+	Fanout        int // 每层把一个范围最多切成几份
+	MaxDepth      int // 最多细分的层数
+	MaxMismatches int // 找到这么多不一致的范围后停止
+}
+
+var DefaultConfig = Config{Fanout: 16, MaxDepth: 8, MaxMismatches: 32}
+
+// Diff 从整个key空间开始逐层比较 local 与 remote, 返回不一致且无法再细分的范围.
+// 两边都只有不超过一个key, 或达到 MaxDepth 时停止细分.
+func Diff(local, remote HashFunc, cfg Config) ([]Mismatch, error) {
+	if cfg.Fanout < 2 || cfg.MaxDepth < 1 || cfg.MaxMismatches < 1 {
+		return nil, fmt.Errorf("hashtree: invalid config %+v", cfg)
+	}
+	var mismatches []Mismatch
+	level := []Range{{}}
+	for depth := 1; len(level) > 0; depth++ {
+		lh, err := local(level, cfg.Fanout-1)
+		if err != nil {
+			return mismatches, err
+		}
+		rh, err := remote(level, cfg.Fanout-1)
+		if err != nil {
+			return mismatches, err
+		}
+		if len(lh) != len(level) || len(rh) != len(level) {
+			return mismatches, fmt.Errorf("hashtree: expected %d range hashes, got %d local and %d remote", len(level), len(lh), len(rh))
+		}
+
+		var next []Range
+		for i, r := range level {
+			l, rm := lh[i], rh[i]
+			if l.Hash == rm.Hash && l.Count == rm.Count {
+				continue
+			}
+			splits := l.SplitKeys
+			if len(rm.SplitKeys) > len(splits) {
+				splits = rm.SplitKeys
+			}
+			if len(splits) == 0 || depth >= cfg.MaxDepth {
+				mismatches = append(mismatches, Mismatch{Range: r, Local: l, Remote: rm})
+				if len(mismatches) >= cfg.MaxMismatches {
+					return mismatches, nil
+				}
+				continue
+			}
+			next = append(next, split(r, splits)...)
+		}
+		level = next
+	}
+	return mismatches, nil
+}
+
+// split 按递增的切分点把范围切成 len(splits)+1 份
+func split(r Range, splits []string) []Range {
+	ranges := make([]Range, 0, len(splits)+1)
+	start := r.Key
+	for _, k := range splits {
+		ranges = append(ranges, Range{Key: start, End: k})
+		start = k
+	}
+	return append(ranges, Range{Key: start, End: r.End})
+}
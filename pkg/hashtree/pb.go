@@ -0,0 +1,56 @@
+// Copyright 2016 The etcd Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashtree
+
+import pb "github.com/ls-2018/etcd_cn/offical/etcdserverpb"
+
+// HashKV 请求与响应中 range_end 为 "\x00" 表示不限上界, 对应 Range.End 为空
+
+// RangesToPb 转换为 HashKV 请求中的key范围
+func RangesToPb(ranges []Range) []*pb.KeyRange {
+	krs := make([]*pb.KeyRange, len(ranges))
+	for i, r := range ranges {
+		krs[i] = &pb.KeyRange{Key: r.Key, RangeEnd: RangeEndToPb(r.End)}
+	}
+	return krs
+}
+
+// RangeEndToPb 转换范围的上界
+func RangeEndToPb(end string) string {
+	if end == "" {
+		return "\x00"
+	}
+	return end
+}
+
+// HashesFromPb 转换 HashKV 响应中各范围的哈希
+func HashesFromPb(hashes []*pb.KeyRangeHash) []Hash {
+	hs := make([]Hash, len(hashes))
+	for i, h := range hashes {
+		hs[i] = Hash{
+			Range:          Range{Key: h.Key, End: h.RangeEnd},
+			Hash:           h.Hash,
+			Count:          h.Count,
+			MaxModRevision: h.MaxModRevision,
+			FirstKey:       h.FirstKey,
+			LastKey:        h.LastKey,
+			SplitKeys:      h.SplitKeys,
+		}
+		if h.RangeEnd == "\x00" {
+			hs[i].End = ""
+		}
+	}
+	return hs
+}
@@ -119,6 +119,11 @@ type raft struct {
 	pendingReadIndexMessages []pb.Message
 	readStates               []ReadState // leader会直接往这里存储； follower 转发MsgReadIndex至leader 的响应
 	readOnly                 *readOnly
+
+	// ReadOnlyLeaseBased 时用于判断leader租约是否有效, 见 leaseValid
+	leaseDriftTicks int
+	leaseTick       uint64            // 本任期经过的tick数, 从1开始
+	leaseAcks       map[uint64]uint64 // follower最近一次响应中带回的、leader发送消息时的 leaseTick
 }
 
 // 通知RawNode 应用程序已经应用并保存了最后一个Ready结果的进度.
@@ -249,6 +254,7 @@ type Config struct {
 	CheckQuorum               bool           // CheckQuorum 检查需要维持的选票数,一旦小于,就会丢失leader
 	PreVote                   bool           // PreVote 防止分区服务器[term会很大]重新加入集群时出现中断   是否启用PreVote
 	ReadOnlyOption            ReadOnlyOption // 必须是enabled if ReadOnlyOption is ReadOnlyLeaseBased.
+	LeaseClockDriftTicks      int            // ReadOnlyLeaseBased 时leader租约比选举超时短的tick数, 需覆盖节点间的时钟漂移和消息延迟
 	DisableProposalForwarding bool           // 禁止将请求转发到leader,默认FALSE
 	Logger                    Logger
 }
@@ -291,6 +297,9 @@ func (c *Config) validate() error {
 	if c.ReadOnlyOption == ReadOnlyLeaseBased && !c.CheckQuorum {
 		return errors.New("如果ReadOnlyOption 是 ReadOnlyLeaseBased 的时候必须开启CheckQuorum")
 	}
+	if c.LeaseClockDriftTicks < 0 || c.LeaseClockDriftTicks >= c.ElectionTick {
+		return errors.New("租约的时钟漂移必须是>=0且小于选举超时")
+	}
 
 	return nil
 }
@@ -322,6 +331,7 @@ func newRaft(c *Config) *raft {
 		preVote:                   c.PreVote,                     // PreVote 是否启用PreVote
 		readOnly:                  newReadOnly(c.ReadOnlyOption), // etcd/etcdserver/over_raft.go:469    默认值0 ReadOnlySafe
 		disableProposalForwarding: c.DisableProposalForwarding,   // 禁止将请求转发到leader,默认FALSE
+		leaseDriftTicks:           c.LeaseClockDriftTicks,
 	}
 	// todo 没看懂
 	// -----------------------
@@ -364,6 +374,8 @@ func (r *raft) sendHeartbeat(to uint64, ctx []byte) {
 		Type:    pb.MsgHeartbeat,
 		Commit:  commit, // leader会为每个Follower都维护一个leaderCommit,表示leader认为Follower已经提交的日志条目索引值
 		Context: ctx,
+		// 租约按发送时的tick计算, 延迟到达的响应不会延长租约
+		LeaseTick: r.leaseTick,
 	}
 	r.send(m)
 }
@@ -457,6 +469,7 @@ func (r *raft) maybeSendAppend(to uint64, sendIfEmpty bool) bool {
 		}
 	}
 	// 7. 发送消息
+	m.LeaseTick = r.leaseTick
 	r.send(m)
 	return true
 }
@@ -471,6 +484,7 @@ func (r *raft) tickHeartbeat() {
 	// 每次tick计时器触发,会调用这个函数
 	r.heartbeatElapsed++
 	r.electionElapsed++
+	r.leaseTick++
 	if r.electionElapsed >= r.electionTimeout { // 如果选举计时超时
 		r.electionElapsed = 0 // 重置计时器
 		if r.checkQuorum {    // 给自己发送一条 MsgCheckQuorum 消息,检测是否出现网络隔离
@@ -540,6 +554,7 @@ func (r *raft) reset(term uint64) {
 	r.pendingConfIndex = 0
 	r.uncommittedSize = 0
 	r.readOnly = newReadOnly(r.readOnly.option) // 只读请求的相关摄者
+	r.resetLease()
 }
 
 // 通过减少记录未提交的条目大小   来处理新提交的条目.
@@ -712,7 +727,7 @@ func (r *raft) handleHeartbeat(m pb.Message) {
 	// 把msg中的commit提交,commit是只增不减的
 	r.raftLog.commitTo(m.Commit) // leader commit 了,follower再commit
 	// 发送Response给Leader   按照raft协议的要求带上自己日志的进度.
-	r.send(pb.Message{To: m.From, Type: pb.MsgHeartbeatResp, Context: m.Context})
+	r.send(pb.Message{To: m.From, Type: pb.MsgHeartbeatResp, Context: m.Context, LeaseTick: m.LeaseTick})
 }
 
 // 非leader角色的 tick函数, 每次逻辑计时器触发就会调用
@@ -732,7 +747,7 @@ func (r *raft) handleAppendEntries(m pb.Message) {
 	// 在leader在发消息时,也会将消息写入本地日志文件中,不会等待follower确认
 	// 判断是否是过时的消息; 日志索引 小于本地已经commit的消息
 	if m.Index < r.raftLog.committed {
-		r.send(pb.Message{To: m.From, Type: pb.MsgAppResp, Index: r.raftLog.committed})
+		r.send(pb.Message{To: m.From, Type: pb.MsgAppResp, Index: r.raftLog.committed, LeaseTick: m.LeaseTick})
 		return
 	}
 	// 会进行一致性检查;尝试将消息携带的Entry记录追加到raftLog中
@@ -740,7 +755,7 @@ func (r *raft) handleAppendEntries(m pb.Message) {
 	// m.Entries... 真正的日志数据
 	if mlastIndex, ok := r.raftLog.maybeAppend(m.Index, m.LogTerm, m.Commit, m.Entries...); ok {
 		// 返回收到的最后一条日志的索引,这样Leader节点就可以根据此值更新其对应的Next和Match值
-		r.send(pb.Message{To: m.From, Type: pb.MsgAppResp, Index: mlastIndex})
+		r.send(pb.Message{To: m.From, Type: pb.MsgAppResp, Index: mlastIndex, LeaseTick: m.LeaseTick})
 	} else {
 		// 收到的日志索引任期不满足以下条件:任期一样,日志索引比lastIndex大1
 
@@ -776,6 +791,7 @@ func (r *raft) handleAppendEntries(m pb.Message) {
 			Reject:     true,
 			RejectHint: hintIndex,
 			LogTerm:    hintTerm,
+			LeaseTick:  m.LeaseTick,
 		})
 	}
 }
@@ -339,6 +339,8 @@ type Message struct {
 	RejectHint uint64 `protobuf:"varint,11,opt,name=rejectHint" json:"rejectHint"` // 拒绝同步日志请求时返回的当前节点日志ID,用于被拒绝方快速定位到下一次合适的同步日志位置
 	// 携带的一些上下文的信息, 例如,campaignTransfer
 	Context []byte `protobuf:"bytes,12,opt,name=context" json:"context,omitempty"`
+	// leader发送MsgApp/MsgSnap/MsgHeartbeat时的tick, follower在响应中原样带回, 用于计算leader租约
+	LeaseTick uint64 `protobuf:"varint,13,opt,name=leaseTick" json:"leaseTick,omitempty"`
 }
 
 func (m *Message) Reset()         { *m = Message{} }
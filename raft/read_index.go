@@ -20,7 +20,12 @@ func releasePendingReadIndexMessages(r *raft) {
 // ReadOnlySafe是ETCD作者推荐的模式,因为这种模式不受节点之间时钟差异和网络分区的影响
 // 线性一致性读用的就是ReadOnlySafe
 func sendMsgReadIndexResponse(r *raft, m pb.Message) {
-	switch r.readOnly.option {
+	option := r.readOnly.option
+	if option == ReadOnlyLeaseBased && !r.leaseValid() {
+		// 租约无法确认时退化为通过心跳确认
+		option = ReadOnlySafe
+	}
+	switch option {
 	// 如果需要更多的地方投票,进行全面的广播.
 	case ReadOnlySafe:
 		//	该线性读模式,每次 Follower 进行读请求时,需要和 Leader 同步日志提交位点信息,而 Leader需要向过半的 Follower 发起证明自己是 Leader 的轻量的 RPC 请求,
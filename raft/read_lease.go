@@ -0,0 +1,43 @@
+package raft
+
+import "github.com/ls-2018/etcd_cn/raft/quorum"
+
+// leaseAckIndexer 把follower最近一次确认的发送tick当作索引, 用于计算多数节点都已确认的tick
+type leaseAckIndexer map[uint64]uint64
+
+func (l leaseAckIndexer) AckedIndex(id uint64) (quorum.Index, bool) {
+	tick, ok := l[id]
+	return quorum.Index(tick), ok
+}
+
+func (r *raft) resetLease() {
+	r.leaseTick = 1
+	r.leaseAcks = make(map[uint64]uint64)
+}
+
+// recordLeaseAck 记录follower的响应; 只有leader调用.
+// tick是响应中带回的leader发送消息时的tick, 而不是响应到达的tick: follower收到消息不早于该tick,
+// 延迟到达的响应不会把租约延长到follower自己的 CheckQuorum 窗口之外.
+// 没有带回tick的响应(例如来自旧版本的follower)不计入租约.
+func (r *raft) recordLeaseAck(id uint64, tick uint64) {
+	if tick == 0 || tick > r.leaseTick {
+		return
+	}
+	if tick > r.leaseAcks[id] {
+		r.leaseAcks[id] = tick
+	}
+}
+
+// leaseValid leader租约是否有效.
+// 开启CheckQuorum时, follower在收到leader消息后的选举超时内不会投票给其他节点;
+// 因此多数节点在最近 electionTimeout - leaseDriftTicks 个tick内响应过时, 不会有新的leader.
+// 确认的tick取leader发送消息时的tick, 不晚于follower收到消息的时间, 只有时钟漂移需由 leaseDriftTicks 覆盖.
+func (r *raft) leaseValid() bool {
+	if r.state != StateLeader {
+		return false
+	}
+	acks := leaseAckIndexer(r.leaseAcks)
+	acks[r.id] = r.leaseTick
+	confirmed := uint64(r.prstrack.Voters.CommittedIndex(acks))
+	return confirmed > 0 && r.leaseTick-confirmed < uint64(r.electionTimeout-r.leaseDriftTicks)
+}
@@ -255,6 +255,7 @@ func stepLeader(r *raft, m pb.Message) error {
 		// 更新对应Progress实例的RecentActive字段,从Leader节点的角度来看,MsgAppResp消息的发送节点还是存活的
 
 		pr.RecentActive = true
+		r.recordLeaseAck(m.From, m.LeaseTick)
 
 		if m.Reject { // MsgApp 消息被拒绝;如果收到的是reject消息,则根据follower反馈的index重新发送日志
 			_ = r.handleAppendEntries // 含有拒绝的逻辑
@@ -331,6 +332,7 @@ func stepLeader(r *raft, m pb.Message) error {
 	case pb.MsgHeartbeatResp:
 		pr.RecentActive = true
 		pr.ProbeSent = false
+		r.recordLeaseAck(m.From, m.LeaseTick)
 
 		// free one slot for the full inflights window to allow progress.
 		if pr.State == tracker.StateReplicate && pr.Inflights.Full() {
@@ -340,7 +342,8 @@ func stepLeader(r *raft, m pb.Message) error {
 			r.sendAppend(m.From)
 		}
 
-		if r.readOnly.option != ReadOnlySafe || len(m.Context) == 0 {
+		// ReadOnlyLeaseBased 在租约无效时同样通过心跳确认, 见 sendMsgReadIndexResponse
+		if len(m.Context) == 0 {
 			return nil
 		}
 		// 判断leader有没有收到大多数节点的确认